@@ -2,14 +2,14 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/url"
 	"os"
-	"path/filepath"
 	"restman/components/config"
 	"restman/utils"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/google/uuid"
@@ -27,11 +27,18 @@ type Auth struct {
 }
 
 type Collection struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Calls   []Call `json:"calls"`
-	BaseUrl string `json:"base_url"`
-	Auth    *Auth  `json:"auth,omitempty"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Calls     []Call            `json:"calls"`
+	Folders   []Folder          `json:"folders,omitempty"`
+	BaseUrl   string            `json:"base_url"`
+	Auth      *Auth             `json:"auth,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+	Scripts   *Scripts          `json:"scripts,omitempty"`
+	// file or URL of the OpenAPI spec the collection was imported from
+	Spec string `json:"spec,omitempty"`
+	// stored in the workspace instead of the config directory
+	workspace bool
 }
 
 func NewCollection() Collection {
@@ -42,6 +49,9 @@ func NewCollection() Collection {
 
 func (i Collection) Title() string { return i.Name }
 func (i Collection) Description() string {
+	if i.workspace {
+		return "󰊢 " + i.BaseUrl
+	}
 	if i.BaseUrl != "" {
 		return i.BaseUrl
 	}
@@ -95,22 +105,34 @@ func (i *Collection) ValidatePartial(fields ...string) []string {
 }
 
 type Call struct {
-	ID       string   `json:"id"`
-	Name     string   `json:"name"`
-	Url      string   `json:"url"`
-	Method   string   `json:"method"`
-	Headers  []string `json:"headers"`
-	Auth     *Auth    `json:"auth"`
-	Data     string   `json:"data"`
-	DataType string   `json:"data_type"`
-	hash     string
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Url         string       `json:"url"`
+	Method      string       `json:"method"`
+	Folder      string       `json:"folder,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Params      []Param      `json:"params,omitempty"`
+	PathParams  []PathParam  `json:"path_params,omitempty"`
+	Headers     Headers      `json:"headers"`
+	Auth        *Auth        `json:"auth"`
+	Data        string       `json:"data"`
+	DataType    string       `json:"data_type"`
+	GraphQL     *GraphQL     `json:"graphql,omitempty"`
+	GRPC        *GRPC        `json:"grpc,omitempty"`
+	Assertions  []Assertion  `json:"assertions,omitempty"`
+	Extractions []Extraction `json:"extractions,omitempty"`
+	Scripts     *Scripts     `json:"scripts,omitempty"`
+	Examples    []Response   `json:"examples,omitempty"`
+	hash        string
+	// variables set on the call which are not applied yet
+	scope *variableScope
 }
 
 func NewCall() *Call {
 	return &Call{
 		ID:      uuid.NewString(),
 		Method:  "GET",
-		Headers: []string{},
+		Headers: Headers{},
 	}
 }
 
@@ -151,17 +173,14 @@ func (i Call) Title() string {
 	return "untitled"
 }
 
+// HeadersCount returns the number of enabled headers
 func (i Call) HeadersCount() int {
-	return len(i.Headers)
+	return len(i.Headers.Enabled())
 }
 
+// ParamsCount returns the number of enabled query params
 func (i Call) ParamsCount() int {
-	items := make(map[string][]string)
-	u, err := url.Parse(i.Url)
-	if err == nil && i.Url != "" {
-		items, _ = url.ParseQuery(u.RawQuery)
-	}
-	return len(items)
+	return len(ParseParams(i.Url))
 }
 
 func (i Call) IsValid() bool {
@@ -184,22 +203,83 @@ func (i Call) Collection() *Collection {
 }
 
 func (i Call) GetUrl() string {
+	url := SubstitutePathParams(i.Url, i.PathParams)
 	if i.Collection() != nil {
-		return strings.Replace(i.Url, "{{BASE_URL}}", i.Collection().BaseUrl, 1)
+		url = strings.Replace(url, "{{BASE_URL}}", i.Collection().BaseUrl, 1)
 	}
-	return i.Url
+	return utils.SubstituteVariables(url, GetInstance().Variables(&i))
 }
 
 func (i Call) GetAuth() *Auth {
 	if i.Auth != nil && i.Auth.Type == "inherit" {
-		if i.Collection() != nil {
-			return i.Collection().Auth
+		if collection := i.Collection(); collection != nil {
+			return collection.inheritedAuth(i.Folder)
 		}
 	}
 	return i.Auth
 }
 
+// RequestParams builds the parameters of the HTTP request described by the
+// call, with variables substituted
+func (i Call) RequestParams() utils.HTTPRequestParams {
+	variables := GetInstance().Variables(&i)
+	substitute := func(s string) string {
+		return utils.SubstituteVariables(s, variables)
+	}
+
+	// headers of the call override the ones of its folders
+	headers := make(map[string]string)
+	for _, h := range append(i.inheritedHeaders(), i.Headers.Enabled()...) {
+		headers[substitute(h.Key)] = substitute(h.Value)
+	}
+
+	params := utils.HTTPRequestParams{
+		Method:  i.Method,
+		URL:     i.GetUrl(),
+		Headers: headers}
+
+	if i.DataType == "GraphQL" && i.GraphQL != nil {
+		// variables which are not valid JSON are left out, the editor
+		// reports them before the request is sent
+		body, err := i.GraphQL.Body()
+		if err != nil {
+			body, _ = GraphQL{Query: i.GraphQL.Query, OperationName: i.GraphQL.OperationName}.Body()
+		}
+		params.Body = strings.NewReader(substitute(body))
+		if _, ok := params.Headers["Content-Type"]; !ok {
+			params.Headers["Content-Type"] = "application/json"
+		}
+	} else if i.Data != "" {
+		params.Body = strings.NewReader(substitute(i.Data))
+	}
+
+	auth := i.GetAuth()
+	if auth != nil {
+		if auth.Type == "basic_auth" {
+			params.Username = substitute(auth.Username)
+			params.Password = substitute(auth.Password)
+		} else if auth.Type == "bearer_token" {
+			params.Headers["Authorization"] = fmt.Sprintf("Bearer %s", substitute(auth.Token))
+		}
+	}
+	return params
+}
+
+func (i Call) IsWebSocket() bool {
+	return IsWebSocketUrl(i.GetUrl())
+}
+
+func (i Call) IsGRPC() bool {
+	return IsGRPCUrl(i.GetUrl())
+}
+
 func (i Call) MethodShortView() string {
+	if i.IsWebSocket() {
+		return config.MethodsShort[config.WS]
+	}
+	if i.IsGRPC() {
+		return config.MethodsShort[config.GRPC]
+	}
 	return config.MethodsShort[i.Method]
 }
 
@@ -208,9 +288,26 @@ func (i Call) Description() string { return i.Method }
 func (i Call) FilterValue() string { return i.Url }
 
 type App struct {
-	SelectedCollection *Collection
-	SelectedCall       *Call
-	Collections        []Collection
+	SelectedCollection  *Collection
+	SelectedCall        *Call
+	Collections         []Collection
+	Environments        []Environment
+	SelectedEnvironment *Environment
+	// ids of the calls with unsaved edits
+	unsaved map[string]bool
+	// workspace of the project restman runs in, if there is one
+	workspace string
+	// set when collections.json cannot be read, it is not written then
+	storageErr error
+	// set when a file of the workspace cannot be read, it is not written
+	// then
+	workspaceErr error
+	// files of the workspace as last read or written, see saveWorkspace
+	workspaceFiles map[string]bool
+	// the collections as last read from or written to disk
+	base snapshot
+	// changes of the collections on disk, see WatchCollections
+	changes chan struct{}
 }
 
 var instance *App
@@ -225,15 +322,30 @@ func GetInstance() *App {
 
 // Read collections from a JSON file
 func (a *App) ReadCollectionsFromJSON() tea.Cmd {
-	configDir, _ := os.UserConfigDir()
+	err := a.LoadCollections()
+	return func() tea.Msg {
+		return FetchCollectionsSuccessMsg{Collections: a.Collections, Err: err}
+	}
+}
 
-	os.MkdirAll(filepath.Join(configDir, "restman"), os.ModePerm)
+// LoadCollections reads the global collections, the ones of the workspace
+// and the environments. The collections which could be read are loaded
+// even when an error is returned.
+func (a *App) LoadCollections() error {
+	keyErr := a.UnlockSecretsWithKeyFile()
+	collections, err := a.readCollectionsFile()
+	err = errors.Join(keyErr, err)
+	a.Collections = collections
+	a.ReadEnvironments()
 
-	file, err := os.ReadFile(filepath.Join(configDir, "restman", "collections.json"))
-	if err != nil {
-		fmt.Println(err)
+	// collections of the project are shown next to the global ones
+	cwd, _ := os.Getwd()
+	a.workspaceErr, a.workspaceFiles = nil, nil
+	if a.workspace = FindWorkspace(cwd); a.workspace != "" {
+		collections, workspaceErr := a.loadWorkspace()
+		a.Collections = append(a.Collections, collections...)
+		err = errors.Join(err, workspaceErr)
 	}
-	json.Unmarshal(file, &a.Collections)
 
 	// filePath := "/home/jackmort/programming/gotest/petstorev3.json" // Replace with your OpenAPI spec file path
 	// collection, err := ImportOpenAPISpec(filePath)
@@ -247,10 +359,8 @@ func (a *App) ReadCollectionsFromJSON() tea.Cmd {
 			a.Collections[i].Calls[j].hash = utils.ComputeHash(call)
 		}
 	}
-
-	return func() tea.Msg {
-		return FetchCollectionsSuccessMsg{Collections: a.Collections}
-	}
+	a.base = takeSnapshot(a.Collections)
+	return err
 }
 
 func (a *App) ImportCollectionFromUrl(url string) tea.Cmd {
@@ -269,6 +379,7 @@ func (a *App) ImportCollectionFromUrl(url string) tea.Cmd {
 		println(err)
 		return nil
 	}
+	collection.Spec = url
 
 	return a.CreateCollection(*collection)
 }
@@ -318,7 +429,38 @@ func (a *App) SetCallAuthValue(call *Call, key string, value string) tea.Cmd {
 	return a.UpdateCall(call)
 }
 
+func (a *App) SetCallTests(call *Call, assertions []Assertion, extractions []Extraction) tea.Cmd {
+	call.Assertions = assertions
+	call.Extractions = extractions
+	return a.UpdateCall(call)
+}
+
+// SetCallScripts sets the scripts of the call, or of its collection when
+// collection is true
+func (a *App) SetCallScripts(call *Call, scripts Scripts, collection bool) tea.Cmd {
+	var saved *Scripts
+	if scripts != (Scripts{}) {
+		saved = &scripts
+	}
+	if !collection {
+		call.Scripts = saved
+		return a.UpdateCall(call)
+	}
+	if c := a.findCollection(call); c != nil {
+		c.Scripts = saved
+		return a.SaveCollections()
+	}
+	return nil
+}
+
 func (a *App) UpdateCall(call *Call) tea.Cmd {
+	// the folder is changed by moving the call, which may have happened
+	// while it was open
+	if saved := a.findCall(call.ID); saved != nil {
+		call.Folder = saved.Folder
+	}
+	// the call is saved as a whole, it has no unsaved changes anymore
+	call.hash = utils.ComputeHash(*call)
 	for i, collection := range a.Collections {
 		for j, c := range collection.Calls {
 			if c.ID == call.ID {
@@ -348,55 +490,62 @@ func (a *App) GetResponse(call *Call) tea.Cmd {
 		},
 		// fetch response
 		func() tea.Msg {
-			headers := make(map[string]string)
-			for _, h := range call.Headers {
-				header := strings.Split(h, ":")
-				if len(header) > 1 {
-					headers[header[0]] = header[1]
-				}
-			}
+			// a new request replaces the event stream or socket of the
+			// call, there is no need to report them closed
+			a.StopStream(call)
+			closeSocket(call)
 
-			params := utils.HTTPRequestParams{
-				Method:  call.Method,
-				URL:     call.GetUrl(),
-				Headers: headers}
-
-			if call.Data != "" {
-				params.Body = strings.NewReader(call.Data)
+			if err := call.MissingPathParams(); err != nil {
+				return OnResponseMsg{Call: call, Err: err}
 			}
-
-			auth := call.GetAuth()
-			if auth != nil {
-				if auth.Type == "basic_auth" {
-					params.Username = auth.Username
-					params.Password = auth.Password
-				} else if auth.Type == "bearer_token" {
-					params.Headers["Authorization"] = fmt.Sprintf("Bearer %s", auth.Token)
-				}
+			if call.IsWebSocket() {
+				return waitForResponse(a.connectWebSocket(call))()
+			}
+			if call.IsGRPC() {
+				return waitForResponse(a.startGRPC(call))()
 			}
 
+			// the variables set by the scripts are applied with the response
+			sent := forkScope(call)
+			params := sent.RequestParams()
+			if err := a.runPreRequestScripts(sent, &params); err != nil {
+				return OnResponseMsg{Call: call, Err: err}
+			}
+			r := startRequest(call)
+			params.Context = r
+			started := time.Now()
 			response, err := utils.MakeRequest(params)
-			if err == nil {
-				defer response.Body.Close()
-				body, err := io.ReadAll(response.Body)
-				// get response size in bytes
-				bytes := int64(len(body))
-				return OnResponseMsg{Call: call, Body: string(body), Bytes: bytes, Err: err, Response: response}
+			if err != nil {
+				r.release()
+				// a newer request of the call replaced this one
+				if r.Err() != nil {
+					return nil
+				}
+				return OnResponseMsg{Call: call, Err: err, Response: response}
 			}
-			return OnResponseMsg{Call: call, Err: err, Response: response}
+			if isEventStream(response) {
+				return waitForResponse(a.streamEvents(r, call, response))()
+			}
+			// stream the body so large downloads report progress
+			return waitForResponse(a.streamResponse(r, call, sent, response, started))()
 		})
 }
 
+// Disconnect stops the event stream or gRPC call and closes the WebSocket
+// connection of the call, e.g. when its tab is closed
+func (a *App) Disconnect(call *Call) {
+	a.StopStream(call)
+	closeSocket(call)
+}
+
+// CreateCollection adds a collection, it is stored in the workspace when
+// there is one
 func (a *App) CreateCollection(collection Collection) tea.Cmd {
 	collection.ID = uuid.NewString()
+	collection.workspace = a.workspace != ""
 	return func() tea.Msg {
-		configDir, _ := os.UserConfigDir()
 		a.Collections = append(a.Collections, collection)
-
-		file, _ := json.MarshalIndent(a.Collections, "", " ")
-		_ = os.WriteFile(filepath.Join(configDir, "restman", "collections.json"), file, 0644)
-
-		return FetchCollectionsSuccessMsg{Collections: a.Collections}
+		return a.syncCollections(ResolveAsk, false)
 	}
 }
 
@@ -415,14 +564,35 @@ func (a *App) UpdateCollection(collection Collection) tea.Cmd {
 // TODO refactor
 func (a *App) SaveCollections() tea.Cmd {
 	return func() tea.Msg {
-		configDir, _ := os.UserConfigDir()
+		return a.syncCollections(ResolveAsk, false)
+	}
+}
 
-		os.MkdirAll(filepath.Join(configDir, "restman"), os.ModePerm)
-		file, _ := json.MarshalIndent(a.Collections, "", " ")
-		_ = os.WriteFile(filepath.Join(configDir, "restman", "collections.json"), file, 0644)
+// writeCollections stores the global collections in the config directory
+// and the ones of the project in its workspace
+func (a *App) writeCollections() error {
+	global := []Collection{}
+	shared := []Collection{}
+	for _, collection := range a.storable(a.Collections) {
+		if collection.workspace {
+			shared = append(shared, collection)
+		} else {
+			global = append(global, collection)
+		}
+	}
 
-		return FetchCollectionsSuccessMsg{Collections: a.Collections}
+	// the collections on disk could not all be merged, nothing is written
+	if a.workspace != "" && a.workspaceErr != nil {
+		return a.workspaceErr
 	}
+	if err := a.writeCollectionsFile(global); err != nil {
+		return err
+	}
+
+	if a.workspace == "" {
+		return nil
+	}
+	return a.writeWorkspace(shared)
 }
 
 func (a *App) GetOrCreateCollection(name string) *Collection {
@@ -433,6 +603,7 @@ func (a *App) GetOrCreateCollection(name string) *Collection {
 	}
 	collection := NewCollection()
 	collection.Name = name
+	collection.workspace = a.workspace != ""
 	a.Collections = append(a.Collections, collection)
 	return &collection
 }
@@ -442,6 +613,7 @@ func (a *App) AddToCollection(
 	call *Call,
 ) tea.Cmd {
 	collection := a.GetOrCreateCollection(collectionName)
+	call.hash = utils.ComputeHash(*call)
 
 	// if call already exists in collection update if not append
 	var exists bool
@@ -498,6 +670,7 @@ func ImportOpenAPISpec(filePath string) (*Collection, error) {
 		Name:    doc.Info.Title,
 		BaseUrl: getBaseUrl(doc),
 		Calls:   []Call{},
+		Spec:    filePath,
 	}
 
 	// Iterate over paths in matching order
@@ -507,14 +680,15 @@ func ImportOpenAPISpec(filePath string) (*Collection, error) {
 		for method, operation := range item.Operations() {
 			data, dataType := extractRequestBodyData(doc, operation)
 			call := Call{
-				ID:       operation.OperationID,
-				Name:     operation.Summary,
-				Url:      genereatePartialUrl(path),
-				Method:   method,
-				Headers:  extractHeaders(operation),
-				Auth:     extractAuth(doc, operation),
-				Data:     data,
-				DataType: dataType,
+				ID:         operation.OperationID,
+				Name:       operation.Summary,
+				Url:        genereatePartialUrl(path),
+				Method:     method,
+				Headers:    extractHeaders(operation),
+				PathParams: extractPathParams(item, operation),
+				Auth:       extractAuth(doc, operation),
+				Data:       data,
+				DataType:   dataType,
 			}
 			collection.Calls = append(collection.Calls, call)
 		}
@@ -648,16 +822,41 @@ func generateExampleFromSchema(doc *openapi3.T, schema *openapi3.Schema) (string
 	return "", nil
 }
 
-func extractHeaders(operation *openapi3.Operation) []string {
-	headers := []string{}
+func extractHeaders(operation *openapi3.Operation) Headers {
+	headers := Headers{}
 	for _, param := range operation.Parameters {
 		if param.Value.In == "header" {
-			headers = append(headers, param.Value.Name)
+			headers = append(headers, Header{Key: param.Value.Name})
 		}
 	}
 	return headers
 }
 
+// extractPathParams returns the path parameters of the operation with
+// their descriptions and defaults, parameters of the path item included
+func extractPathParams(item *openapi3.PathItem, operation *openapi3.Operation) []PathParam {
+	params := []PathParam{}
+	for _, parameters := range []openapi3.Parameters{item.Parameters, operation.Parameters} {
+		for _, param := range parameters {
+			if param.Value == nil || param.Value.In != "path" {
+				continue
+			}
+			pathParam := PathParam{Name: param.Value.Name, Description: param.Value.Description}
+			if param.Value.Schema != nil && param.Value.Schema.Value != nil && param.Value.Schema.Value.Default != nil {
+				pathParam.Default = fmt.Sprint(param.Value.Schema.Value.Default)
+			}
+			if param.Value.Example != nil && pathParam.Default == "" {
+				pathParam.Default = fmt.Sprint(param.Value.Example)
+			}
+			params = append(params, pathParam)
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
 func extractAuth(doc *openapi3.T, operation *openapi3.Operation) *Auth {
 	if operation.Security != nil {
 		for _, security := range *operation.Security {
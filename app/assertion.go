@@ -0,0 +1,383 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"restman/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ASSERT_STATUS   = "status"
+	ASSERT_HEADER   = "header"
+	ASSERT_JSONPATH = "jsonpath"
+	ASSERT_BODY     = "body"
+	ASSERT_TIME     = "time"
+	ASSERT_SIZE     = "size"
+)
+
+// operators accepted by every subject
+var assertionOperators = map[string][]string{
+	ASSERT_STATUS:   {"==", "in"},
+	ASSERT_HEADER:   {"exists", "==", "matches"},
+	ASSERT_JSONPATH: {"exists", "==", "matches", "type"},
+	ASSERT_BODY:     {"contains"},
+	ASSERT_TIME:     {"<"},
+	ASSERT_SIZE:     {"<"},
+}
+
+// Assertion is a check run against every response of a call. It is written
+// as a single line, e.g.
+//
+//	status in 200..299
+//	header Content-Type matches ^application/json
+//	jsonpath $.items[0].id == 42
+//	jsonpath $.items type array
+//	body contains "ok"
+//	time < 500
+//	size < 1024
+type Assertion struct {
+	Subject  string `json:"subject"`
+	Target   string `json:"target,omitempty"`
+	Operator string `json:"operator"`
+	Value    string `json:"value,omitempty"`
+}
+
+// AssertionResult is the outcome of an assertion for one response
+type AssertionResult struct {
+	Assertion Assertion
+	Passed    bool
+	Message   string
+}
+
+func (a Assertion) String() string {
+	parts := []string{a.Subject}
+	if a.Target != "" {
+		parts = append(parts, a.Target)
+	}
+	if a.Operator != "" {
+		parts = append(parts, a.Operator)
+	}
+	if a.Value != "" {
+		parts = append(parts, a.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// nextField returns the first whitespace separated field of s, brackets and
+// quotes are kept together so paths like $['a key'] are a single field
+func nextField(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	depth := 0
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case (r == ' ' || r == '\t') && depth <= 0:
+			return s[:i], strings.TrimSpace(s[i:])
+		}
+	}
+	return s, ""
+}
+
+// ParseAssertion reads an assertion from its single line form
+func ParseAssertion(line string) (Assertion, error) {
+	var a Assertion
+	var rest string
+	a.Subject, rest = nextField(line)
+	a.Subject = strings.ToLower(a.Subject)
+
+	operators, ok := assertionOperators[a.Subject]
+	if !ok {
+		return a, fmt.Errorf("unknown assertion %q, use one of status, header, jsonpath, body, time or size", a.Subject)
+	}
+
+	if a.Subject == ASSERT_HEADER || a.Subject == ASSERT_JSONPATH {
+		a.Target, rest = nextField(rest)
+		if a.Target == "" {
+			return a, fmt.Errorf("%s name is missing", a.Subject)
+		}
+		if a.Subject == ASSERT_JSONPATH {
+			if _, err := utils.JSONPath(nil, a.Target); err != nil {
+				return a, err
+			}
+		}
+	}
+
+	a.Operator, a.Value = nextField(rest)
+	known := false
+	for _, operator := range operators {
+		known = known || operator == a.Operator
+	}
+	if !known {
+		return a, fmt.Errorf("%s expects one of %s", a.Subject, strings.Join(operators, ", "))
+	}
+	if a.Operator != "exists" && a.Value == "" {
+		return a, fmt.Errorf("value is missing after %s", a.Operator)
+	}
+
+	switch a.Operator {
+	case "matches":
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return a, err
+		}
+	case "type":
+		switch a.Value {
+		case "string", "number", "boolean", "object", "array", "null":
+		default:
+			return a, fmt.Errorf("unknown type %q", a.Value)
+		}
+	case "in":
+		if _, _, err := statusRange(a.Value); err != nil {
+			return a, err
+		}
+	case "<":
+		if a.Subject == ASSERT_TIME {
+			if _, err := ParseMilliseconds(a.Value); err != nil {
+				return a, err
+			}
+		} else if _, err := strconv.ParseInt(a.Value, 10, 64); err != nil {
+			return a, fmt.Errorf("size must be a number of bytes")
+		}
+	}
+	if a.Subject == ASSERT_STATUS && a.Operator == "==" {
+		if _, err := strconv.Atoi(a.Value); err != nil {
+			return a, fmt.Errorf("status must be a number")
+		}
+	}
+	return a, nil
+}
+
+// ParseAssertions reads one assertion per line, skipping blank lines and
+// comments. Errors are returned per line number, starting at 1.
+func ParseAssertions(text string) ([]Assertion, map[int]error) {
+	assertions := []Assertion{}
+	errors := map[int]error{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		assertion, err := ParseAssertion(line)
+		if err != nil {
+			errors[i+1] = err
+			continue
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions, errors
+}
+
+// FormatAssertions writes assertions in the form read by ParseAssertions
+func FormatAssertions(assertions []Assertion) string {
+	lines := []string{}
+	for _, a := range assertions {
+		lines = append(lines, a.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statusRange accepts 200..299, 2xx or a single code
+func statusRange(value string) (int, int, error) {
+	if strings.HasSuffix(strings.ToLower(value), "xx") && len(value) == 3 {
+		n, err := strconv.Atoi(value[:1])
+		if err == nil {
+			return n * 100, n*100 + 99, nil
+		}
+	}
+	from, to, found := strings.Cut(value, "..")
+	low, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status range %q, use 200..299 or 2xx", value)
+	}
+	if !found {
+		return low, low, nil
+	}
+	high, err := strconv.Atoi(to)
+	if err != nil || high < low {
+		return 0, 0, fmt.Errorf("invalid status range %q, use 200..299 or 2xx", value)
+	}
+	return low, high, nil
+}
+
+// ParseMilliseconds accepts a plain number of milliseconds or a duration
+func ParseMilliseconds(value string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use milliseconds or a duration like 1.5s", value)
+	}
+	return d, nil
+}
+
+// expectedValue decodes a JSON literal, anything else is taken as a string
+func expectedValue(value string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+	return v
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "null"
+}
+
+// unquote removes the quotes around a value, if any
+func unquote(value string) string {
+	if s, err := strconv.Unquote(value); err == nil {
+		return s
+	}
+	return value
+}
+
+// Evaluate checks the assertion against a response received after the given
+// duration
+func (a Assertion) Evaluate(response Response, duration time.Duration, size int64) AssertionResult {
+	result := AssertionResult{Assertion: a, Passed: true}
+	fail := func(format string, args ...interface{}) AssertionResult {
+		result.Passed = false
+		result.Message = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	switch a.Subject {
+	case ASSERT_STATUS:
+		low, high, err := statusRange(a.Value)
+		if err != nil {
+			return fail("%s", err)
+		}
+		if response.Status < low || response.Status > high {
+			return fail("got status %d", response.Status)
+		}
+
+	case ASSERT_HEADER:
+		values := response.Headers.Values(a.Target)
+		if len(values) == 0 {
+			return fail("header %s is missing", a.Target)
+		}
+		value := strings.Join(values, ", ")
+		switch a.Operator {
+		case "==":
+			if value != unquote(a.Value) {
+				return fail("got %q", value)
+			}
+		case "matches":
+			pattern, err := regexp.Compile(a.Value)
+			if err != nil {
+				return fail("%s", err)
+			}
+			if !pattern.MatchString(value) {
+				return fail("%q does not match", value)
+			}
+		}
+
+	case ASSERT_JSONPATH:
+		var document interface{}
+		if err := json.Unmarshal([]byte(response.Content()), &document); err != nil {
+			return fail("body is not JSON")
+		}
+		matches, err := utils.JSONPath(document, a.Target)
+		if err != nil {
+			return fail("%s", err)
+		}
+		if len(matches) == 0 {
+			return fail("%s not found", a.Target)
+		}
+		var actual interface{} = matches
+		if len(matches) == 1 {
+			actual = matches[0]
+		}
+
+		switch a.Operator {
+		case "==":
+			if !reflect.DeepEqual(actual, expectedValue(a.Value)) {
+				return fail("got %s", utils.FormatValue(actual))
+			}
+		case "matches":
+			text, ok := actual.(string)
+			if !ok {
+				text = utils.FormatValue(actual)
+			}
+			pattern, err := regexp.Compile(a.Value)
+			if err != nil {
+				return fail("%s", err)
+			}
+			if !pattern.MatchString(text) {
+				return fail("%s does not match", utils.FormatValue(actual))
+			}
+		case "type":
+			if jsonType(actual) != a.Value {
+				return fail("got %s", jsonType(actual))
+			}
+		}
+
+	case ASSERT_BODY:
+		if !strings.Contains(response.Content(), unquote(a.Value)) {
+			return fail("body does not contain %s", a.Value)
+		}
+
+	case ASSERT_TIME:
+		limit, err := ParseMilliseconds(a.Value)
+		if err != nil {
+			return fail("%s", err)
+		}
+		if duration >= limit {
+			return fail("took %s", duration.Round(time.Millisecond))
+		}
+
+	case ASSERT_SIZE:
+		limit, _ := strconv.ParseInt(a.Value, 10, 64)
+		if size >= limit {
+			return fail("got %s", utils.ByteCountIEC(size))
+		}
+	}
+	return result
+}
+
+// EvaluateAssertions runs every assertion of the call against a response
+func EvaluateAssertions(assertions []Assertion, response Response, duration time.Duration, size int64) []AssertionResult {
+	results := []AssertionResult{}
+	for _, a := range assertions {
+		results = append(results, a.Evaluate(response, duration, size))
+	}
+	return results
+}
+
+// CountPassed returns how many of the results passed
+func CountPassed(results []AssertionResult) int {
+	passed := 0
+	for _, r := range results {
+		if r.Passed {
+			passed++
+		}
+	}
+	return passed
+}
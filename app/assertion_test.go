@@ -0,0 +1,139 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		line string
+		want Assertion
+		err  string
+	}{
+		{"status == 200", Assertion{Subject: "status", Operator: "==", Value: "200"}, ""},
+		{"Status in 2xx", Assertion{Subject: "status", Operator: "in", Value: "2xx"}, ""},
+		{"header Content-Type exists", Assertion{Subject: "header", Target: "Content-Type", Operator: "exists"}, ""},
+		{"header X-Id matches ^[0-9]+$", Assertion{Subject: "header", Target: "X-Id", Operator: "matches", Value: "^[0-9]+$"}, ""},
+		{"jsonpath $['a key'][0] == \"x y\"", Assertion{Subject: "jsonpath", Target: "$['a key'][0]", Operator: "==", Value: "\"x y\""}, ""},
+		{"body contains hello world", Assertion{Subject: "body", Operator: "contains", Value: "hello world"}, ""},
+		{"time < 1.5s", Assertion{Subject: "time", Operator: "<", Value: "1.5s"}, ""},
+		{"size < 1024", Assertion{Subject: "size", Operator: "<", Value: "1024"}, ""},
+		{"latency < 5", Assertion{}, "unknown assertion"},
+		{"status > 200", Assertion{}, "status expects one of"},
+		{"status in 300..200", Assertion{}, "invalid status range"},
+		{"jsonpath items == 1", Assertion{}, "path must start with $"},
+		{"jsonpath $.a type text", Assertion{}, "unknown type"},
+		{"header X-Id matches (", Assertion{}, "error parsing regexp"},
+		{"body contains", Assertion{}, "value is missing"},
+		{"size < 1kb", Assertion{}, "size must be a number"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseAssertion(test.line)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseAssertion(%q) error = %v, want %q", test.line, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAssertion(%q) error = %v", test.line, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseAssertion(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+		if again, _ := ParseAssertion(got.String()); again != got {
+			t.Errorf("ParseAssertion(%q) does not round trip, got %+v", got.String(), again)
+		}
+	}
+}
+
+func TestParseAssertions(t *testing.T) {
+	assertions, errors := ParseAssertions("# comment\nstatus == 200\n\nbogus\nsize < 10\n")
+	if len(assertions) != 2 {
+		t.Errorf("ParseAssertions() = %v, want 2 assertions", assertions)
+	}
+	if len(errors) != 1 || errors[4] == nil {
+		t.Errorf("ParseAssertions() errors = %v, want an error on line 4", errors)
+	}
+}
+
+func TestEvaluateAssertions(t *testing.T) {
+	response := Response{
+		Status:  201,
+		Headers: http.Header{"Content-Type": {"application/json"}},
+		Body:    `{"id": 42, "name": "restman", "tags": ["a", "b"], "owner": null}`,
+	}
+
+	tests := []struct {
+		line   string
+		passed bool
+	}{
+		{"status == 201", true},
+		{"status == 200", false},
+		{"status in 200..299", true},
+		{"status in 3xx", false},
+		{"header content-type exists", true},
+		{"header X-Missing exists", false},
+		{"header Content-Type == application/json", true},
+		{"header Content-Type matches ^text/", false},
+		{"jsonpath $.id == 42", true},
+		{"jsonpath $.id == 43", false},
+		{"jsonpath $.name == restman", true},
+		{"jsonpath $.name == \"restman\"", true},
+		{"jsonpath $.tags == [\"a\",\"b\"]", true},
+		{"jsonpath $.tags[*] == [\"a\",\"b\"]", true},
+		{"jsonpath $.name matches ^rest", true},
+		{"jsonpath $.id matches ^4", true},
+		{"jsonpath $.tags type array", true},
+		{"jsonpath $.owner type null", true},
+		{"jsonpath $.id type string", false},
+		{"jsonpath $.missing exists", false},
+		{"body contains \"restman\"", true},
+		{"body contains nope", false},
+		{"time < 100", true},
+		{"time < 10", false},
+		{"size < 100", true},
+		{"size < 10", false},
+	}
+
+	for _, test := range tests {
+		assertion, err := ParseAssertion(test.line)
+		if err != nil {
+			t.Fatalf("ParseAssertion(%q) error = %v", test.line, err)
+		}
+		result := assertion.Evaluate(response, 50*time.Millisecond, int64(len(response.Body)))
+		if result.Passed != test.passed {
+			t.Errorf("%q passed = %v, want %v (%s)", test.line, result.Passed, test.passed, result.Message)
+		}
+		if !result.Passed && result.Message == "" {
+			t.Errorf("%q failed without a message", test.line)
+		}
+	}
+}
+
+func TestEvaluateInvalidPattern(t *testing.T) {
+	response := Response{Status: 200, Headers: http.Header{"Content-Type": {"application/json"}}, Body: `{"id": 42}`}
+
+	// hand edited collections skip the validation of ParseAssertion
+	for _, assertion := range []Assertion{
+		{Subject: ASSERT_HEADER, Target: "Content-Type", Operator: "matches", Value: "("},
+		{Subject: ASSERT_JSONPATH, Target: "$.id", Operator: "matches", Value: "("},
+	} {
+		result := assertion.Evaluate(response, 0, 0)
+		if result.Passed || !strings.Contains(result.Message, "missing closing )") {
+			t.Errorf("Evaluate() = %+v, want failure with the compile error", result)
+		}
+	}
+}
+
+func TestCountPassed(t *testing.T) {
+	results := []AssertionResult{{Passed: true}, {Passed: false}, {Passed: true}}
+	if got := CountPassed(results); got != 2 {
+		t.Errorf("CountPassed() = %d, want 2", got)
+	}
+}
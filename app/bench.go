@@ -0,0 +1,308 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"restman/utils"
+	"sort"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BenchOptions say how often a call is sent by a benchmark
+type BenchOptions struct {
+	// number of requests, ignored when a duration is given
+	Requests    int
+	Duration    time.Duration
+	Concurrency int
+	// requests per second over all workers, 0 sends as fast as possible
+	Rate float64
+}
+
+// MaxBenchRate is the highest rate of a benchmark, a request every
+// nanosecond
+const MaxBenchRate = 1e9
+
+// BenchStats are the results of a benchmark so far
+type BenchStats struct {
+	Elapsed   time.Duration
+	Requests  int
+	Bytes     int64
+	Statuses  map[int]int
+	Errors    map[string]int
+	Latencies []time.Duration // sorted
+}
+
+// BenchBucket is a bar of the latency histogram
+type BenchBucket struct {
+	From  time.Duration
+	To    time.Duration
+	Count int
+}
+
+// Throughput returns the completed requests per second
+func (s BenchStats) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Requests) / s.Elapsed.Seconds()
+}
+
+// ErrorCount returns the number of requests which got no response
+func (s BenchStats) ErrorCount() int {
+	count := 0
+	for _, n := range s.Errors {
+		count += n
+	}
+	return count
+}
+
+// Percentile returns the latency p percent of the responses were faster
+// than or as fast as, p is between 0 and 100
+func (s BenchStats) Percentile(p float64) time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(s.Latencies))))
+	return s.Latencies[min(max(rank-1, 0), len(s.Latencies)-1)]
+}
+
+// Mean returns the average latency
+func (s BenchStats) Mean() time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range s.Latencies {
+		total += latency
+	}
+	return total / time.Duration(len(s.Latencies))
+}
+
+// Histogram splits the latencies from the fastest to the slowest response
+// into buckets of the same width
+func (s BenchStats) Histogram(buckets int) []BenchBucket {
+	if len(s.Latencies) == 0 || buckets < 1 {
+		return nil
+	}
+	fastest, slowest := s.Latencies[0], s.Latencies[len(s.Latencies)-1]
+	width := (slowest - fastest) / time.Duration(buckets)
+	if width <= 0 {
+		return []BenchBucket{{From: fastest, To: slowest, Count: len(s.Latencies)}}
+	}
+
+	histogram := make([]BenchBucket, buckets)
+	for i := range histogram {
+		histogram[i].From = fastest + time.Duration(i)*width
+		histogram[i].To = histogram[i].From + width
+	}
+	histogram[buckets-1].To = slowest
+	for _, latency := range s.Latencies {
+		i := min(int((latency-fastest)/width), buckets-1)
+		histogram[i].Count++
+	}
+	return histogram
+}
+
+// benchRecorder collects the results of the workers of a benchmark
+type benchRecorder struct {
+	sync.Mutex
+	started   time.Time
+	requests  int
+	bytes     int64
+	statuses  map[int]int
+	errors    map[string]int
+	latencies []time.Duration
+}
+
+func newBenchRecorder() *benchRecorder {
+	return &benchRecorder{started: time.Now(), statuses: map[int]int{}, errors: map[string]int{}}
+}
+
+func (r *benchRecorder) record(latency time.Duration, status int, size int64, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.requests++
+	if err != nil {
+		r.errors[err.Error()]++
+		return
+	}
+	r.statuses[status]++
+	r.bytes += size
+	r.latencies = append(r.latencies, latency)
+}
+
+func (r *benchRecorder) snapshot() BenchStats {
+	r.Lock()
+	defer r.Unlock()
+	stats := BenchStats{
+		Elapsed:   time.Since(r.started),
+		Requests:  r.requests,
+		Bytes:     r.bytes,
+		Statuses:  make(map[int]int, len(r.statuses)),
+		Errors:    make(map[string]int, len(r.errors)),
+		Latencies: append([]time.Duration{}, r.latencies...),
+	}
+	for status, n := range r.statuses {
+		stats.Statuses[status] = n
+	}
+	for err, n := range r.errors {
+		stats.Errors[err] = n
+	}
+	sort.Slice(stats.Latencies, func(i, j int) bool { return stats.Latencies[i] < stats.Latencies[j] })
+	return stats
+}
+
+// Bench sends the call over and over from concurrent workers and reports
+// the stats so far to progress a few times a second. The variables are
+// substituted and the pre-request scripts run once, the responses are not
+// checked. Connections are kept alive and reused between requests.
+func (a *App) Bench(ctx context.Context, call *Call, opts BenchOptions, progress func(BenchStats)) (BenchStats, error) {
+	if call.IsWebSocket() || call.IsGRPC() {
+		return BenchStats{}, fmt.Errorf("only HTTP calls can be benchmarked")
+	}
+	if opts.Requests < 1 && opts.Duration <= 0 {
+		return BenchStats{}, fmt.Errorf("a number of requests or a duration is needed")
+	}
+	if opts.Rate < 0 || !(opts.Rate <= MaxBenchRate) {
+		return BenchStats{}, fmt.Errorf("the rate must be between 0 and %g requests per second", MaxBenchRate)
+	}
+	if err := call.MissingPathParams(); err != nil {
+		return BenchStats{}, err
+	}
+	concurrency := max(opts.Concurrency, 1)
+
+	params := call.RequestParams()
+	if err := a.runPreRequestScripts(call, &params); err != nil {
+		return BenchStats{}, err
+	}
+	var body []byte
+	if params.Body != nil {
+		body, _ = io.ReadAll(params.Body)
+	}
+	params.Client = utils.NewKeepAliveClient(concurrency)
+	defer params.Client.CloseIdleConnections()
+
+	benchCtx := ctx
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		benchCtx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+	params.Context = benchCtx
+
+	// jobs hands out the requests to the workers, at the given rate
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opts.Rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i := 0; opts.Duration > 0 || i < opts.Requests; i++ {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-benchCtx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-benchCtx.Done():
+				return
+			}
+		}
+	}()
+
+	recorder := newBenchRecorder()
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for range jobs {
+				request := params
+				request.Body = bytes.NewReader(body)
+				started := time.Now()
+				status, size, err := benchRequest(request)
+				// requests cut off by the end of the benchmark do not count
+				if benchCtx.Err() != nil {
+					continue
+				}
+				recorder.record(time.Since(started), status, size, err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return recorder.snapshot(), ctx.Err()
+		case <-ticker.C:
+			if progress != nil {
+				progress(recorder.snapshot())
+			}
+		}
+	}
+}
+
+// benchRequest sends a request of a benchmark and reads the whole response
+func benchRequest(params utils.HTTPRequestParams) (int, int64, error) {
+	response, err := utils.MakeRequest(params)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer response.Body.Close()
+	size, err := io.Copy(io.Discard, response.Body)
+	return response.StatusCode, size, err
+}
+
+// the benchmark started from the UI, canceled when it is closed
+var activeBench struct {
+	cancel context.CancelFunc
+}
+
+// StartBench benchmarks the call in the background, the stats are sent as
+// OnBenchProgressMsg followed by an OnBenchFinishedMsg
+func (a *App) StartBench(call Call, opts BenchOptions) tea.Cmd {
+	a.StopBench()
+	ctx, cancel := context.WithCancel(context.Background())
+	activeBench.cancel = cancel
+
+	messages := make(chan tea.Msg)
+	go func() {
+		defer close(messages)
+		stats, err := a.Bench(ctx, &call, opts, func(stats BenchStats) {
+			messages <- OnBenchProgressMsg{Stats: stats, next: waitForResponse(messages)}
+		})
+		if err == context.Canceled {
+			err = fmt.Errorf("benchmark canceled")
+		}
+		messages <- OnBenchFinishedMsg{Stats: stats, Err: err}
+	}()
+	return waitForResponse(messages)
+}
+
+// StopBench cancels the benchmark started from the UI, if any
+func (a *App) StopBench() {
+	if activeBench.cancel != nil {
+		activeBench.cancel()
+		activeBench.cancel = nil
+	}
+}
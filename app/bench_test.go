@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBenchStats(t *testing.T) {
+	stats := BenchStats{Elapsed: 2 * time.Second, Requests: 12, Errors: map[string]int{"refused": 2}}
+	for i := 1; i <= 10; i++ {
+		stats.Latencies = append(stats.Latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		got  time.Duration
+		want time.Duration
+	}{
+		{stats.Percentile(50), 5 * time.Millisecond},
+		{stats.Percentile(90), 9 * time.Millisecond},
+		{stats.Percentile(99), 10 * time.Millisecond},
+		{stats.Percentile(0), 1 * time.Millisecond},
+		{stats.Mean(), 5500 * time.Microsecond},
+	}
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%d: got %v, want %v", i, test.got, test.want)
+		}
+	}
+	if stats.Throughput() != 6 || stats.ErrorCount() != 2 {
+		t.Errorf("throughput = %v, errors = %d", stats.Throughput(), stats.ErrorCount())
+	}
+
+	histogram := stats.Histogram(3)
+	if len(histogram) != 3 || histogram[0].Count != 3 || histogram[1].Count != 3 || histogram[2].Count != 4 {
+		t.Errorf("Histogram(3) = %+v", histogram)
+	}
+	if histogram[0].From != time.Millisecond || histogram[2].To != 10*time.Millisecond {
+		t.Errorf("Histogram(3) range = %v - %v", histogram[0].From, histogram[2].To)
+	}
+	if (BenchStats{}).Histogram(3) != nil || (BenchStats{}).Percentile(50) != 0 {
+		t.Errorf("empty stats should have no histogram and percentiles")
+	}
+}
+
+func TestBench(t *testing.T) {
+	isolateConfig(t)
+
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	a := GetInstance()
+	call := Call{ID: "b", Method: "GET", Url: server.URL}
+	stats, err := a.Bench(context.Background(), &call, BenchOptions{Requests: 50, Concurrency: 4}, nil)
+	if err != nil {
+		t.Fatalf("Bench() error = %v", err)
+	}
+	if stats.Requests != 50 || stats.Statuses[200] != 50 || len(stats.Latencies) != 50 || stats.Bytes != 100 {
+		t.Errorf("Bench() = %d requests, statuses %v, %d bytes", stats.Requests, stats.Statuses, stats.Bytes)
+	}
+	if n := connections.Load(); n > 4 {
+		t.Errorf("Bench() opened %d connections, want at most 4", n)
+	}
+
+	call.Url = server.URL + "?fail=1"
+	stats, _ = a.Bench(context.Background(), &call, BenchOptions{Duration: 200 * time.Millisecond, Rate: 50}, nil)
+	if stats.Requests < 5 || stats.Requests > 12 || stats.Statuses[500] != stats.Requests {
+		t.Errorf("Bench() for 200ms at 50/s = %d requests, statuses %v", stats.Requests, stats.Statuses)
+	}
+
+	call.Url = "http://127.0.0.1:1"
+	stats, _ = a.Bench(context.Background(), &call, BenchOptions{Requests: 3}, nil)
+	if stats.ErrorCount() != 3 {
+		t.Errorf("Bench() of an unreachable server = %d errors, want 3", stats.ErrorCount())
+	}
+
+	if _, err := a.Bench(context.Background(), &call, BenchOptions{}, nil); err == nil {
+		t.Errorf("Bench() without requests or a duration should fail")
+	}
+	// the interval between requests would round to 0
+	if _, err := a.Bench(context.Background(), &call, BenchOptions{Requests: 1, Rate: 1e12}, nil); err == nil {
+		t.Errorf("Bench() at a rate above %g should fail", MaxBenchRate)
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"restman/utils"
+	"strings"
+)
+
+// ReadDataFile reads the rows of a data file, either CSV with a header row or
+// a JSON array of objects. Every row becomes one iteration of a run with its
+// fields as variables.
+func ReadDataFile(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		rows, err = parseJSONData(data)
+	case ".csv":
+		rows, err = parseCSVData(data)
+	default:
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			rows, err = parseJSONData(data)
+		} else {
+			rows, err = parseCSVData(data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s has no rows", filepath.Base(path))
+	}
+	return rows, nil
+}
+
+func parseCSVData(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i, name := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	rows := []map[string]string{}
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, value := range record {
+			row[header[i]] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONData(data []byte) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("expected an array of objects: %w", err)
+	}
+
+	rows := []map[string]string{}
+	for _, object := range objects {
+		row := map[string]string{}
+		for name, value := range object {
+			// strings are used without their quotes, anything else as JSON
+			if s, ok := value.(string); ok {
+				row[name] = s
+			} else {
+				row[name] = utils.FormatValue(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadDataFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"users.csv", "\ufeffuser, id\nalice,1\n\"bob, jr\",2\n", "[map[id:1 user:alice] map[id:2 user:bob, jr]]", false},
+		{"users.json", `[{"user": "alice", "id": 1, "admin": true}, {"user": "bob", "tags": ["a"]}]`, `[map[admin:true id:1 user:alice] map[tags:["a"] user:bob]]`, false},
+		{"users.txt", ` [{"user": "alice"}]`, "[map[user:alice]]", false},
+		{"users.data", "user\nalice\n", "[map[user:alice]]", false},
+		{"header.csv", "user,id\n", "", true},
+		{"object.json", `{"user": "alice"}`, "", true},
+		{"fields.csv", "user,id\nalice\n", "", true},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := ReadDataFile(path)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ReadDataFile(%s) error = %v, wantErr %v", test.name, err, test.wantErr)
+			continue
+		}
+		if got := fmt.Sprint(rows); !test.wantErr && got != test.want {
+			t.Errorf("ReadDataFile(%s) = %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	if _, err := ReadDataFile(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Errorf("ReadDataFile() of a missing file should fail")
+	}
+}
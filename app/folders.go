@@ -0,0 +1,180 @@
+package app
+
+import (
+	"restman/utils"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Folder holds the settings of a folder of a collection. Calls are put in a
+// folder through their Folder path and folders nest through slash separated
+// paths, e.g. "users/admin" is inside "users". The auth, headers and
+// variables of a folder are inherited by the calls inside it, the ones of
+// inner folders win.
+type Folder struct {
+	Path      string            `json:"path"`
+	Auth      *Auth             `json:"auth,omitempty"`
+	Headers   Headers           `json:"headers,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// CleanFolderPath trims the segments of a folder path and drops empty ones
+func CleanFolderPath(path string) string {
+	segments := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// FolderName returns the last segment of a folder path
+func FolderName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// FolderParent returns the path of the folder holding the folder, empty for
+// folders at the top of a collection
+func FolderParent(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// FolderPaths returns the sorted paths of all folders of the collection,
+// including the ones only named by calls and their parents
+func (c Collection) FolderPaths() []string {
+	seen := map[string]bool{}
+	add := func(path string) {
+		for ; path != "" && !seen[path]; path = FolderParent(path) {
+			seen[path] = true
+		}
+	}
+	for _, folder := range c.Folders {
+		add(folder.Path)
+	}
+	for _, call := range c.Calls {
+		add(CleanFolderPath(call.Folder))
+	}
+
+	paths := []string{}
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Folder returns the settings of the folder, nil when it has none
+func (c Collection) Folder(path string) *Folder {
+	for i := range c.Folders {
+		if c.Folders[i].Path == path {
+			return &c.Folders[i]
+		}
+	}
+	return nil
+}
+
+// folderChain returns the settings of the folders around path, the
+// outermost first
+func (c Collection) folderChain(path string) []Folder {
+	chain := []Folder{}
+	for ; path != ""; path = FolderParent(path) {
+		if folder := c.Folder(path); folder != nil {
+			chain = append([]Folder{*folder}, chain...)
+		}
+	}
+	return chain
+}
+
+// inheritedAuth returns the auth of the innermost folder around path which
+// sets one, or the auth of the collection
+func (c Collection) inheritedAuth(path string) *Auth {
+	chain := c.folderChain(path)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if auth := chain[i].Auth; auth != nil && auth.Type != "inherit" {
+			return auth
+		}
+	}
+	return c.Auth
+}
+
+// inheritedHeaders returns the enabled headers of the folders around the
+// call, the ones of inner folders come last
+func (i Call) inheritedHeaders() Headers {
+	headers := Headers{}
+	if collection := i.Collection(); collection != nil {
+		for _, folder := range collection.folderChain(i.Folder) {
+			headers = append(headers, folder.Headers.Enabled()...)
+		}
+	}
+	return headers
+}
+
+// findCollectionByID returns the collection with the id, not a copy of it
+func (a *App) findCollectionByID(id string) *Collection {
+	for i := range a.Collections {
+		if a.Collections[i].ID == id {
+			return &a.Collections[i]
+		}
+	}
+	return nil
+}
+
+// CreateFolder adds an empty folder to the collection
+func (a *App) CreateFolder(collectionID string, path string) tea.Cmd {
+	collection := a.findCollectionByID(collectionID)
+	path = CleanFolderPath(path)
+	if collection == nil || path == "" || collection.Folder(path) != nil {
+		return nil
+	}
+	collection.Folders = append(collection.Folders, Folder{Path: path})
+	return a.SaveCollections()
+}
+
+// UpdateFolder stores the settings of a folder of the collection
+func (a *App) UpdateFolder(collectionID string, folder Folder) tea.Cmd {
+	collection := a.findCollectionByID(collectionID)
+	if collection == nil {
+		return nil
+	}
+	if stored := collection.Folder(folder.Path); stored != nil {
+		*stored = folder
+	} else {
+		collection.Folders = append(collection.Folders, folder)
+	}
+	return a.SaveCollections()
+}
+
+// MoveCall moves the call into a folder of the collection, which may be
+// another one than the call is in. An empty folder is the top level of the
+// collection.
+func (a *App) MoveCall(callID string, collectionID string, folder string) tea.Cmd {
+	target := a.findCollectionByID(collectionID)
+	if target == nil {
+		return nil
+	}
+
+	for i := range a.Collections {
+		source := &a.Collections[i]
+		for j, call := range source.Calls {
+			if call.ID != callID {
+				continue
+			}
+			call.Folder = CleanFolderPath(folder)
+			call.hash = utils.ComputeHash(call)
+			if source == target {
+				source.Calls[j] = call
+			} else {
+				source.Calls = append(source.Calls[:j:j], source.Calls[j+1:]...)
+				target.Calls = append(target.Calls, call)
+			}
+			return a.SaveCollections()
+		}
+	}
+	return nil
+}
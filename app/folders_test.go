@@ -0,0 +1,99 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func folderCollection() Collection {
+	return Collection{
+		ID:        "shop",
+		Name:      "shop",
+		BaseUrl:   "http://localhost",
+		Auth:      &Auth{Type: "bearer_token", Token: "collection"},
+		Variables: map[string]string{"region": "us", "team": "all"},
+		Calls: []Call{
+			{ID: "users", Url: "{{BASE_URL}}/{{region}}/users", Method: "GET", Folder: "users", Auth: &Auth{Type: "inherit"}},
+			{ID: "admins", Url: "{{BASE_URL}}/{{team}}", Method: "GET", Folder: "users/admin", Auth: &Auth{Type: "inherit"},
+				Headers: Headers{{Key: "X-Level", Value: "call"}}},
+			{ID: "health", Url: "{{BASE_URL}}/health", Method: "GET", Folder: "ops/checks"},
+		},
+		Folders: []Folder{
+			{Path: "users", Auth: &Auth{Type: "bearer_token", Token: "users"},
+				Headers: Headers{{Key: "X-Level", Value: "users"}, {Key: "X-Team", Value: "core"}}, Variables: map[string]string{"region": "eu"}},
+			{Path: "users/admin", Auth: &Auth{Type: "inherit"},
+				Headers: Headers{{Key: "X-Admin", Value: "1", Disabled: true}}, Variables: map[string]string{"team": "admins"}},
+			{Path: "empty"},
+		},
+	}
+}
+
+func TestFolderPaths(t *testing.T) {
+	want := []string{"empty", "ops", "ops/checks", "users", "users/admin"}
+	if got := folderCollection().FolderPaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FolderPaths() = %v, want %v", got, want)
+	}
+
+	if got := CleanFolderPath(" users// admin /"); got != "users/admin" {
+		t.Errorf("CleanFolderPath() = %q", got)
+	}
+	if FolderName("users/admin") != "admin" || FolderParent("users/admin") != "users" || FolderParent("users") != "" {
+		t.Errorf("Unexpected folder name or parent")
+	}
+}
+
+func TestFolderInheritance(t *testing.T) {
+	GetInstance().Collections = []Collection{folderCollection()}
+	defer func() { GetInstance().Collections = nil }()
+	calls := GetInstance().Collections[0].Calls
+
+	// the admin folder inherits the auth of the users folder
+	if auth := calls[1].GetAuth(); auth == nil || auth.Token != "users" {
+		t.Errorf("GetAuth() = %+v, want the auth of the users folder", auth)
+	}
+
+	params := calls[1].RequestParams()
+	wantHeaders := map[string]string{"X-Level": "call", "X-Team": "core", "Authorization": "Bearer users"}
+	if !reflect.DeepEqual(params.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", params.Headers, wantHeaders)
+	}
+	if params.URL != "http://localhost/admins" {
+		t.Errorf("URL = %q", params.URL)
+	}
+	if got := calls[0].GetUrl(); got != "http://localhost/eu/users" {
+		t.Errorf("GetUrl() = %q", got)
+	}
+}
+
+func TestMoveCall(t *testing.T) {
+	other := Collection{ID: "other", Name: "other", Calls: []Call{}}
+	GetInstance().Collections = []Collection{folderCollection(), other}
+	defer func() { GetInstance().Collections = nil }()
+	a := GetInstance()
+
+	a.MoveCall("health", "shop", " ops ")
+	if call := a.findCall("health"); call.Folder != "ops" || call.WasChanged() {
+		t.Errorf("Expected call to be moved to ops, got %+v", call)
+	}
+
+	a.MoveCall("users", "other", "")
+	if len(a.Collections[0].Calls) != 2 || len(a.Collections[1].Calls) != 1 || a.Collections[1].Calls[0].Folder != "" {
+		t.Errorf("Expected call to be moved to the other collection, got %+v", a.Collections)
+	}
+
+	a.CreateFolder("other", "new/folder/")
+	if a.Collections[1].Folder("new/folder") == nil {
+		t.Errorf("Expected folder to be created, got %+v", a.Collections[1].Folders)
+	}
+}
+
+func TestParseVariablesText(t *testing.T) {
+	variables := ParseVariablesText("region = eu\n\n = skipped\ntoken=a=b")
+	want := map[string]string{"region": "eu", "token": "a=b"}
+	if !reflect.DeepEqual(variables, want) {
+		t.Errorf("ParseVariablesText() = %v, want %v", variables, want)
+	}
+	if got := VariablesText(want); got != "region = eu\ntoken = a=b" {
+		t.Errorf("VariablesText() = %q", got)
+	}
+}
@@ -0,0 +1,467 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"restman/utils"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// GraphQL holds the parts of a GraphQL request, they are encoded as a JSON
+// body when the call is sent
+type GraphQL struct {
+	Query         string `json:"query"`
+	Variables     string `json:"variables,omitempty"`
+	OperationName string `json:"operation_name,omitempty"`
+}
+
+// Body returns the JSON payload of the request
+func (g GraphQL) Body() (string, error) {
+	payload := map[string]interface{}{"query": g.Query}
+	if strings.TrimSpace(g.Variables) != "" {
+		var variables interface{}
+		if err := json.Unmarshal([]byte(g.Variables), &variables); err != nil {
+			return "", fmt.Errorf("invalid variables: %w", err)
+		}
+		payload["variables"] = variables
+	}
+	if g.OperationName != "" {
+		payload["operationName"] = g.OperationName
+	}
+	data, err := json.Marshal(payload)
+	return string(data), err
+}
+
+var operationReg = regexp.MustCompile(`\b(?:query|mutation|subscription)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+
+// OperationNames returns the names of the operations defined in the query
+func (g GraphQL) OperationNames() []string {
+	names := []string{}
+	for _, match := range operationReg.FindAllStringSubmatch(stripGraphQLComments(g.Query), -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+func stripGraphQLComments(query string) string {
+	lines := strings.Split(query, "\n")
+	for i, line := range lines {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			lines[i] = line[:idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      description
+      fields(includeDeprecated: true) {
+        name
+        description
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: true) { name description }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// TypeRef references a type, wrapped in LIST and NON_NULL modifiers
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// NamedType returns the name of the type without its modifiers
+func (t TypeRef) NamedType() string {
+	if t.OfType != nil {
+		return t.OfType.NamedType()
+	}
+	return t.Name
+}
+
+func (t TypeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType != nil {
+			return t.OfType.String() + "!"
+		}
+	case "LIST":
+		if t.OfType != nil {
+			return "[" + t.OfType.String() + "]"
+		}
+	}
+	return t.Name
+}
+
+type SchemaInputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         TypeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type SchemaField struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Args        []SchemaInputValue `json:"args"`
+	Type        TypeRef            `json:"type"`
+}
+
+type SchemaEnumValue struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SchemaType struct {
+	Kind        string             `json:"kind"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Fields      []SchemaField      `json:"fields"`
+	InputFields []SchemaInputValue `json:"inputFields"`
+	EnumValues  []SchemaEnumValue  `json:"enumValues"`
+}
+
+// Schema is the result of an introspection query
+type Schema struct {
+	QueryType        *struct{ Name string } `json:"queryType"`
+	MutationType     *struct{ Name string } `json:"mutationType"`
+	SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+	Types            []SchemaType           `json:"types"`
+}
+
+// Type looks up a type by name
+func (s Schema) Type(name string) *SchemaType {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// Field looks up a field of the given type
+func (s Schema) Field(typeName string, fieldName string) *SchemaField {
+	t := s.Type(typeName)
+	if t == nil {
+		return nil
+	}
+	for i := range t.Fields {
+		if t.Fields[i].Name == fieldName {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+func (s Schema) rootType(operation string) string {
+	switch operation {
+	case "mutation":
+		if s.MutationType != nil {
+			return s.MutationType.Name
+		}
+	case "subscription":
+		if s.SubscriptionType != nil {
+			return s.SubscriptionType.Name
+		}
+	default:
+		if s.QueryType != nil {
+			return s.QueryType.Name
+		}
+	}
+	return ""
+}
+
+// UserTypes returns the types defined by the API, sorted by name and
+// without the introspection types
+func (s Schema) UserTypes() []SchemaType {
+	types := []SchemaType{}
+	for _, t := range s.Types {
+		if !strings.HasPrefix(t.Name, "__") {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+var gqlTokenReg = regexp.MustCompile(`#[^\n]*|"""(?s:.*?)"""|"(?:\\.|[^"\\])*"|\.\.\.|[A-Za-z_][A-Za-z0-9_]*|-?[0-9][0-9.eE+-]*|\S`)
+
+type gqlToken struct {
+	text string
+	end  int
+}
+
+func tokenizeGraphQL(query string) []gqlToken {
+	tokens := []gqlToken{}
+	for _, loc := range gqlTokenReg.FindAllStringIndex(query, -1) {
+		text := query[loc[0]:loc[1]]
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		tokens = append(tokens, gqlToken{text, loc[1]})
+	}
+	return tokens
+}
+
+func isGraphQLName(text string) bool {
+	return text != "" && (text[0] == '_' || (text[0] >= 'A' && text[0] <= 'Z') || (text[0] >= 'a' && text[0] <= 'z'))
+}
+
+// walkSelections follows the selection sets of the query, calling visit
+// for every selected field with the name of the type it is selected on. It
+// returns the stack of types open at the end of the tokens.
+func (s Schema) walkSelections(tokens []gqlToken, visit func(field string, parent string)) []string {
+	stack := []string{}
+	pending := ""
+	parens := 0
+
+	for i := 0; i < len(tokens); i++ {
+		text := tokens[i].text
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].text
+		}
+
+		switch {
+		case text == "(":
+			parens++
+		case text == ")":
+			parens--
+		case parens > 0:
+			// arguments and variable definitions
+
+		case text == "{":
+			if len(stack) == 0 && pending == "" {
+				// anonymous query
+				pending = s.rootType("query")
+			}
+			stack = append(stack, pending)
+			pending = ""
+		case text == "}":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			pending = ""
+
+		case text == "@":
+			// skip the directive name
+			i++
+		case text == "on":
+			pending = next
+			i++
+		case text == "...":
+			if next != "on" {
+				// fragment spread
+				i++
+			}
+
+		case len(stack) == 0:
+			switch text {
+			case "query", "mutation", "subscription":
+				pending = s.rootType(text)
+			case "fragment":
+				// the type follows "on"
+				i++
+			}
+
+		case isGraphQLName(text):
+			if next == ":" {
+				// alias, the field name follows the colon
+				i++
+				continue
+			}
+			parent := stack[len(stack)-1]
+			if visit != nil {
+				visit(text, parent)
+			}
+			pending = ""
+			if field := s.Field(parent, text); field != nil {
+				pending = field.Type.NamedType()
+			}
+		}
+	}
+	return stack
+}
+
+// Validate reports fields which do not exist on the type they are selected on
+func (s Schema) Validate(query string) []string {
+	errors := []string{}
+	s.walkSelections(tokenizeGraphQL(query), func(field string, parent string) {
+		if parent == "" || field == "__typename" {
+			return
+		}
+		if s.Type(parent) == nil {
+			errors = append(errors, fmt.Sprintf("Unknown type \"%s\"", parent))
+		} else if s.Field(parent, field) == nil {
+			errors = append(errors, fmt.Sprintf("Unknown field \"%s\" on type \"%s\"", field, parent))
+		}
+	})
+	return errors
+}
+
+// Complete returns the names which can be typed at the given offset of the
+// query together with the partial name already typed.
+func (s Schema) Complete(query string, offset int) ([]string, string) {
+	offset = min(max(offset, 0), len(query))
+	before := query[:offset]
+
+	prefix := ""
+	for i := len(before) - 1; i >= 0; i-- {
+		c := before[i]
+		if c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			prefix = string(c) + prefix
+		} else {
+			break
+		}
+	}
+
+	stack := s.walkSelections(tokenizeGraphQL(before[:len(before)-len(prefix)]), nil)
+
+	candidates := []string{"query", "mutation", "subscription", "fragment"}
+	if len(stack) > 0 {
+		candidates = []string{}
+		if t := s.Type(stack[len(stack)-1]); t != nil {
+			for _, field := range t.Fields {
+				candidates = append(candidates, field.Name)
+			}
+		}
+	}
+
+	suggestions := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) && candidate != prefix {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, prefix
+}
+
+// schemas fetched in this session, keyed by collection
+var schemas struct {
+	sync.Mutex
+	cache map[string]*Schema
+}
+
+func schemaKey(call *Call) string {
+	if collection := call.Collection(); collection != nil {
+		return collection.ID
+	}
+	return call.GetUrl()
+}
+
+func schemaPath(key string) string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "restman", "graphql", utils.ComputeHash(key)[:16]+".json")
+}
+
+// GetSchema returns the cached schema of the collection the call belongs to
+func (a *App) GetSchema(call *Call) *Schema {
+	key := schemaKey(call)
+
+	schemas.Lock()
+	defer schemas.Unlock()
+	if schema, ok := schemas.cache[key]; ok {
+		return schema
+	}
+
+	file, err := os.ReadFile(schemaPath(key))
+	if err != nil {
+		return nil
+	}
+	var schema Schema
+	if json.Unmarshal(file, &schema) != nil {
+		return nil
+	}
+	if schemas.cache == nil {
+		schemas.cache = make(map[string]*Schema)
+	}
+	schemas.cache[key] = &schema
+	return &schema
+}
+
+// FetchSchema runs an introspection query against the call URL and caches
+// the schema for the collection
+func (a *App) FetchSchema(call *Call) tea.Cmd {
+	return func() tea.Msg {
+		schema, err := fetchSchema(call)
+		if err != nil {
+			return SchemaFetchedMsg{Call: call, Err: err}
+		}
+
+		key := schemaKey(call)
+		schemas.Lock()
+		if schemas.cache == nil {
+			schemas.cache = make(map[string]*Schema)
+		}
+		schemas.cache[key] = schema
+		schemas.Unlock()
+
+		if data, err := json.Marshal(schema); err == nil {
+			os.MkdirAll(filepath.Dir(schemaPath(key)), os.ModePerm)
+			os.WriteFile(schemaPath(key), data, 0644)
+		}
+
+		return SchemaFetchedMsg{Call: call, Schema: schema}
+	}
+}
+
+func fetchSchema(call *Call) (*Schema, error) {
+	introspection := *call
+	introspection.Method = "POST"
+	introspection.DataType = "GraphQL"
+	introspection.GraphQL = &GraphQL{Query: introspectionQuery}
+
+	response, err := utils.MakeRequest(introspection.RequestParams())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("unexpected introspection response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", result.Errors[0].Message)
+	}
+	if result.Data.Schema == nil {
+		return nil, fmt.Errorf("introspection returned no schema")
+	}
+	return result.Data.Schema, nil
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSchema() *Schema {
+	named := func(name string) TypeRef { return TypeRef{Kind: "OBJECT", Name: name} }
+	list := func(name string) TypeRef {
+		return TypeRef{Kind: "NON_NULL", OfType: &TypeRef{Kind: "LIST", OfType: &TypeRef{Kind: "OBJECT", Name: name}}}
+	}
+	return &Schema{
+		QueryType:    &struct{ Name string }{"Query"},
+		MutationType: &struct{ Name string }{"Mutation"},
+		Types: []SchemaType{
+			{Kind: "OBJECT", Name: "Query", Fields: []SchemaField{
+				{Name: "user", Type: named("User")},
+				{Name: "users", Type: list("User")},
+			}},
+			{Kind: "OBJECT", Name: "Mutation", Fields: []SchemaField{
+				{Name: "createUser", Type: named("User")},
+			}},
+			{Kind: "OBJECT", Name: "User", Fields: []SchemaField{
+				{Name: "id", Type: named("ID")},
+				{Name: "name", Type: named("String")},
+				{Name: "friends", Type: list("User")},
+			}},
+			{Kind: "SCALAR", Name: "ID"},
+			{Kind: "SCALAR", Name: "String"},
+			{Kind: "OBJECT", Name: "__Schema"},
+		},
+	}
+}
+
+func TestGraphQLBody(t *testing.T) {
+	g := GraphQL{Query: "query A { a }", Variables: `{"id": 1}`, OperationName: "A"}
+	body, err := g.Body()
+	if err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	want := `{"operationName":"A","query":"query A { a }","variables":{"id":1}}`
+	if body != want {
+		t.Errorf("Body() = %s, want %s", body, want)
+	}
+
+	g.Variables = "{"
+	if _, err := g.Body(); err == nil {
+		t.Errorf("Body() with invalid variables should fail")
+	}
+}
+
+func TestOperationNames(t *testing.T) {
+	g := GraphQL{Query: "# query Commented\nquery First { a }\nmutation Second($id: ID) { b }\n{ c }"}
+	want := []string{"First", "Second"}
+	if got := g.OperationNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("OperationNames() = %v, want %v", got, want)
+	}
+}
+
+func TestTypeRefString(t *testing.T) {
+	ref := TypeRef{Kind: "NON_NULL", OfType: &TypeRef{Kind: "LIST", OfType: &TypeRef{Kind: "OBJECT", Name: "User"}}}
+	if got := ref.String(); got != "[User]!" {
+		t.Errorf("String() = %s", got)
+	}
+	if got := ref.NamedType(); got != "User" {
+		t.Errorf("NamedType() = %s", got)
+	}
+}
+
+func TestSchemaComplete(t *testing.T) {
+	schema := testSchema()
+
+	tests := []struct {
+		query  string
+		want   []string
+		prefix string
+	}{
+		{"{ ", []string{"user", "users"}, ""},
+		{"query { us", []string{"user", "users"}, "us"},
+		{"query { user { ", []string{"id", "name", "friends"}, ""},
+		{"query { user { friends { na", []string{"name"}, "na"},
+		{"query { user(id: \"1\") { n", []string{"name"}, "n"},
+		{"query { me: user { i", []string{"id"}, "i"},
+		{"query { user { id } us", []string{"user", "users"}, "us"},
+		{"mutation { cr", []string{"createUser"}, "cr"},
+		{"fragment F on User { fr", []string{"friends"}, "fr"},
+		{"qu", []string{"query"}, "qu"},
+	}
+
+	for _, test := range tests {
+		got, prefix := schema.Complete(test.query, len(test.query))
+		if !reflect.DeepEqual(got, test.want) || prefix != test.prefix {
+			t.Errorf("Complete(%q) = %v, %q, want %v, %q", test.query, got, prefix, test.want, test.prefix)
+		}
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := testSchema()
+
+	if errors := schema.Validate("query { user { id name __typename friends { id } } }"); len(errors) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errors)
+	}
+
+	errors := schema.Validate("query { user { id email } posts }")
+	want := []string{
+		`Unknown field "email" on type "User"`,
+		`Unknown field "posts" on type "Query"`,
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Errorf("Validate() = %v, want %v", errors, want)
+	}
+}
+
+func TestUserTypes(t *testing.T) {
+	names := []string{}
+	for _, t := range testSchema().UserTypes() {
+		names = append(names, t.Name)
+	}
+	want := []string{"ID", "Mutation", "Query", "String", "User"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("UserTypes() = %v, want %v", names, want)
+	}
+}
+
+func TestFetchSchema(t *testing.T) {
+	isolateConfig(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || !strings.Contains(string(body), "__schema") {
+			t.Errorf("unexpected introspection request %s %s", r.Method, body)
+		}
+		data, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"__schema": testSchema()}})
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	call := NewCall()
+	call.Url = server.URL
+	msg := GetInstance().FetchSchema(call)().(SchemaFetchedMsg)
+	if msg.Err != nil {
+		t.Fatalf("FetchSchema() error = %v", msg.Err)
+	}
+	if msg.Schema.Type("User") == nil {
+		t.Errorf("FetchSchema() schema is missing types")
+	}
+
+	// the schema is cached in memory and on disk
+	schemas.Lock()
+	schemas.cache = nil
+	schemas.Unlock()
+	if schema := GetInstance().GetSchema(call); schema == nil || schema.Field("User", "friends") == nil {
+		t.Errorf("GetSchema() = %v, want the cached schema", schema)
+	}
+}
@@ -0,0 +1,494 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"restman/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPC describes the method called by a gRPC call, services are discovered
+// through server reflection unless proto files are given
+type GRPC struct {
+	Service     string   `json:"service"`
+	Method      string   `json:"method"`
+	ProtoFiles  []string `json:"proto_files,omitempty"`
+	ImportPaths []string `json:"import_paths,omitempty"`
+}
+
+// GRPCMethod is a method discovered on the server or in the proto files
+type GRPCMethod struct {
+	Service         string
+	Name            string
+	ClientStreaming bool
+	ServerStreaming bool
+	// Template is a JSON request message with every field of the input type
+	Template string
+}
+
+func (m GRPCMethod) FullName() string {
+	return m.Service + "/" + m.Name
+}
+
+// IsGRPCUrl reports whether the URL uses the grpc:// (plaintext) or
+// grpcs:// (TLS) scheme
+func IsGRPCUrl(rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "grpc" || u.Scheme == "grpcs"
+}
+
+func dialGRPC(call *Call) (*grpc.ClientConn, error) {
+	u, err := url.Parse(call.GetUrl())
+	if err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if u.Scheme == "grpcs" {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	return grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+}
+
+// grpcMetadata turns the call headers and auth into request metadata
+func grpcMetadata(call *Call) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range handshakeHeaders(call) {
+		md.Append(strings.ToLower(k), strings.TrimSpace(v[0]))
+	}
+	return md
+}
+
+// descriptors resolved per URL and proto files, reflection is only asked once
+var grpcDescriptors struct {
+	sync.Mutex
+	cache map[string][]protoreflect.ServiceDescriptor
+}
+
+func descriptorsKey(call *Call) string {
+	key := call.GetUrl()
+	if call.GRPC != nil {
+		key += "|" + strings.Join(call.GRPC.ProtoFiles, ",") + "|" + strings.Join(call.GRPC.ImportPaths, ",")
+	}
+	return key
+}
+
+func loadServices(ctx context.Context, call *Call, refresh bool) ([]protoreflect.ServiceDescriptor, error) {
+	key := descriptorsKey(call)
+
+	grpcDescriptors.Lock()
+	services, ok := grpcDescriptors.cache[key]
+	grpcDescriptors.Unlock()
+	if ok && !refresh {
+		return services, nil
+	}
+
+	var err error
+	if call.GRPC != nil && len(call.GRPC.ProtoFiles) > 0 {
+		services, err = compileProtoFiles(ctx, call.GRPC.ProtoFiles, call.GRPC.ImportPaths)
+	} else {
+		services, err = reflectServices(ctx, call)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	grpcDescriptors.Lock()
+	if grpcDescriptors.cache == nil {
+		grpcDescriptors.cache = make(map[string][]protoreflect.ServiceDescriptor)
+	}
+	grpcDescriptors.cache[key] = services
+	grpcDescriptors.Unlock()
+	return services, nil
+}
+
+func compileProtoFiles(ctx context.Context, files []string, importPaths []string) ([]protoreflect.ServiceDescriptor, error) {
+	// files are compiled relative to their directory, explicit import
+	// paths take precedence
+	paths := append([]string{}, importPaths...)
+	names := []string{}
+	for _, file := range files {
+		paths = append(paths, filepath.Dir(file))
+		names = append(names, filepath.Base(file))
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: paths}),
+	}
+	compiled, err := compiler.Compile(ctx, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	services := []protoreflect.ServiceDescriptor{}
+	for _, file := range compiled {
+		for i := 0; i < file.Services().Len(); i++ {
+			services = append(services, file.Services().Get(i))
+		}
+	}
+	return services, nil
+}
+
+// reflection methods tried in order, older servers only know v1alpha which
+// uses the same messages
+var reflectionMethods = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+func reflectServices(ctx context.Context, call *Call) ([]protoreflect.ServiceDescriptor, error) {
+	conn, err := dialGRPC(call)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx = metadata.NewOutgoingContext(ctx, grpcMetadata(call))
+	for _, method := range reflectionMethods {
+		services, err := reflectWith(ctx, conn, method)
+		if status.Code(err) == codes.Unimplemented {
+			continue
+		}
+		return services, err
+	}
+	return nil, errors.New("server reflection is not supported, load .proto files instead")
+}
+
+func reflectWith(ctx context.Context, conn *grpc.ClientConn, method string) ([]protoreflect.ServiceDescriptor, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, method)
+	if err != nil {
+		return nil, err
+	}
+
+	ask := func(request *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+		if err := stream.SendMsg(request); err != nil {
+			return nil, err
+		}
+		response := &reflectionpb.ServerReflectionResponse{}
+		if err := stream.RecvMsg(response); err != nil {
+			return nil, err
+		}
+		if e := response.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.ErrorCode), e.ErrorMessage)
+		}
+		return response, nil
+	}
+
+	response, err := ask(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, service := range response.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(service.Name, "grpc.reflection.") {
+			continue
+		}
+		names = append(names, service.Name)
+
+		response, err := ask(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service.Name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// the server sends the file with its dependencies
+		for _, raw := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return nil, err
+			}
+			if !seen[file.GetName()] {
+				seen[file.GetName()] = true
+				set.File = append(set.File, file)
+			}
+		}
+	}
+	stream.CloseSend()
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+
+	services := []protoreflect.ServiceDescriptor{}
+	for _, name := range names {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, err
+		}
+		if service, ok := descriptor.(protoreflect.ServiceDescriptor); ok {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+func findMethod(services []protoreflect.ServiceDescriptor, service string, method string) protoreflect.MethodDescriptor {
+	for _, s := range services {
+		if string(s.FullName()) == service {
+			return s.Methods().ByName(protoreflect.Name(method))
+		}
+	}
+	return nil
+}
+
+// MessageTemplate returns a JSON object holding every field of the message
+// set to its zero value
+func MessageTemplate(message protoreflect.MessageDescriptor) string {
+	data, _ := json.MarshalIndent(messageTemplate(message, 0), "", "  ")
+	return string(data)
+}
+
+func messageTemplate(message protoreflect.MessageDescriptor, depth int) map[string]interface{} {
+	template := map[string]interface{}{}
+	// recursive messages would never end
+	if depth > 3 {
+		return template
+	}
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		var value interface{}
+		switch {
+		case field.IsMap():
+			value = map[string]interface{}{}
+		case field.IsList():
+			value = []interface{}{fieldTemplate(field, depth)}
+		default:
+			value = fieldTemplate(field, depth)
+		}
+		template[field.JSONName()] = value
+	}
+	return template
+}
+
+func fieldTemplate(field protoreflect.FieldDescriptor, depth int) interface{} {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageTemplate(field.Message(), depth+1)
+	case protoreflect.EnumKind:
+		if values := field.Enum().Values(); values.Len() > 0 {
+			return string(values.Get(0).Name())
+		}
+		return ""
+	case protoreflect.BoolKind:
+		return false
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return ""
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64 bit integers are strings in the JSON mapping
+		return "0"
+	}
+	return 0
+}
+
+// DiscoverGRPC lists the methods of the services exposed by the server or
+// defined in the proto files of the call
+func (a *App) DiscoverGRPC(call *Call, refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		services, err := loadServices(ctx, call, refresh)
+		if err != nil {
+			return GRPCServicesMsg{Call: call, Err: err}
+		}
+
+		methods := []GRPCMethod{}
+		for _, service := range services {
+			for i := 0; i < service.Methods().Len(); i++ {
+				method := service.Methods().Get(i)
+				methods = append(methods, GRPCMethod{
+					Service:         string(service.FullName()),
+					Name:            string(method.Name()),
+					ClientStreaming: method.IsStreamingClient(),
+					ServerStreaming: method.IsStreamingServer(),
+					Template:        MessageTemplate(method.Input()),
+				})
+			}
+		}
+		sort.Slice(methods, func(i, j int) bool { return methods[i].FullName() < methods[j].FullName() })
+		return GRPCServicesMsg{Call: call, Methods: methods}
+	}
+}
+
+// requestMessages decodes the call data into request messages, client
+// streaming methods accept a JSON array with one element per message
+func requestMessages(method protoreflect.MethodDescriptor, data string) ([]proto.Message, error) {
+	if strings.TrimSpace(data) == "" {
+		data = "{}"
+	}
+
+	raw := []json.RawMessage{json.RawMessage(data)}
+	if method.IsStreamingClient() && strings.HasPrefix(strings.TrimSpace(data), "[") {
+		if err := json.Unmarshal([]byte(data), &raw); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := []proto.Message{}
+	for _, r := range raw {
+		message := dynamicpb.NewMessage(method.Input())
+		if err := protojson.Unmarshal(r, message); err != nil {
+			return nil, fmt.Errorf("invalid request message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+var grpcJSON = protojson.MarshalOptions{EmitUnpopulated: true}
+
+// startGRPC invokes the call as the stream of the call, StopStream cancels it
+func (a *App) startGRPC(call *Call) chan tea.Msg {
+	ctx, cancel := context.WithCancel(context.Background())
+	// a finished call stays tracked until it is sent again, canceling it
+	// does nothing then
+	trackStream(&openStream{call: call, cancel: cancel, grpc: true})
+
+	return a.invokeGRPC(ctx, call)
+}
+
+// invokeGRPC calls the method of the call and returns a channel delivering
+// an OnGRPCMessageMsg for every response message and finally an
+// OnGRPCStatusMsg. Nothing more is sent once ctx is canceled.
+func (a *App) invokeGRPC(ctx context.Context, call *Call) chan tea.Msg {
+	messages := make(chan tea.Msg)
+
+	go func() {
+		defer close(messages)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		send := func(msg tea.Msg) {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+			}
+		}
+
+		started := time.Now()
+		done := func(err error, header metadata.MD, trailer metadata.MD) {
+			s := status.Convert(err)
+			send(OnGRPCStatusMsg{
+				Call:     call,
+				Code:     s.Code(),
+				Message:  s.Message(),
+				Header:   header,
+				Trailer:  trailer,
+				Duration: time.Since(started),
+			})
+		}
+
+		if call.GRPC == nil || call.GRPC.Service == "" || call.GRPC.Method == "" {
+			done(status.Error(codes.InvalidArgument, "no method selected, pick one in the Body tab"), nil, nil)
+			return
+		}
+
+		services, err := loadServices(ctx, call, false)
+		if err != nil {
+			done(err, nil, nil)
+			return
+		}
+		method := findMethod(services, call.GRPC.Service, call.GRPC.Method)
+		if method == nil {
+			done(status.Errorf(codes.Unimplemented, "method %s/%s not found", call.GRPC.Service, call.GRPC.Method), nil, nil)
+			return
+		}
+
+		requests, err := requestMessages(method, utils.SubstituteVariables(call.Data, a.Variables(call)))
+		if err != nil {
+			done(status.Error(codes.InvalidArgument, err.Error()), nil, nil)
+			return
+		}
+
+		conn, err := dialGRPC(call)
+		if err != nil {
+			done(err, nil, nil)
+			return
+		}
+		defer conn.Close()
+
+		var header, trailer metadata.MD
+		ctx = metadata.NewOutgoingContext(ctx, grpcMetadata(call))
+		stream, err := conn.NewStream(
+			ctx,
+			&grpc.StreamDesc{ServerStreams: method.IsStreamingServer(), ClientStreams: method.IsStreamingClient()},
+			fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name()),
+			grpc.Header(&header),
+			grpc.Trailer(&trailer),
+		)
+		if err != nil {
+			done(err, header, trailer)
+			return
+		}
+
+		for _, request := range requests {
+			if err := stream.SendMsg(request); err != nil {
+				break
+			}
+		}
+		stream.CloseSend()
+
+		for {
+			response := dynamicpb.NewMessage(method.Output())
+			err := stream.RecvMsg(response)
+			if err == io.EOF {
+				done(nil, header, trailer)
+				return
+			}
+			if err != nil {
+				done(err, header, trailer)
+				return
+			}
+			// protojson output is deliberately unstable, indent it ourselves
+			data, _ := grpcJSON.Marshal(response)
+			var body bytes.Buffer
+			json.Indent(&body, data, "", "  ")
+			send(OnGRPCMessageMsg{Call: call, Message: body.String(), next: waitForResponse(messages)})
+		}
+	}()
+
+	return messages
+}
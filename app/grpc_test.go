@@ -0,0 +1,207 @@
+package app
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func startGRPCServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return "grpc://" + listener.Addr().String()
+}
+
+func TestIsGRPCUrl(t *testing.T) {
+	tests := map[string]bool{
+		"grpc://localhost:50051":  true,
+		"grpcs://example.com:443": true,
+		"https://example.com":     false,
+		"localhost:50051":         false,
+	}
+	for url, want := range tests {
+		if got := IsGRPCUrl(url); got != want {
+			t.Errorf("IsGRPCUrl(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestDiscoverGRPC(t *testing.T) {
+	call := NewCall()
+	call.Url = startGRPCServer(t)
+
+	msg := GetInstance().DiscoverGRPC(call, true)().(GRPCServicesMsg)
+	if msg.Err != nil {
+		t.Fatalf("DiscoverGRPC() error = %v", msg.Err)
+	}
+
+	names := []string{}
+	for _, method := range msg.Methods {
+		names = append(names, method.FullName())
+	}
+	want := "grpc.health.v1.Health/Check,grpc.health.v1.Health/Watch"
+	if strings.Join(names, ",") != want {
+		t.Errorf("DiscoverGRPC() methods = %v, want %s", names, want)
+	}
+	if !msg.Methods[1].ServerStreaming || msg.Methods[0].ServerStreaming {
+		t.Errorf("DiscoverGRPC() streaming flags are wrong: %+v", msg.Methods)
+	}
+	if !strings.Contains(msg.Methods[0].Template, `"service": ""`) {
+		t.Errorf("DiscoverGRPC() template = %s", msg.Methods[0].Template)
+	}
+}
+
+func TestInvokeGRPC(t *testing.T) {
+	call := NewCall()
+	call.Url = startGRPCServer(t)
+	call.Headers = Headers{{Key: "X-Request-Id", Value: "42"}}
+	call.GRPC = &GRPC{Service: "grpc.health.v1.Health", Method: "Check"}
+	call.Data = `{"service": ""}`
+
+	messages := GetInstance().invokeGRPC(context.Background(), call)
+	message, ok := (<-messages).(OnGRPCMessageMsg)
+	if !ok || !strings.Contains(message.Message, `"status": "SERVING"`) {
+		t.Errorf("invokeGRPC() message = %+v", message)
+	}
+	done := (<-messages).(OnGRPCStatusMsg)
+	if done.Code != codes.OK {
+		t.Errorf("invokeGRPC() code = %v, %s", done.Code, done.Message)
+	}
+
+	call.Data = `{"service": "unknown"}`
+	messages = GetInstance().invokeGRPC(context.Background(), call)
+	done = (<-messages).(OnGRPCStatusMsg)
+	if done.Code != codes.NotFound {
+		t.Errorf("invokeGRPC() code = %v, want NotFound", done.Code)
+	}
+
+	call.Data = `{"unknown": 1}`
+	messages = GetInstance().invokeGRPC(context.Background(), call)
+	done = (<-messages).(OnGRPCStatusMsg)
+	if done.Code != codes.InvalidArgument {
+		t.Errorf("invokeGRPC() code = %v, want InvalidArgument", done.Code)
+	}
+}
+
+func TestExecuteGRPC(t *testing.T) {
+	call := NewCall()
+	call.Url = startGRPCServer(t)
+	call.GRPC = &GRPC{Service: "grpc.health.v1.Health", Method: "Check"}
+	call.Data = `{"service": ""}`
+	call.Assertions = []Assertion{
+		{Subject: ASSERT_STATUS, Operator: "==", Value: "0"},
+		{Subject: ASSERT_JSONPATH, Target: "$.status", Operator: "==", Value: `"SERVING"`},
+		{Subject: ASSERT_BODY, Operator: "contains", Value: "NOT_SERVING"},
+	}
+
+	result := GetInstance().Execute(context.Background(), call)
+	if result.Err != nil {
+		t.Fatalf("Execute() error = %v", result.Err)
+	}
+	if len(result.Assertions) != 3 || !result.Assertions[0].Passed || !result.Assertions[1].Passed || result.Assertions[2].Passed {
+		t.Errorf("Execute() assertions = %+v", result.Assertions)
+	}
+	if result.Passed() {
+		t.Errorf("Execute() should fail with a failed assertion")
+	}
+
+	// extractions and scripts see the response like for HTTP calls
+	call.Assertions = nil
+	call.Extractions = []Extraction{{Variable: "status", Scope: SCOPE_COLLECTION, Source: EXTRACT_JSONPATH, Expression: "$.status"}}
+	call.Scripts = &Scripts{PostResponse: `rm.variables.set("code", String(rm.response.status))`}
+	result = GetInstance().Execute(context.Background(), call)
+	variables := map[string]string{}
+	for _, change := range result.Variables {
+		variables[change.Name] = change.Value
+	}
+	if variables["status"] != "SERVING" || variables["code"] != "0" {
+		t.Errorf("Execute() variables = %+v", result.Variables)
+	}
+}
+
+func TestStopGRPCStream(t *testing.T) {
+	call := NewCall()
+	call.Url = startGRPCServer(t)
+	call.GRPC = &GRPC{Service: "grpc.health.v1.Health", Method: "Watch"}
+	call.Data = `{"service": ""}`
+
+	messages := GetInstance().startGRPC(call)
+	if _, ok := (<-messages).(OnGRPCMessageMsg); !ok {
+		t.Fatalf("Expected a message of the stream")
+	}
+
+	GetInstance().StopStream(call)
+	select {
+	case msg, ok := <-messages:
+		if ok {
+			t.Errorf("Expected no message once stopped, got %#v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for the stream to stop")
+	}
+}
+
+func TestDiscoverGRPCFromProtoFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "greeter.proto")
+	os.WriteFile(file, []byte(`syntax = "proto3";
+package demo;
+
+import "google/protobuf/timestamp.proto";
+
+enum Mood { HAPPY = 0; SAD = 1; }
+
+message HelloRequest {
+  string name = 1;
+  int64 count = 2;
+  repeated string tags = 3;
+  Mood mood = 4;
+  google.protobuf.Timestamp at = 5;
+}
+message HelloReply { string message = 1; }
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+  rpc Chat (stream HelloRequest) returns (stream HelloReply);
+}
+`), 0644)
+
+	call := NewCall()
+	call.Url = "grpc://localhost:1"
+	call.GRPC = &GRPC{ProtoFiles: []string{file}}
+
+	msg := GetInstance().DiscoverGRPC(call, false)().(GRPCServicesMsg)
+	if msg.Err != nil {
+		t.Fatalf("DiscoverGRPC() error = %v", msg.Err)
+	}
+	if len(msg.Methods) != 2 || msg.Methods[0].FullName() != "demo.Greeter/Chat" {
+		t.Fatalf("DiscoverGRPC() methods = %+v", msg.Methods)
+	}
+	if !msg.Methods[0].ClientStreaming || !msg.Methods[0].ServerStreaming {
+		t.Errorf("Chat should be bidi streaming")
+	}
+
+	template := msg.Methods[1].Template
+	for _, field := range []string{`"name": ""`, `"count": "0"`, `"tags": [`, `"mood": "HAPPY"`, `"at": {`} {
+		if !strings.Contains(template, field) {
+			t.Errorf("template %s is missing %s", template, field)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Header is a request header of a call, disabled headers are kept but not
+// sent
+type Header struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// ParseHeader reads a `Key: Value` line, the value is everything after the
+// first colon
+func ParseHeader(line string) Header {
+	key, value, _ := strings.Cut(line, ":")
+	return Header{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)}
+}
+
+func (h Header) String() string {
+	return h.Key + ": " + h.Value
+}
+
+// Headers are the request headers of a call in their order
+type Headers []Header
+
+// UnmarshalJSON reads the headers, collections saved by older versions
+// store them as `Key: Value` strings
+func (h *Headers) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	headers := Headers{}
+	for _, item := range raw {
+		var line string
+		if err := json.Unmarshal(item, &line); err == nil {
+			if strings.TrimSpace(line) != "" {
+				headers = append(headers, ParseHeader(line))
+			}
+			continue
+		}
+		var header Header
+		if err := json.Unmarshal(item, &header); err != nil {
+			return err
+		}
+		headers = append(headers, header)
+	}
+	*h = headers
+	return nil
+}
+
+// Enabled returns the headers which are sent
+func (h Headers) Enabled() Headers {
+	enabled := Headers{}
+	for _, header := range h {
+		if !header.Disabled && header.Key != "" {
+			enabled = append(enabled, header)
+		}
+	}
+	return enabled
+}
+
+// Get returns the value of the first enabled header with the name, names
+// are case-insensitive
+func (h Headers) Get(key string) string {
+	for _, header := range h.Enabled() {
+		if strings.EqualFold(header.Key, key) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// Text returns the headers as `Key: Value` lines for bulk editing, disabled
+// headers are commented out with #
+func (h Headers) Text() string {
+	lines := make([]string, 0, len(h))
+	for _, header := range h {
+		line := header.String()
+		if header.Disabled {
+			line = "# " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseHeadersText reads the lines written by Text, empty lines and lines
+// without a name are left out
+func ParseHeadersText(text string) Headers {
+	headers := Headers{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		disabled := strings.HasPrefix(line, "#")
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		header := ParseHeader(line)
+		if header.Key == "" {
+			continue
+		}
+		header.Disabled = disabled
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+// StandardHeaders are the request header names suggested by the editor
+var StandardHeaders = []string{
+	"Accept",
+	"Accept-Charset",
+	"Accept-Encoding",
+	"Accept-Language",
+	"Authorization",
+	"Cache-Control",
+	"Connection",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Type",
+	"Cookie",
+	"DNT",
+	"Date",
+	"Expect",
+	"Forwarded",
+	"From",
+	"Host",
+	"If-Match",
+	"If-Modified-Since",
+	"If-None-Match",
+	"If-Range",
+	"If-Unmodified-Since",
+	"Origin",
+	"Pragma",
+	"Prefer",
+	"Range",
+	"Referer",
+	"TE",
+	"Upgrade",
+	"User-Agent",
+	"Via",
+	"X-API-Key",
+	"X-Correlation-ID",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+	"X-Request-ID",
+	"X-Requested-With",
+}
+
+var contentTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"application/graphql",
+	"application/octet-stream",
+	"application/pdf",
+	"multipart/form-data",
+	"text/plain",
+	"text/html",
+	"text/csv",
+	"text/xml",
+}
+
+// HeaderValueSuggestions returns common values of the header
+func HeaderValueSuggestions(key string) []string {
+	switch strings.ToLower(key) {
+	case "accept":
+		return append([]string{"*/*", "application/json, text/plain, */*", "text/event-stream"}, contentTypes...)
+	case "content-type":
+		return contentTypes
+	case "accept-encoding":
+		return []string{"gzip, deflate, br", "gzip", "deflate", "br", "identity"}
+	case "accept-language":
+		return []string{"en-US,en;q=0.9", "en", "de", "fr", "es"}
+	case "authorization":
+		return []string{"Bearer {{token}}", "Basic ", "Bearer "}
+	case "cache-control", "pragma":
+		return []string{"no-cache", "no-store", "max-age=0", "must-revalidate", "public", "private"}
+	case "connection":
+		return []string{"keep-alive", "close"}
+	case "prefer":
+		return []string{"return=minimal", "return=representation", "respond-async"}
+	case "x-requested-with":
+		return []string{"XMLHttpRequest"}
+	}
+	return nil
+}
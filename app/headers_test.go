@@ -0,0 +1,73 @@
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		line string
+		want Header
+	}{
+		{"Content-Type: application/json", Header{Key: "Content-Type", Value: "application/json"}},
+		{"Referer:https://example.com:8080/path", Header{Key: "Referer", Value: "https://example.com:8080/path"}},
+		{"X-Time: 12:30:00", Header{Key: "X-Time", Value: "12:30:00"}},
+		{"X-Empty", Header{Key: "X-Empty"}},
+	}
+	for _, tt := range tests {
+		if got := ParseHeader(tt.line); got != tt.want {
+			t.Errorf("ParseHeader(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHeadersUnmarshalLegacy(t *testing.T) {
+	var call Call
+	data := `{"id": "1", "headers": ["Authorization: Bearer a:b", "", {"key": "X-Off", "value": "1", "disabled": true}]}`
+	if err := json.Unmarshal([]byte(data), &call); err != nil {
+		t.Fatal(err)
+	}
+	want := Headers{
+		{Key: "Authorization", Value: "Bearer a:b"},
+		{Key: "X-Off", Value: "1", Disabled: true},
+	}
+	if !reflect.DeepEqual(call.Headers, want) {
+		t.Errorf("Headers = %+v, want %+v", call.Headers, want)
+	}
+
+	saved, _ := json.Marshal(call.Headers)
+	if string(saved) != `[{"key":"Authorization","value":"Bearer a:b"},{"key":"X-Off","value":"1","disabled":true}]` {
+		t.Errorf("saved headers = %s", saved)
+	}
+}
+
+func TestHeadersText(t *testing.T) {
+	headers := Headers{
+		{Key: "Accept", Value: "*/*"},
+		{Key: "X-Debug", Value: "on", Disabled: true},
+		{Key: "Referer", Value: "http://localhost:3000/"},
+	}
+	text := headers.Text()
+	if text != "Accept: */*\n# X-Debug: on\nReferer: http://localhost:3000/" {
+		t.Errorf("Text() = %q", text)
+	}
+	if got := ParseHeadersText(text + "\n\n: no name"); !reflect.DeepEqual(got, headers) {
+		t.Errorf("ParseHeadersText() = %+v, want %+v", got, headers)
+	}
+}
+
+func TestDisabledHeadersAreNotSent(t *testing.T) {
+	call := Call{Method: "GET", Url: "http://localhost", Headers: Headers{
+		{Key: "Referer", Value: "http://localhost:3000/"},
+		{Key: "X-Debug", Value: "on", Disabled: true},
+	}}
+	headers := call.RequestParams().Headers
+	if len(headers) != 1 || headers["Referer"] != "http://localhost:3000/" {
+		t.Errorf("RequestParams().Headers = %v", headers)
+	}
+	if call.HeadersCount() != 1 {
+		t.Errorf("HeadersCount() = %d, want 1", call.HeadersCount())
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// number of responses remembered for every call
+const historySize = 10
+
+// Response is a snapshot of a received response, either kept in the
+// in-memory history or saved on a call as an example.
+type Response struct {
+	Name       string      `json:"name,omitempty"`
+	Status     int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	ReceivedAt time.Time   `json:"received_at"`
+	bodyFile   string
+}
+
+// Content returns the response body, bodies spilled to disk are read back
+// up to the in-memory cap.
+func (r Response) Content() string {
+	if r.bodyFile == "" {
+		return r.Body
+	}
+	f, err := os.Open(r.bodyFile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(io.LimitReader(f, MaxBodyInMemory()))
+	return string(data)
+}
+
+// responses received in this session, newest last
+var history struct {
+	sync.Mutex
+	responses map[string][]Response
+}
+
+func newResponse(response *http.Response, body string, bodyFile string) Response {
+	return Response{
+		Status:     response.StatusCode,
+		Headers:    response.Header.Clone(),
+		Body:       body,
+		ReceivedAt: time.Now(),
+		bodyFile:   bodyFile,
+	}
+}
+
+func recordResponse(call *Call, response Response) {
+	history.Lock()
+	defer history.Unlock()
+	if history.responses == nil {
+		history.responses = make(map[string][]Response)
+	}
+	responses := append(history.responses[call.ID], response)
+	if len(responses) > historySize {
+		responses = responses[len(responses)-historySize:]
+	}
+	history.responses[call.ID] = responses
+}
+
+// History returns the responses received for the call in this session,
+// newest last.
+func (a *App) History(call *Call) []Response {
+	history.Lock()
+	defer history.Unlock()
+	return append([]Response{}, history.responses[call.ID]...)
+}
+
+// SaveExample stores the last received response as an example on the call
+func (a *App) SaveExample(call *Call, name string) tea.Cmd {
+	responses := a.History(call)
+	if len(responses) == 0 {
+		return nil
+	}
+	example := responses[len(responses)-1]
+	example.Name = name
+	example.Body = example.Content()
+	example.bodyFile = ""
+	call.Examples = append(call.Examples, example)
+	return a.UpdateCall(call)
+}
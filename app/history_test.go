@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRecordResponse(t *testing.T) {
+	call := NewCall()
+	response := &http.Response{StatusCode: 200, Header: http.Header{"X-Run": {"1"}}}
+
+	for i := 0; i < historySize+2; i++ {
+		recordResponse(call, newResponse(response, "body", ""))
+	}
+
+	responses := GetInstance().History(call)
+	if len(responses) != historySize {
+		t.Errorf("Expected history to be capped at %d, got %d", historySize, len(responses))
+	}
+
+	if responses[0].Status != 200 || responses[0].Headers.Get("X-Run") != "1" {
+		t.Errorf("Expected recorded response to keep status and headers, got %+v", responses[0])
+	}
+}
+
+func TestSaveExample(t *testing.T) {
+	call := NewCall()
+
+	if cmd := GetInstance().SaveExample(call, "empty"); cmd != nil {
+		t.Errorf("Expected no command when there is no response yet")
+	}
+
+	recordResponse(call, newResponse(&http.Response{StatusCode: 201, Header: http.Header{}}, `{"id": 1}`, ""))
+	GetInstance().SaveExample(call, "created")
+
+	if len(call.Examples) != 1 {
+		t.Fatalf("Expected 1 example, got %d", len(call.Examples))
+	}
+
+	example := call.Examples[0]
+	if example.Name != "created" || example.Status != 201 || example.Body != `{"id": 1}` {
+		t.Errorf("Unexpected example %+v", example)
+	}
+}
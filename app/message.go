@@ -2,14 +2,44 @@ package app
 
 import (
 	"net/http"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 )
 
-type FetchCollectionsSuccessMsg struct{ Collections []Collection }
+// FetchCollectionsSuccessMsg is sent when the collections are read or
+// saved, Err is set when reading or writing them failed
+type FetchCollectionsSuccessMsg struct {
+	Collections []Collection
+	Err         error
+	// shown to the user when the collections were saved with a caveat
+	Notice string
+}
+
+// CollectionsChangedMsg is sent when the collections were changed on disk
+// outside restman
+type CollectionsChangedMsg struct{}
+
+// CollectionsConflictMsg is sent when collections or calls were changed both
+// in restman and on disk, they are saved once ResolveConflicts is called
+type CollectionsConflictMsg struct{ Names []string }
+
+// SecretsLockedMsg is sent when the secrets store has to be unlocked with
+// its passphrase
+type SecretsLockedMsg struct{}
 
 type CollectionSelectedMsg struct{ Collection *Collection }
 
 type CollectionEditMsg struct{ Collection *Collection }
 
+// FolderEditMsg opens the settings of a folder of the collection
+type FolderEditMsg struct {
+	Collection *Collection
+	Path       string
+}
+
 type CallSelectedMsg struct{ Call *Call }
 
 type CallUpdatedMsg struct{ Call *Call }
@@ -17,11 +47,206 @@ type CallUpdatedMsg struct{ Call *Call }
 type OnResponseMsg struct {
 	Call     *Call
 	Body     string
+	BodyFile string // set instead of Body when the body was spilled to disk
 	Bytes    int64
 	Err      error
 	Response *http.Response
+	Duration time.Duration
+	// results of the call assertions
+	Assertions []AssertionResult
+	// variables set by the extractions, see ApplyVariables
+	Variables []VariableChange
+}
+
+// OnProgressMsg is sent periodically while a response body is downloading
+type OnProgressMsg struct {
+	Call  *Call
+	Bytes int64
+	Total int64 // Content-Length, -1 when unknown
+	Rate  float64
+	next  tea.Cmd
+}
+
+// Next returns the command waiting for the next download update
+func (m OnProgressMsg) Next() tea.Cmd {
+	return m.next
 }
 
 type OnLoadingMsg struct{ Call *Call }
 
+type EnvironmentSelectedMsg struct{ Environment *Environment }
+
 type SetFocusMsg struct{ Item string }
+
+// StreamingMsg is implemented by messages of a running download or stream,
+// Next must be called to receive the following message
+type StreamingMsg interface {
+	Next() tea.Cmd
+}
+
+// OnEventStreamMsg is sent when a text/event-stream response was opened
+type OnEventStreamMsg struct {
+	Call     *Call
+	Response *http.Response
+	next     tea.Cmd
+}
+
+func (m OnEventStreamMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnEventMsg is sent for every Server-Sent Event received
+type OnEventMsg struct {
+	Call  *Call
+	Event Event
+	next  tea.Cmd
+}
+
+func (m OnEventMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnEventStreamClosedMsg is sent when an event stream was closed by the
+// server (and is about to reconnect) or stopped
+type OnEventStreamClosedMsg struct {
+	Call         *Call
+	Err          error
+	Reconnecting bool
+	next         tea.Cmd
+}
+
+func (m OnEventStreamClosedMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnWebSocketOpenMsg is sent when the WebSocket handshake succeeded
+type OnWebSocketOpenMsg struct {
+	Call     *Call
+	Response *http.Response
+	next     tea.Cmd
+}
+
+func (m OnWebSocketOpenMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnFrameMsg is sent for every WebSocket frame sent or received
+type OnFrameMsg struct {
+	Call  *Call
+	Frame Frame
+	next  tea.Cmd
+}
+
+func (m OnFrameMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnWebSocketClosedMsg is sent when the WebSocket connection was closed
+type OnWebSocketClosedMsg struct {
+	Call   *Call
+	Code   int
+	Reason string
+	Err    error
+}
+
+// SchemaFetchedMsg is sent when a GraphQL introspection query finished
+type SchemaFetchedMsg struct {
+	Call   *Call
+	Schema *Schema
+	Err    error
+}
+
+// GRPCServicesMsg is sent when the methods of a gRPC server were discovered
+type GRPCServicesMsg struct {
+	Call    *Call
+	Methods []GRPCMethod
+	Err     error
+}
+
+// OnGRPCMessageMsg is sent for every gRPC response message
+type OnGRPCMessageMsg struct {
+	Call    *Call
+	Message string
+	next    tea.Cmd
+}
+
+func (m OnGRPCMessageMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnGRPCStatusMsg is sent when a gRPC call finished
+type OnGRPCStatusMsg struct {
+	Call     *Call
+	Code     codes.Code
+	Message  string
+	Header   metadata.MD
+	Trailer  metadata.MD
+	Duration time.Duration
+}
+
+// OnRunProgressMsg is sent for every call sent by a collection run
+type OnRunProgressMsg struct {
+	Result RunResult
+	Total  int
+	next   tea.Cmd
+}
+
+func (m OnRunProgressMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnRunFinishedMsg is sent when a collection run finished or was canceled
+type OnRunFinishedMsg struct {
+	Results []RunResult
+	Err     error
+}
+
+// RunCollectionMsg asks to open the runner for a collection
+type RunCollectionMsg struct{ Collection *Collection }
+
+// OnBenchProgressMsg is sent a few times a second while a call is
+// benchmarked
+type OnBenchProgressMsg struct {
+	Stats BenchStats
+	next  tea.Cmd
+}
+
+func (m OnBenchProgressMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnBenchFinishedMsg is sent when a benchmark finished or was canceled
+type OnBenchFinishedMsg struct {
+	Stats BenchStats
+	Err   error
+}
+
+// BenchCallMsg asks to open the benchmark of a call
+type BenchCallMsg struct{ Call *Call }
+
+// OnMockStartedMsg is sent when the mock server listens
+type OnMockStartedMsg struct {
+	Addr   string
+	Routes []string
+	next   tea.Cmd
+}
+
+func (m OnMockStartedMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnMockRequestMsg is sent for every request answered by the mock server
+type OnMockRequestMsg struct {
+	Entry MockLogEntry
+	next  tea.Cmd
+}
+
+func (m OnMockRequestMsg) Next() tea.Cmd {
+	return m.next
+}
+
+// OnMockStoppedMsg is sent when the mock server stopped or could not start
+type OnMockStoppedMsg struct{ Err error }
+
+// MockCollectionMsg asks to open the mock server of a collection
+type MockCollectionMsg struct{ Collection *Collection }
@@ -0,0 +1,598 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// sources of the answers of the mock server
+const (
+	MOCK_EXAMPLE   = "example"
+	MOCK_OPENAPI   = "openapi"
+	MOCK_INJECTED  = "injected"
+	MOCK_NOT_FOUND = "not found"
+)
+
+// MockOptions change how the mock server answers
+type MockOptions struct {
+	Latency time.Duration
+	// random extra latency, up to Jitter
+	Jitter time.Duration
+	// share of the requests answered with ErrorStatus, from 0 to 1
+	ErrorRate   float64
+	ErrorStatus int
+	// file or URL of an OpenAPI spec, instead of the one of the collection
+	Spec string
+}
+
+// MockLogEntry is a request answered by the mock server
+type MockLogEntry struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Status   int
+	Call     string // title of the matched call
+	Source   string
+	Duration time.Duration
+}
+
+// mockResponse is an answer of the mock server
+type mockResponse struct {
+	name    string
+	status  int
+	headers http.Header
+	body    string
+}
+
+type mockRoute struct {
+	call     Call
+	method   string
+	segments []string // empty for a path parameter
+	literals int
+	examples []mockResponse
+	spec     *mockResponse
+}
+
+// MockServer answers the calls of a collection with their saved examples,
+// or with data generated from the OpenAPI spec of the collection
+type MockServer struct {
+	routes   []mockRoute
+	basePath string
+	opts     MockOptions
+	log      func(MockLogEntry)
+}
+
+// NewMockServer routes requests by the method and path template of every
+// call of the collection, log is told about every request
+func NewMockServer(collection Collection, opts MockOptions, log func(MockLogEntry)) (*MockServer, error) {
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusInternalServerError
+	}
+	server := &MockServer{opts: opts, log: log}
+	if u, err := neturl.Parse(collection.BaseUrl); err == nil {
+		server.basePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	spec := opts.Spec
+	if spec == "" {
+		spec = collection.Spec
+	}
+	specResponses := map[string]mockResponse{}
+	if spec != "" {
+		var err error
+		if specResponses, err = loadSpecResponses(spec); err != nil {
+			return nil, fmt.Errorf("spec %s: %w", spec, err)
+		}
+	}
+
+	for _, call := range collection.Calls {
+		if call.IsWebSocket() || call.IsGRPC() {
+			continue
+		}
+		route := mockRoute{call: call, method: strings.ToUpper(call.Method), segments: mockSegments(mockPath(call.Url))}
+		for _, segment := range route.segments {
+			if segment != "" {
+				route.literals++
+			}
+		}
+		for _, example := range call.Examples {
+			route.examples = append(route.examples, mockResponse{
+				name:    example.Name,
+				status:  example.Status,
+				headers: example.Headers,
+				body:    example.Body,
+			})
+		}
+		if response, ok := specResponses[mockKey(route.method, route.segments)]; ok {
+			route.spec = &response
+		}
+		server.routes = append(server.routes, route)
+	}
+	// the most specific template wins, e.g. /users/me over /users/{id}
+	sort.SliceStable(server.routes, func(i, j int) bool {
+		return server.routes[i].literals > server.routes[j].literals
+	})
+	return server, nil
+}
+
+// Routes describes the routes of the server, one line per call
+func (s *MockServer) Routes() []string {
+	routes := []string{}
+	for _, route := range s.routes {
+		path := []string{}
+		for _, segment := range route.segments {
+			if segment == "" {
+				segment = "*"
+			}
+			path = append(path, segment)
+		}
+		source := fmt.Sprintf("%d examples", len(route.examples))
+		switch {
+		case len(route.examples) == 1:
+			source = "1 example"
+		case len(route.examples) == 0 && route.spec != nil:
+			source = "OpenAPI"
+		case len(route.examples) == 0:
+			source = "no examples"
+		}
+		routes = append(routes, fmt.Sprintf("%-7s /%s · %s · %s", route.method, strings.Join(path, "/"), route.call.Title(), source))
+	}
+	return routes
+}
+
+// mockPath returns the path of the URL of a call, without the host or the
+// variable the URL starts with
+func mockPath(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	if strings.HasPrefix(url, "{{") {
+		if end := strings.Index(url, "}}"); end >= 0 {
+			url = url[end+2:]
+		}
+	} else if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		if slash := strings.Index(url, "/"); slash >= 0 {
+			url = url[slash:]
+		} else {
+			url = ""
+		}
+	}
+	return "/" + strings.Trim(url, "/")
+}
+
+// mockSegments splits a path template, parameters like {id}, :id and
+// {{id}} become empty segments
+func mockSegments(path string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.Contains(segment, "{") || strings.HasPrefix(segment, ":") {
+			segment = ""
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+func mockKey(method string, segments []string) string {
+	path := []string{}
+	for _, segment := range segments {
+		if segment == "" {
+			segment = "*"
+		}
+		path = append(path, segment)
+	}
+	return strings.ToUpper(method) + " /" + strings.Join(path, "/")
+}
+
+func (r mockRoute) matches(segments []string) bool {
+	if len(segments) != len(r.segments) {
+		return false
+	}
+	for i, segment := range r.segments {
+		if segment != "" && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the route of the request, or whether another method is
+// routed for the path
+func (s *MockServer) find(method string, path string) (*mockRoute, bool) {
+	candidates := [][]string{mockSegments(path)}
+	if s.basePath != "" && strings.HasPrefix(path, s.basePath+"/") {
+		candidates = append(candidates, mockSegments(strings.TrimPrefix(path, s.basePath)))
+	}
+
+	otherMethod := false
+	for _, segments := range candidates {
+		for i, route := range s.routes {
+			if route.matches(segments) {
+				if route.method == method {
+					return &s.routes[i], false
+				}
+				otherMethod = true
+			}
+		}
+	}
+	return nil, otherMethod
+}
+
+// respond picks the answer of a route, the X-Mock-Example and X-Mock-Status
+// headers of the request choose an example by name or status
+func (r mockRoute) respond(request *http.Request) (mockResponse, bool) {
+	if name := request.Header.Get("X-Mock-Example"); name != "" {
+		for _, example := range r.examples {
+			if strings.EqualFold(example.name, name) {
+				return example, true
+			}
+		}
+	}
+	if status, err := strconv.Atoi(request.Header.Get("X-Mock-Status")); err == nil {
+		for _, example := range r.examples {
+			if example.status == status {
+				return example, true
+			}
+		}
+	}
+	for _, example := range r.examples {
+		if example.status >= 200 && example.status < 300 {
+			return example, true
+		}
+	}
+	if len(r.examples) > 0 {
+		return r.examples[0], true
+	}
+	if r.spec != nil {
+		return *r.spec, true
+	}
+	return mockResponse{}, false
+}
+
+// headers which no longer match the body or the connection of the mock
+var skippedMockHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Content-Encoding":  true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Date":              true,
+}
+
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	started := time.Now()
+	entry := MockLogEntry{Time: started, Method: request.Method, Path: request.URL.RequestURI()}
+	defer func() {
+		entry.Duration = time.Since(started)
+		if s.log != nil {
+			s.log(entry)
+		}
+	}()
+
+	// frontends run on another origin
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		origin = "*"
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Expose-Headers", "*")
+	if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", request.Header.Get("Access-Control-Request-Method"))
+		w.Header().Set("Access-Control-Allow-Headers", request.Header.Get("Access-Control-Request-Headers"))
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		entry.Status = http.StatusNoContent
+		w.WriteHeader(entry.Status)
+		return
+	}
+
+	latency := s.opts.Latency
+	if s.opts.Jitter > 0 {
+		latency += time.Duration(rand.Int63n(int64(s.opts.Jitter)))
+	}
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-request.Context().Done():
+			return
+		}
+	}
+
+	route, otherMethod := s.find(strings.ToUpper(request.Method), request.URL.Path)
+	if route != nil {
+		entry.Call = route.call.Title()
+	}
+
+	if s.opts.ErrorRate > 0 && rand.Float64() < s.opts.ErrorRate {
+		entry.Status, entry.Source = s.opts.ErrorStatus, MOCK_INJECTED
+		writeMockError(w, entry.Status, "error injected by the restman mock server")
+		return
+	}
+
+	if route == nil {
+		entry.Source = MOCK_NOT_FOUND
+		entry.Status = http.StatusNotFound
+		if otherMethod {
+			entry.Status = http.StatusMethodNotAllowed
+		}
+		writeMockError(w, entry.Status, fmt.Sprintf("no call matches %s %s", request.Method, request.URL.Path))
+		return
+	}
+
+	response, ok := route.respond(request)
+	if !ok {
+		entry.Source = MOCK_NOT_FOUND
+		entry.Status = http.StatusNotImplemented
+		writeMockError(w, entry.Status, fmt.Sprintf("%s has no saved examples", route.call.Title()))
+		return
+	}
+
+	entry.Source = MOCK_EXAMPLE
+	if len(route.examples) == 0 {
+		entry.Source = MOCK_OPENAPI
+	}
+	for name, values := range response.headers {
+		if skippedMockHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(name, strings.TrimSpace(value))
+		}
+	}
+	entry.Status = response.status
+	if entry.Status == 0 {
+		entry.Status = http.StatusOK
+	}
+	w.WriteHeader(entry.Status)
+	fmt.Fprint(w, response.body)
+}
+
+func writeMockError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// loadSpecResponses generates a response for every operation of an OpenAPI
+// spec, by method and path template
+func loadSpecResponses(location string) (map[string]mockResponse, error) {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	var doc *openapi3.T
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		var u *neturl.URL
+		if u, err = neturl.Parse(location); err == nil {
+			doc, err = loader.LoadFromURI(u)
+		}
+	} else {
+		doc, err = loader.LoadFromFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	responses := map[string]mockResponse{}
+	for path, item := range doc.Paths.Map() {
+		for method, operation := range item.Operations() {
+			if response, ok := specResponse(operation); ok {
+				responses[mockKey(method, mockSegments(path))] = response
+			}
+		}
+	}
+	return responses, nil
+}
+
+// specResponse generates the first successful response of an operation,
+// from its examples or its schema
+func specResponse(operation *openapi3.Operation) (mockResponse, bool) {
+	if operation.Responses == nil {
+		return mockResponse{}, false
+	}
+	codes := []string{}
+	for code := range operation.Responses.Map() {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var ref *openapi3.ResponseRef
+	status := http.StatusOK
+	for _, code := range codes {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			ref, status = operation.Responses.Value(code), n
+			break
+		}
+	}
+	if ref == nil {
+		ref = operation.Responses.Default()
+	}
+	if ref == nil || ref.Value == nil {
+		return mockResponse{}, false
+	}
+
+	response := mockResponse{name: "OpenAPI", status: status, headers: http.Header{}}
+	contentType, media := "", (*openapi3.MediaType)(nil)
+	for name, m := range ref.Value.Content {
+		if contentType == "" || strings.Contains(name, "json") {
+			contentType, media = name, m
+		}
+	}
+	if media == nil {
+		return response, true
+	}
+	response.headers.Set("Content-Type", contentType)
+
+	var value interface{}
+	switch {
+	case media.Example != nil:
+		value = media.Example
+	case len(media.Examples) > 0:
+		names := []string{}
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if example := media.Examples[names[0]]; example.Value != nil {
+			value = example.Value.Value
+		}
+	case media.Schema != nil:
+		value = mockValue(media.Schema.Value, 0)
+	}
+	if s, ok := value.(string); ok && !strings.Contains(contentType, "json") {
+		response.body = s
+	} else if body, err := json.MarshalIndent(value, "", "  "); err == nil {
+		response.body = string(body)
+	}
+	return response, true
+}
+
+// mockValue generates a value matching the schema, preferring its example,
+// enum and default
+func mockValue(schema *openapi3.Schema, depth int) interface{} {
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, ref := range schema.AllOf {
+			if object, ok := mockValue(ref.Value, depth+1).(map[string]interface{}); ok {
+				for name, value := range object {
+					merged[name] = value
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return mockValue(schema.OneOf[0].Value, depth+1)
+	case len(schema.AnyOf) > 0:
+		return mockValue(schema.AnyOf[0].Value, depth+1)
+	}
+
+	switch {
+	case isType(schema, "object") || len(schema.Properties) > 0:
+		object := map[string]interface{}{}
+		for name, ref := range schema.Properties {
+			object[name] = mockValue(ref.Value, depth+1)
+		}
+		return object
+	case isType(schema, "array"):
+		if schema.Items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{mockValue(schema.Items.Value, depth+1)}
+	case isType(schema, "integer"):
+		if schema.Min != nil {
+			return int(*schema.Min)
+		}
+		return 1
+	case isType(schema, "number"):
+		if schema.Min != nil {
+			return *schema.Min
+		}
+		return 1.5
+	case isType(schema, "boolean"):
+		return true
+	case isType(schema, "string"):
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T12:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "uuid":
+			return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// the mock server started from the UI
+var activeMock struct {
+	server *http.Server
+}
+
+// StartMock serves the collection on addr in the background. An
+// OnMockStartedMsg is sent once it listens, then an OnMockRequestMsg for
+// every request and an OnMockStoppedMsg when it stopped.
+func (a *App) StartMock(collection Collection, addr string, opts MockOptions) tea.Cmd {
+	a.StopMock()
+
+	messages := make(chan tea.Msg)
+	var logging struct {
+		sync.RWMutex
+		stopped bool
+	}
+	mock, err := NewMockServer(collection, opts, func(entry MockLogEntry) {
+		logging.RLock()
+		defer logging.RUnlock()
+		if !logging.stopped {
+			messages <- OnMockRequestMsg{Entry: entry, next: waitForResponse(messages)}
+		}
+	})
+	if err != nil {
+		return func() tea.Msg { return OnMockStoppedMsg{Err: err} }
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return func() tea.Msg { return OnMockStoppedMsg{Err: err} }
+	}
+	server := &http.Server{Handler: mock}
+	activeMock.server = server
+
+	go func() {
+		defer close(messages)
+		messages <- OnMockStartedMsg{Addr: listener.Addr().String(), Routes: mock.Routes(), next: waitForResponse(messages)}
+		err := server.Serve(listener)
+		if err == http.ErrServerClosed {
+			err = nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if server.Shutdown(ctx) != nil {
+			server.Close()
+		}
+		// handlers cancelled by Close still log, the entries being sent
+		// are waited for and later ones dropped before the channel closes
+		logging.Lock()
+		logging.stopped = true
+		logging.Unlock()
+		messages <- OnMockStoppedMsg{Err: err}
+	}()
+	return waitForResponse(messages)
+}
+
+// StopMock stops the mock server started from the UI, if any. It does not
+// wait, an OnMockStoppedMsg is sent once the server stopped.
+func (a *App) StopMock() {
+	if activeMock.server != nil {
+		go activeMock.server.Shutdown(context.Background())
+		activeMock.server = nil
+	}
+}
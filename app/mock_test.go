@@ -0,0 +1,152 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMockPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"{{BASE_URL}}/users/{id}?page=1", "/users/{id}"},
+		{"https://api.example.com/v1/users/", "/v1/users"},
+		{"https://api.example.com", "/"},
+		{"/users/:id", "/users/:id"},
+	}
+	for _, test := range tests {
+		if got := mockPath(test.url); got != test.want {
+			t.Errorf("mockPath(%q) = %q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+const testSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "pets", "version": "1"},
+  "paths": {
+    "/pets/{petId}": {
+      "get": {
+        "operationId": "getPet",
+        "responses": {
+          "200": {
+            "description": "a pet",
+            "content": {"application/json": {"schema": {
+              "type": "object",
+              "properties": {
+                "id": {"type": "integer"},
+                "name": {"type": "string", "example": "Rex"},
+                "status": {"type": "string", "enum": ["available", "sold"]},
+                "born": {"type": "string", "format": "date"},
+                "tags": {"type": "array", "items": {"type": "string"}}
+              }
+            }}}
+          },
+          "404": {"description": "not found"}
+        }
+      }
+    }
+  }
+}`
+
+func TestMockServer(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "pets.json")
+	if err := os.WriteFile(spec, []byte(testSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collection := Collection{Name: "api", BaseUrl: "https://api.example.com/v1", Spec: spec, Calls: []Call{
+		{ID: "user", Name: "user", Method: "GET", Url: "{{BASE_URL}}/users/{{id}}", Examples: []Response{
+			{Name: "missing", Status: 404, Body: `{"error": "no user"}`},
+			{Name: "alice", Status: 200, Headers: http.Header{"Content-Type": {" application/json"}, "Content-Length": {"99"}}, Body: `{"name": "alice"}`},
+		}},
+		{ID: "me", Name: "me", Method: "GET", Url: "{{BASE_URL}}/users/me", Examples: []Response{{Status: 200, Body: "me"}}},
+		{ID: "getPet", Method: "GET", Url: "{{BASE_URL}}/pets/{petId}"},
+		{ID: "create", Method: "POST", Url: "{{BASE_URL}}/users"},
+	}}
+
+	entries := []MockLogEntry{}
+	mock, err := NewMockServer(collection, MockOptions{}, func(entry MockLogEntry) { entries = append(entries, entry) })
+	if err != nil {
+		t.Fatalf("NewMockServer() error = %v", err)
+	}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	send := func(method, path string, headers map[string]string) (*http.Response, string) {
+		request, _ := http.NewRequest(method, server.URL+path, nil)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return response, string(body)
+	}
+
+	tests := []struct {
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"GET", "/users/42", nil, 200, `{"name": "alice"}`},
+		{"GET", "/v1/users/42", nil, 200, `{"name": "alice"}`},
+		{"GET", "/users/42", map[string]string{"X-Mock-Status": "404"}, 404, `{"error": "no user"}`},
+		{"GET", "/users/42", map[string]string{"X-Mock-Example": "Missing"}, 404, `{"error": "no user"}`},
+		{"GET", "/users/me", nil, 200, "me"},
+		{"DELETE", "/users/42", nil, 405, ""},
+		{"GET", "/nope", nil, 404, ""},
+		{"POST", "/users", nil, 501, ""},
+	}
+	for _, test := range tests {
+		response, body := send(test.method, test.path, test.headers)
+		if response.StatusCode != test.status || (test.body != "" && body != test.body) {
+			t.Errorf("%s %s = %d %s, want %d %s", test.method, test.path, response.StatusCode, body, test.status, test.body)
+		}
+	}
+
+	response, _ := send("GET", "/users/1", nil)
+	if response.Header.Get("Content-Type") != "application/json" || response.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("headers = %v", response.Header)
+	}
+
+	response, body := send("GET", "/pets/7", nil)
+	var pet map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &pet); err != nil || response.StatusCode != 200 {
+		t.Fatalf("generated response = %d %s", response.StatusCode, body)
+	}
+	if pet["name"] != "Rex" || pet["status"] != "available" || pet["born"] != "2024-01-01" || pet["id"] != 1.0 || len(pet["tags"].([]interface{})) != 1 {
+		t.Errorf("generated pet = %v", pet)
+	}
+
+	response, _ = send("OPTIONS", "/users/1", map[string]string{"Origin": "http://localhost:3000", "Access-Control-Request-Method": "PUT"})
+	if response.StatusCode != 204 || response.Header.Get("Access-Control-Allow-Origin") != "http://localhost:3000" {
+		t.Errorf("preflight = %d %v", response.StatusCode, response.Header)
+	}
+
+	last := entries[len(entries)-1]
+	if len(entries) != len(tests)+3 || entries[len(entries)-2].Source != MOCK_OPENAPI || entries[0].Call != "user" || last.Status != 204 {
+		t.Errorf("log = %+v", entries)
+	}
+
+	mock.opts.ErrorRate, mock.opts.ErrorStatus = 1, 503
+	if response, body := send("GET", "/users/1", nil); response.StatusCode != 503 || !strings.Contains(body, "injected") {
+		t.Errorf("injected error = %d %s", response.StatusCode, body)
+	}
+
+	if _, err := NewMockServer(Collection{Spec: "missing.json"}, MockOptions{}, nil); err == nil {
+		t.Errorf("NewMockServer() with a missing spec should fail")
+	}
+}
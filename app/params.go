@@ -0,0 +1,148 @@
+package app
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Param is a query parameter of a call. Enabled params live in the URL,
+// disabled ones are only kept on the call.
+type Param struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// NoValue is set for keys written without =, like ?flag
+	NoValue bool `json:"no_value,omitempty"`
+	// raw is the pair as written in the URL, encoded again while the param
+	// is not edited
+	raw string
+}
+
+// Edited returns the param with the key and value entered in the table
+func (p Param) Edited(key, value string) Param {
+	p.Key, p.Value = key, value
+	return p
+}
+
+// variables like {{id}} are kept as they are when params are encoded
+var templateRegex = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// splitUrl splits a URL into the part before the query, the raw query and
+// the fragment with its #
+func splitUrl(rawUrl string) (string, string, string) {
+	fragment := ""
+	if i := strings.Index(rawUrl, "#"); i >= 0 {
+		rawUrl, fragment = rawUrl[:i], rawUrl[i:]
+	}
+	if i := strings.Index(rawUrl, "?"); i >= 0 {
+		return rawUrl[:i], rawUrl[i+1:], fragment
+	}
+	return rawUrl, "", fragment
+}
+
+// ParseParams returns the query params of the URL in their order,
+// repeated keys included
+func ParseParams(rawUrl string) []Param {
+	_, query, _ := splitUrl(rawUrl)
+	params := []Param{}
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		params = append(params, parseParam(pair))
+	}
+	return params
+}
+
+func parseParam(pair string) Param {
+	key, value, found := strings.Cut(pair, "=")
+	return Param{Key: unescapeQuery(key), Value: unescapeQuery(value), NoValue: !found, raw: pair}
+}
+
+// encode returns the pair of the param in the query. Params which were not
+// edited keep the text they were written with, the others are escaped.
+func (p Param) encode() string {
+	if p.raw != "" {
+		if written := parseParam(p.raw); written.Key == p.Key && written.Value == p.Value && written.NoValue == p.NoValue {
+			return p.raw
+		}
+	}
+	if p.NoValue && p.Value == "" {
+		return escapeQuery(p.Key)
+	}
+	return escapeQuery(p.Key) + "=" + escapeQuery(p.Value)
+}
+
+func unescapeQuery(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+// escapeQuery percent-encodes a key or value of the query, variables are
+// left as they are to be substituted when the call is sent
+func escapeQuery(s string) string {
+	escaped := strings.Builder{}
+	last := 0
+	for _, match := range templateRegex.FindAllStringIndex(s, -1) {
+		escaped.WriteString(url.QueryEscape(s[last:match[0]]))
+		escaped.WriteString(s[match[0]:match[1]])
+		last = match[1]
+	}
+	escaped.WriteString(url.QueryEscape(s[last:]))
+	return escaped.String()
+}
+
+// EncodeParams returns the URL with its query replaced by the enabled
+// params
+func EncodeParams(rawUrl string, params []Param) string {
+	base, _, fragment := splitUrl(rawUrl)
+	pairs := []string{}
+	for _, param := range params {
+		if param.Disabled || (param.Key == "" && param.Value == "") {
+			continue
+		}
+		pairs = append(pairs, param.encode())
+	}
+	if len(pairs) == 0 {
+		return base + fragment
+	}
+	return base + "?" + strings.Join(pairs, "&") + fragment
+}
+
+// QueryParams returns the params of the call in the order of the table.
+// The URL decides which params are enabled, the disabled params stored on
+// the call keep their position between them.
+func (i Call) QueryParams() []Param {
+	enabled := ParseParams(i.Url)
+	if len(i.Params) == 0 {
+		return enabled
+	}
+
+	params := []Param{}
+	for _, param := range i.Params {
+		if param.Disabled {
+			params = append(params, param)
+		} else if len(enabled) > 0 {
+			params = append(params, enabled[0])
+			enabled = enabled[1:]
+		}
+	}
+	return append(params, enabled...)
+}
+
+// SetQueryParams stores the params of the table on the call, the enabled
+// ones are encoded into the URL
+func (i *Call) SetQueryParams(params []Param) {
+	i.Url = EncodeParams(i.Url, params)
+	i.Params = nil
+	for _, param := range params {
+		if param.Disabled {
+			// the order is only needed to place the disabled params
+			i.Params = append([]Param{}, params...)
+			break
+		}
+	}
+}
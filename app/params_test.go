@@ -0,0 +1,126 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		url  string
+		want []Param
+	}{
+		{"https://example.com", []Param{}},
+		{"https://example.com?", []Param{}},
+		{"https://example.com?a=1&b=2&a=3", []Param{{Key: "a", Value: "1", raw: "a=1"}, {Key: "b", Value: "2", raw: "b=2"}, {Key: "a", Value: "3", raw: "a=3"}}},
+		{"https://example.com?empty=&flag", []Param{{Key: "empty", raw: "empty="}, {Key: "flag", NoValue: true, raw: "flag"}}},
+		{"https://example.com?q=a%20b%26c&name=x+y", []Param{{Key: "q", Value: "a b&c", raw: "q=a%20b%26c"}, {Key: "name", Value: "x y", raw: "name=x+y"}}},
+		{"{{BASE}}/users?id={{id}}#top", []Param{{Key: "id", Value: "{{id}}", raw: "id={{id}}"}}},
+		{"https://example.com?bad=%zz", []Param{{Key: "bad", Value: "%zz", raw: "bad=%zz"}}},
+	}
+	for _, tt := range tests {
+		if got := ParseParams(tt.url); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseParams(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeParams(t *testing.T) {
+	tests := []struct {
+		url    string
+		params []Param
+		want   string
+	}{
+		{"https://example.com?old=1", []Param{{Key: "a", Value: "1"}, {Key: "a", Value: "2"}}, "https://example.com?a=1&a=2"},
+		{"https://example.com", []Param{{Key: "q", Value: "a b&c=d"}}, "https://example.com?q=a+b%26c%3Dd"},
+		{"https://example.com", []Param{{Key: "empty"}}, "https://example.com?empty="},
+		{"https://example.com", []Param{{Key: "flag", NoValue: true}}, "https://example.com?flag"},
+		{"https://example.com?a=1#top", []Param{{Key: "a", Value: "1", Disabled: true}}, "https://example.com#top"},
+		{"{{BASE}}/users", []Param{{Key: "id", Value: "{{id}} x"}}, "{{BASE}}/users?id={{id}}+x"},
+	}
+	for _, tt := range tests {
+		if got := EncodeParams(tt.url, tt.params); got != tt.want {
+			t.Errorf("EncodeParams(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+		// encoding is reversible for the enabled params
+		enabled := []Param{}
+		for _, param := range tt.params {
+			if !param.Disabled {
+				enabled = append(enabled, param)
+			}
+		}
+		got := ParseParams(EncodeParams(tt.url, tt.params))
+		for i := range got {
+			got[i].raw = ""
+		}
+		if !reflect.DeepEqual(got, enabled) {
+			t.Errorf("ParseParams(EncodeParams(%q)) = %v, want %v", tt.url, got, enabled)
+		}
+	}
+}
+
+func TestEncodeParamsKeepsUnedited(t *testing.T) {
+	rawUrl := "https://example.com/search?flag&x=a,b&at=user@host:80/p"
+	params := ParseParams(rawUrl)
+	if got := EncodeParams(rawUrl, params); got != rawUrl {
+		t.Errorf("EncodeParams() = %q, want %q", got, rawUrl)
+	}
+
+	// editing one param leaves the others as they were written
+	params[2] = params[2].Edited("at", "other")
+	if got, want := EncodeParams(rawUrl, params), "https://example.com/search?flag&x=a,b&at=other"; got != want {
+		t.Errorf("EncodeParams() = %q, want %q", got, want)
+	}
+
+	// a param edited without changes is not encoded again
+	params[1] = params[1].Edited("x", "a,b")
+	params[0] = params[0].Edited("flag", "")
+	if got, want := EncodeParams(rawUrl, params), "https://example.com/search?flag&x=a,b&at=other"; got != want {
+		t.Errorf("EncodeParams() = %q, want %q", got, want)
+	}
+
+	// disabling and enabling a param keeps it as well
+	call := Call{Url: "https://example.com?flag&x=a,b"}
+	params = call.QueryParams()
+	params[1].Disabled = true
+	call.SetQueryParams(params)
+	params = call.QueryParams()
+	params[1].Disabled = false
+	call.SetQueryParams(params)
+	if call.Url != "https://example.com?flag&x=a,b" {
+		t.Errorf("Url = %q", call.Url)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	call := Call{Url: "https://example.com?a=1"}
+	call.SetQueryParams([]Param{
+		{Key: "a", Value: "1"},
+		{Key: "token", Value: "secret", Disabled: true},
+		{Key: "b", Value: "2"},
+	})
+	if call.Url != "https://example.com?a=1&b=2" {
+		t.Errorf("Url = %q", call.Url)
+	}
+	if len(call.Params) != 3 || call.ParamsCount() != 2 {
+		t.Errorf("Params = %v, ParamsCount() = %d", call.Params, call.ParamsCount())
+	}
+
+	// typing in the URL keeps the disabled param at its position
+	call.Url = "https://example.com?a=10&b=20&c=30"
+	want := []Param{
+		{Key: "a", Value: "10", raw: "a=10"},
+		{Key: "token", Value: "secret", Disabled: true},
+		{Key: "b", Value: "20", raw: "b=20"},
+		{Key: "c", Value: "30", raw: "c=30"},
+	}
+	if got := call.QueryParams(); !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryParams() = %v, want %v", got, want)
+	}
+
+	// without disabled params nothing is stored on the call
+	call.SetQueryParams([]Param{{Key: "a", Value: "1"}})
+	if call.Params != nil || call.Url != "https://example.com?a=1" {
+		t.Errorf("Params = %v, Url = %q", call.Params, call.Url)
+	}
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PathParam is a parameter in the path of a URL, written as {name} or
+// :name
+type PathParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// {name} but not {{name}}, which is a variable
+var pathParamRegex = regexp.MustCompile(`\{\{[^{}]*\}\}|\{([^{}/]+)\}`)
+
+// pathBounds returns where the path of the URL starts and ends, the host,
+// a leading {{BASE_URL}}, the query and the fragment are not part of it
+func pathBounds(rawUrl string) (int, int) {
+	end := len(rawUrl)
+	if i := strings.IndexAny(rawUrl, "?#"); i >= 0 {
+		end = i
+	}
+	start := 0
+	if strings.HasPrefix(rawUrl, "{{") {
+		if i := strings.Index(rawUrl[:end], "}}"); i >= 0 {
+			start = i + 2
+		}
+	} else if i := strings.Index(rawUrl[:end], "://"); i >= 0 {
+		start = end
+		if slash := strings.Index(rawUrl[i+3:end], "/"); slash >= 0 {
+			start = i + 3 + slash
+		}
+	}
+	return start, end
+}
+
+// replacePathParams calls replace for every path parameter of the URL and
+// puts its result in place of the parameter
+func replacePathParams(rawUrl string, replace func(name, param string) string) string {
+	start, end := pathBounds(rawUrl)
+	segments := strings.Split(rawUrl[start:end], "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+			segments[i] = replace(segment[1:], segment)
+			continue
+		}
+		segments[i] = pathParamRegex.ReplaceAllStringFunc(segment, func(match string) string {
+			if strings.HasPrefix(match, "{{") {
+				return match
+			}
+			return replace(match[1:len(match)-1], match)
+		})
+	}
+	return rawUrl[:start] + strings.Join(segments, "/") + rawUrl[end:]
+}
+
+// PathParamNames returns the names of the path parameters of the URL in
+// their order
+func PathParamNames(rawUrl string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	replacePathParams(rawUrl, func(name, param string) string {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return param
+	})
+	return names
+}
+
+// SubstitutePathParams puts the values of the params in the URL, params
+// without a value are left as they are
+func SubstitutePathParams(rawUrl string, params []PathParam) string {
+	values := map[string]string{}
+	for _, param := range params {
+		if value := param.Get(); value != "" {
+			values[param.Name] = value
+		}
+	}
+	return replacePathParams(rawUrl, func(name, param string) string {
+		if value, ok := values[name]; ok {
+			return escapePath(value)
+		}
+		return param
+	})
+}
+
+// escapePath percent-encodes a path segment, variables are left as they
+// are to be substituted later
+func escapePath(s string) string {
+	escaped := strings.Builder{}
+	last := 0
+	for _, match := range templateRegex.FindAllStringIndex(s, -1) {
+		escaped.WriteString(url.PathEscape(s[last:match[0]]))
+		escaped.WriteString(s[match[0]:match[1]])
+		last = match[1]
+	}
+	escaped.WriteString(url.PathEscape(s[last:]))
+	return escaped.String()
+}
+
+// Get returns the value of the param, or its default
+func (p PathParam) Get() string {
+	if p.Value != "" {
+		return p.Value
+	}
+	return p.Default
+}
+
+// PathParamsList returns the path parameters of the URL with the values
+// stored on the call
+func (i Call) PathParamsList() []PathParam {
+	stored := map[string]PathParam{}
+	for _, param := range i.PathParams {
+		stored[param.Name] = param
+	}
+	params := []PathParam{}
+	for _, name := range PathParamNames(i.Url) {
+		param, ok := stored[name]
+		if !ok {
+			param = PathParam{Name: name}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// MissingPathParams returns an error naming the path parameters without a
+// value, the request cannot be sent without them
+func (i Call) MissingPathParams() error {
+	missing := []string{}
+	for _, param := range i.PathParamsList() {
+		if param.Get() == "" {
+			missing = append(missing, param.Name)
+		}
+	}
+	if len(missing) == 1 {
+		return fmt.Errorf("path parameter %s has no value", missing[0])
+	}
+	if len(missing) > 1 {
+		return fmt.Errorf("path parameters %s have no value", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// SetPathParamValue stores the value of a path parameter, values of
+// parameters no longer in the URL are kept in case they come back
+func (i *Call) SetPathParamValue(name, value string) {
+	for j := range i.PathParams {
+		if i.PathParams[j].Name == name {
+			i.PathParams[j].Value = value
+			return
+		}
+	}
+	i.PathParams = append(i.PathParams, PathParam{Name: name, Value: value})
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPathParamNames(t *testing.T) {
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"{{BASE_URL}}/pets/{petId}", []string{"petId"}},
+		{"/users/:id/posts/:postId?sort=:asc", []string{"id", "postId"}},
+		{"http://localhost:8080/users/{{id}}/{id}#{frag}", []string{"id"}},
+		{"https://example.com/files/{name}.{ext}/{name}", []string{"name", "ext"}},
+		{"https://example.com", []string{}},
+	}
+	for _, tt := range tests {
+		if got := PathParamNames(tt.url); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PathParamNames(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSubstitutePathParams(t *testing.T) {
+	params := []PathParam{
+		{Name: "id", Value: "a b/c"},
+		{Name: "kind", Default: "cats"},
+		{Name: "owner", Value: "{{user}}"},
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://localhost:8080/users/:id", "http://localhost:8080/users/a%20b%2Fc"},
+		{"{{BASE_URL}}/{kind}/{owner}?id={id}", "{{BASE_URL}}/cats/{{user}}?id={id}"},
+		{"/pets/{petId}", "/pets/{petId}"},
+	}
+	for _, tt := range tests {
+		if got := SubstitutePathParams(tt.url, params); got != tt.want {
+			t.Errorf("SubstitutePathParams(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestMissingPathParams(t *testing.T) {
+	call := Call{Url: "/users/:id/posts/{postId}"}
+	if err := call.MissingPathParams(); err == nil || err.Error() != "path parameters id, postId have no value" {
+		t.Errorf("MissingPathParams() = %v", err)
+	}
+
+	call.SetPathParamValue("id", "1")
+	call.SetPathParamValue("postId", "2")
+	if err := call.MissingPathParams(); err != nil {
+		t.Errorf("MissingPathParams() = %v, want nil", err)
+	}
+	if got := call.GetUrl(); got != "/users/1/posts/2" {
+		t.Errorf("GetUrl() = %q", got)
+	}
+
+	// values are kept when the param leaves the URL
+	call.Url = "/users/:id"
+	if len(call.PathParamsList()) != 1 || len(call.PathParams) != 2 {
+		t.Errorf("PathParamsList() = %v, PathParams = %v", call.PathParamsList(), call.PathParams)
+	}
+}
+
+func TestImportOpenAPIPathParams(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "pets.json")
+	os.WriteFile(spec, []byte(`{
+  "openapi": "3.0.0",
+  "info": {"title": "pets", "version": "1"},
+  "paths": {
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true, "description": "id of the pet", "schema": {"type": "integer", "default": 1}}],
+      "get": {"operationId": "getPet", "responses": {"200": {"description": "a pet"}}}
+    }
+  }
+}`), 0644)
+
+	collection, err := ImportOpenAPISpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PathParam{{Name: "petId", Default: "1", Description: "id of the pet"}}
+	if got := collection.Calls[0].PathParams; !reflect.DeepEqual(got, want) {
+		t.Errorf("PathParams = %+v, want %+v", got, want)
+	}
+}
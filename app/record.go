@@ -0,0 +1,502 @@
+package app
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordOptions select the traffic captured by the recording proxy
+type RecordOptions struct {
+	// upstream of a reverse proxy, without it requests are forwarded to
+	// where the client sent them
+	Target string
+	// only record hosts and paths matching one of the patterns, like
+	// *.example.com or /api/*, paths also match by prefix
+	Hosts []string
+	Paths []string
+	// intercepts HTTPS when set, HTTPS is tunneled without recording
+	// otherwise
+	CA *tls.Certificate
+}
+
+// RecordedExchange is a request which went through the recording proxy
+type RecordedExchange struct {
+	Time     time.Time
+	Method   string
+	URL      string
+	Headers  http.Header
+	Body     string
+	Response Response
+	Duration time.Duration
+	Err      error
+	// false when the exchange did not match the filters
+	Recorded bool
+}
+
+// RecordingProxy is a forward or reverse HTTP proxy capturing the exchanges
+// into a collection
+type RecordingProxy struct {
+	opts      RecordOptions
+	target    *neturl.URL
+	transport *http.Transport
+	log       func(RecordedExchange)
+
+	mu        sync.Mutex
+	exchanges []RecordedExchange
+	certs     map[string]*tls.Certificate
+}
+
+// headers of a single connection which are not forwarded
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// NewRecordingProxy returns a proxy which tells log about every exchange
+func NewRecordingProxy(opts RecordOptions, log func(RecordedExchange)) (*RecordingProxy, error) {
+	proxy := &RecordingProxy{
+		opts: opts,
+		log:  log,
+		// the proxy of the environment would be this proxy
+		transport: &http.Transport{Proxy: nil, ForceAttemptHTTP2: true, MaxIdleConnsPerHost: 10},
+		certs:     map[string]*tls.Certificate{},
+	}
+	if opts.Target != "" {
+		target, err := neturl.Parse(opts.Target)
+		if err != nil || target.Host == "" {
+			return nil, fmt.Errorf("invalid target %q", opts.Target)
+		}
+		proxy.target = target
+	}
+	return proxy, nil
+}
+
+// Exchanges returns the recorded exchanges in the order they finished
+func (p *RecordingProxy) Exchanges() []RecordedExchange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]RecordedExchange{}, p.exchanges...)
+}
+
+func (p *RecordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.connect(w, r)
+		return
+	}
+
+	var upstream *neturl.URL
+	switch {
+	case r.URL.IsAbs():
+		upstream = r.URL
+	case r.TLS != nil:
+		// a request of an intercepted HTTPS connection
+		upstream = &neturl.URL{Scheme: "https", Host: r.Host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+	case p.target != nil:
+		target := *p.target
+		target.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+		target.RawPath = ""
+		target.RawQuery = r.URL.RawQuery
+		upstream = &target
+	default:
+		http.Error(w, "restman record: not a proxy request, configure the client to use this proxy or start it with a target", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r, upstream)
+}
+
+// forward sends the request upstream and the response back, both are read
+// whole to be recorded
+func (p *RecordingProxy) forward(w http.ResponseWriter, r *http.Request, upstream *neturl.URL) {
+	exchange := RecordedExchange{Time: time.Now(), Method: r.Method, URL: upstream.String(), Headers: r.Header.Clone()}
+	defer func() {
+		exchange.Duration = time.Since(exchange.Time)
+		exchange.Recorded = exchange.Err == nil && p.matches(upstream)
+		if exchange.Recorded {
+			p.mu.Lock()
+			p.exchanges = append(p.exchanges, exchange)
+			p.mu.Unlock()
+		}
+		if p.log != nil {
+			p.log(exchange)
+		}
+	}()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		exchange.Err = err
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	exchange.Body = string(body)
+
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, upstream.String(), bytes.NewReader(body))
+	if err != nil {
+		exchange.Err = err
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out.Header = r.Header.Clone()
+	for _, name := range hopHeaders {
+		out.Header.Del(name)
+	}
+	// the transport asks for gzip itself and decompresses the response, so
+	// that examples are readable
+	out.Header.Del("Accept-Encoding")
+
+	response, err := p.transport.RoundTrip(out)
+	if err != nil {
+		exchange.Err = err
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		exchange.Err = err
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	for _, name := range hopHeaders {
+		response.Header.Del(name)
+	}
+	response.Header.Del("Content-Length")
+	exchange.Response = Response{Status: response.StatusCode, Headers: response.Header.Clone(), Body: string(responseBody), ReceivedAt: time.Now()}
+
+	for name, values := range response.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(response.StatusCode)
+	w.Write(responseBody)
+}
+
+// connect handles the HTTPS tunnel of a forward proxy, the TLS connection
+// is intercepted with a certificate of the CA when the host is recorded
+func (p *RecordingProxy) connect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking is not supported", http.StatusInternalServerError)
+		return
+	}
+	host := r.Host
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+		host = net.JoinHostPort(host, "443")
+	}
+
+	intercept := p.opts.CA != nil && p.matchesHost(hostname)
+	var upstream net.Conn
+	if !intercept {
+		if upstream, err = net.DialTimeout("tcp", host, 10*time.Second); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		if upstream != nil {
+			upstream.Close()
+		}
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		conn.Close()
+		return
+	}
+
+	if !intercept {
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+		return
+	}
+
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return p.certificate(hello.ServerName)
+			}
+			return p.certificate(hostname)
+		},
+	})
+	server := &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	server.Serve(&singleConnListener{conn: tlsConn})
+}
+
+// certificate returns a certificate for the host signed by the CA
+func (p *RecordingProxy) certificate(host string) (*tls.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cert, ok := p.certs[host]; ok {
+		return cert, nil
+	}
+	cert, err := signCertificate(p.opts.CA, host)
+	if err != nil {
+		return nil, err
+	}
+	p.certs[host] = cert
+	return cert, nil
+}
+
+func (p *RecordingProxy) matchesHost(hostname string) bool {
+	if len(p.opts.Hosts) == 0 {
+		return true
+	}
+	for _, pattern := range p.opts.Hosts {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(hostname)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the exchange with the URL is recorded
+func (p *RecordingProxy) matches(u *neturl.URL) bool {
+	if !p.matchesHost(u.Hostname()) {
+		return false
+	}
+	if len(p.opts.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range p.opts.Paths {
+		if matched, _ := path.Match(pattern, u.Path); matched || strings.HasPrefix(u.Path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// singleConnListener hands out one connection to an http.Server, Serve
+// returns after it while the connection is still being served
+type singleConnListener struct {
+	conn net.Conn
+	once sync.Once
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn == nil {
+		return nil, io.EOF
+	}
+	return conn, nil
+}
+
+func (l *singleConnListener) Close() error   { return nil }
+func (l *singleConnListener) Addr() net.Addr { return l.conn.LocalAddr() }
+
+// headers of recorded requests which are not saved on the calls
+var skippedRecordedHeaders = map[string]bool{
+	"Host":            true,
+	"Content-Length":  true,
+	"Accept-Encoding": true,
+}
+
+// credentials of recorded requests are saved as variables named after the
+// header, so that no live credentials end up in the collection
+var recordedCredentials = map[string]string{
+	"Authorization": "authorization",
+	"Cookie":        "cookie",
+}
+
+// recordedCredential returns the variable saved in place of a credential,
+// the scheme of an Authorization like Bearer is kept
+func recordedCredential(name string, value string) string {
+	variable := "{{" + recordedCredentials[name] + "}}"
+	if scheme, _, found := strings.Cut(value, " "); found && name == "Authorization" {
+		return scheme + " " + variable
+	}
+	return variable
+}
+
+// Collection turns the recorded exchanges into a collection, one call per
+// method and URL path in a folder per host. The responses are saved as
+// examples of the calls, without the cookies they set.
+func (p *RecordingProxy) Collection(name string) Collection {
+	collection := NewCollection()
+	collection.Name = name
+	calls := map[string]int{}
+
+	for _, exchange := range p.Exchanges() {
+		u, err := neturl.Parse(exchange.URL)
+		if err != nil {
+			continue
+		}
+		key := exchange.Method + " " + u.Host + u.Path
+		i, ok := calls[key]
+		if !ok {
+			call := NewCall()
+			call.Name = u.Path
+			if call.Name == "" {
+				call.Name = "/"
+			}
+			call.Method = exchange.Method
+			call.Url = exchange.URL
+			call.Folder = u.Host
+			call.Headers = recordedHeaders(exchange.Headers)
+			if exchange.Body != "" {
+				call.Data = exchange.Body
+				call.DataType = "Text"
+				if strings.Contains(exchange.Headers.Get("Content-Type"), "json") {
+					call.DataType = "JSON"
+				}
+			}
+			collection.Calls = append(collection.Calls, *call)
+			i = len(collection.Calls) - 1
+			calls[key] = i
+		}
+
+		example := exchange.Response
+		example.Headers = example.Headers.Clone()
+		example.Headers.Del("Set-Cookie")
+		example.Name = fmt.Sprintf("%d · %s", example.Status, exchange.Time.Format("15:04:05"))
+		collection.Calls[i].Examples = append(collection.Calls[i].Examples, example)
+	}
+	return collection
+}
+
+func recordedHeaders(headers http.Header) Headers {
+	recorded := Headers{}
+	for name, values := range headers {
+		if skippedRecordedHeaders[name] {
+			continue
+		}
+		skip := false
+		for _, hop := range hopHeaders {
+			skip = skip || name == hop
+		}
+		if skip {
+			continue
+		}
+		for _, value := range values {
+			if recordedCredentials[name] != "" {
+				value = recordedCredential(name, value)
+			}
+			recorded = append(recorded, Header{Key: name, Value: value})
+		}
+	}
+	sort.SliceStable(recorded, func(i, j int) bool {
+		return recorded[i].Key < recorded[j].Key
+	})
+	return recorded
+}
+
+// LoadOrCreateCA returns the CA of the recording proxy stored in dir,
+// generating it on the first use. The path of the certificate is returned
+// to be trusted by clients.
+func LoadOrCreateCA(dir string) (*tls.Certificate, string, error) {
+	certPath := filepath.Join(dir, "restman-ca.pem")
+	keyPath := filepath.Join(dir, "restman-ca-key.pem")
+
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		return &cert, certPath, err
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Restman Recording CA", Organization: []string{"Restman"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, "", err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, "", err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return nil, "", err
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return nil, "", err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, "", err
+	}
+	cert.Leaf, err = x509.ParseCertificate(der)
+	return &cert, certPath, err
+}
+
+// signCertificate issues a short lived certificate for the host
+func signCertificate(ca *tls.Certificate, host string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(0, 0, 30),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Leaf, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der, ca.Certificate[0]}, PrivateKey: key}, nil
+}
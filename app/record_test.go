@@ -0,0 +1,137 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"testing"
+)
+
+func testUpstream() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Connection", "close")
+		w.Header().Set("Set-Cookie", "session=s3cret")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		io.WriteString(w, `{"path": "`+r.URL.Path+`", "body": "`+string(body)+`"}`)
+	}))
+}
+
+func TestRecordingProxyReverse(t *testing.T) {
+	upstream := testUpstream()
+	defer upstream.Close()
+
+	logged := 0
+	proxy, err := NewRecordingProxy(RecordOptions{Target: upstream.URL, Paths: []string{"/api"}}, func(RecordedExchange) { logged++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	for _, request := range []struct{ method, path, body string }{
+		{"GET", "/api/users?page=1", ""},
+		{"GET", "/api/users?page=2", ""},
+		{"POST", "/api/users", "alice"},
+		{"GET", "/health", ""},
+	} {
+		req, _ := http.NewRequest(request.method, server.URL+request.path, strings.NewReader(request.body))
+		req.Header.Set("Authorization", "Bearer t0ken")
+		req.Header.Set("Cookie", "session=s3cret")
+		response, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		if !strings.Contains(string(body), request.path[:strings.IndexAny(request.path+"?", "?")]) {
+			t.Errorf("%s %s = %s", request.method, request.path, body)
+		}
+		if response.Header.Get("Connection") != "" {
+			t.Errorf("hop-by-hop headers should not be forwarded")
+		}
+	}
+
+	if logged != 4 || len(proxy.Exchanges()) != 3 {
+		t.Fatalf("logged %d exchanges and recorded %d, want 4 and 3", logged, len(proxy.Exchanges()))
+	}
+
+	collection := proxy.Collection("recorded")
+	if len(collection.Calls) != 2 {
+		t.Fatalf("Collection() = %d calls, want 2", len(collection.Calls))
+	}
+	list := collection.Calls[0]
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	if list.Method != "GET" || list.Url != upstream.URL+"/api/users?page=1" || list.Folder != host || len(list.Examples) != 2 {
+		t.Errorf("first call = %+v", list)
+	}
+	// the token is replaced by a variable
+	if list.Headers[0].String() != "Authorization: Bearer {{authorization}}" || list.Examples[0].Status != 200 {
+		t.Errorf("headers = %v, status = %d", list.Headers, list.Examples[0].Status)
+	}
+	for _, call := range collection.Calls {
+		if data, _ := json.Marshal(call); strings.Contains(string(data), "t0ken") || strings.Contains(string(data), "s3cret") {
+			t.Errorf("Expected the credentials to be left out of %s", data)
+		}
+	}
+	create := collection.Calls[1]
+	if create.Data != "alice" || create.Examples[0].Status != http.StatusCreated || !strings.Contains(create.Examples[0].Body, `"body": "alice"`) {
+		t.Errorf("second call = %+v", create)
+	}
+}
+
+func TestRecordingProxyForward(t *testing.T) {
+	isolateConfig(t)
+
+	upstream := testUpstream()
+	defer upstream.Close()
+	secure := httptest.NewTLSServer(upstream.Config.Handler)
+	defer secure.Close()
+
+	ca, certPath, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() error = %v", err)
+	}
+	if !strings.HasSuffix(certPath, "restman-ca.pem") || !ca.Leaf.IsCA {
+		t.Errorf("LoadOrCreateCA() = %s", certPath)
+	}
+
+	proxy, _ := NewRecordingProxy(RecordOptions{CA: ca, Hosts: []string{"127.0.0.*"}}, nil)
+	// the upstream of the test has a self-signed certificate
+	proxy.transport.TLSClientConfig = secure.Client().Transport.(*http.Transport).TLSClientConfig
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	proxyURL, _ := neturl.Parse(server.URL)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+
+	for _, url := range []string{upstream.URL + "/plain", secure.URL + "/secure"} {
+		response, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s through the proxy: %v", url, err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		if !strings.Contains(string(body), "/plain") && !strings.Contains(string(body), "/secure") {
+			t.Errorf("GET %s = %s", url, body)
+		}
+	}
+
+	exchanges := proxy.Exchanges()
+	if len(exchanges) != 2 || exchanges[1].URL != secure.URL+"/secure" {
+		t.Fatalf("exchanges = %+v", exchanges)
+	}
+}
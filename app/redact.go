@@ -0,0 +1,101 @@
+package app
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const REDACTED = "[REDACTED]"
+
+// parts of header, query parameter and variable names holding secrets
+var sensitiveNames = []string{"authorization", "cookie", "token", "secret", "password", "passwd", "api-key", "apikey", "api_key", "session", "credential", "signature"}
+
+// IsSensitive reports whether a header, parameter or variable name looks like
+// it holds a secret
+func IsSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// minimum length of a secret value replaced anywhere in a text, shorter
+// values would redact unrelated text
+const minSecretLength = 4
+
+// Redactor hides secrets in reports: sensitive headers and query parameters,
+// credentials of the call and values of sensitive variables
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor collects the secrets known for the call
+func (a *App) NewRedactor(call *Call) Redactor {
+	secrets := []string{}
+	if auth := call.GetAuth(); auth != nil {
+		secrets = append(secrets, auth.Password, auth.Token, auth.HeaderValue)
+	}
+	for name, value := range a.Variables(call) {
+		if IsSensitive(name) {
+			secrets = append(secrets, value)
+		}
+	}
+	secrets = append(secrets, secretValues()...)
+
+	r := Redactor{}
+	for _, s := range secrets {
+		if len(s) >= minSecretLength {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// longest first, so a secret containing another one is replaced whole
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// String replaces the known secret values in s
+func (r Redactor) String(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, REDACTED)
+	}
+	return s
+}
+
+// Header returns the value of a header, redacted when the name is sensitive
+func (r Redactor) Header(name string, value string) string {
+	if IsSensitive(name) {
+		return REDACTED
+	}
+	return r.String(value)
+}
+
+// Headers returns a copy of the headers with sensitive values redacted
+func (r Redactor) Headers(headers map[string][]string) map[string][]string {
+	redacted := map[string][]string{}
+	for name, values := range headers {
+		for _, value := range values {
+			redacted[name] = append(redacted[name], r.Header(name, value))
+		}
+	}
+	return redacted
+}
+
+var queryValueReg = regexp.MustCompile(`([?&])([^=&#]+)=([^&#]*)`)
+
+// URL redacts sensitive query parameters and known secrets of a URL
+func (r Redactor) URL(u string) string {
+	u = queryValueReg.ReplaceAllStringFunc(u, func(match string) string {
+		parts := queryValueReg.FindStringSubmatch(match)
+		name, _ := url.QueryUnescape(parts[2])
+		if IsSensitive(name) {
+			return parts[1] + parts[2] + "=" + REDACTED
+		}
+		return match
+	})
+	return r.String(u)
+}
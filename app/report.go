@@ -0,0 +1,413 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	REPORT_JUNIT = "junit"
+	REPORT_TAP   = "tap"
+	REPORT_JSON  = "json"
+)
+
+// Report is the outcome of a collection run, written as JUnit XML, TAP or
+// JSON. Requests and responses of failed calls are included with secrets
+// redacted.
+type Report struct {
+	Collection string
+	Started    time.Time
+	Duration   time.Duration
+	Results    []RunResult
+	Err        error
+}
+
+// Passed counts the calls which passed
+func (r Report) Passed() int {
+	passed := 0
+	for _, result := range r.Results {
+		if result.Passed() {
+			passed++
+		}
+	}
+	return passed
+}
+
+// Failed is true when a call failed or the run could not finish
+func (r Report) Failed() bool {
+	return r.Err != nil || r.Passed() < len(r.Results)
+}
+
+// Write writes the report in the given format
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case REPORT_JUNIT:
+		return r.WriteJUnit(w)
+	case REPORT_TAP:
+		return r.WriteTAP(w)
+	case REPORT_JSON:
+		return r.WriteJSON(w)
+	}
+	return fmt.Errorf("unknown report format %q, use junit, tap or json", format)
+}
+
+// failureReason describes why a call failed, one line per failed assertion
+func failureReason(result RunResult) string {
+	if result.Err != nil {
+		return result.Err.Error()
+	}
+	lines := []string{}
+	for _, a := range result.Assertions {
+		if !a.Passed {
+			lines = append(lines, a.Assertion.String()+": "+a.Message)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type reportRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type reportResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    string              `json:"body,omitempty"`
+}
+
+// exchange returns the redacted request and response of a failed call
+func (a *App) exchange(result RunResult) (*reportRequest, *reportResponse) {
+	redactor := a.NewRedactor(&result.Call)
+
+	var request *reportRequest
+	if result.Request != nil {
+		request = &reportRequest{
+			Method:  result.Request.Method,
+			Url:     redactor.URL(result.Request.Url),
+			Headers: map[string]string{},
+			Body:    redactor.String(result.Request.Body),
+		}
+		for name, value := range result.Request.Headers {
+			request.Headers[name] = redactor.Header(name, value)
+		}
+	}
+
+	var response *reportResponse
+	if result.Response != nil {
+		response = &reportResponse{
+			Status:  result.Response.Status,
+			Headers: redactor.Headers(result.Response.Headers),
+			Body:    redactor.String(result.Response.Content()),
+		}
+	}
+	return request, response
+}
+
+// exchangeText renders the request and response of a failed call as plain
+// text, like they would appear on the wire
+func (a *App) exchangeText(result RunResult) string {
+	request, response := a.exchange(result)
+	lines := []string{}
+	if request != nil {
+		lines = append(lines, request.Method+" "+request.Url)
+		for _, name := range sortedKeys(request.Headers) {
+			lines = append(lines, name+": "+request.Headers[name])
+		}
+		if request.Body != "" {
+			lines = append(lines, "", request.Body)
+		}
+	}
+	if response != nil {
+		lines = append(lines, "", fmt.Sprintf("HTTP %d", response.Status))
+		for _, name := range sortedKeys(response.Headers) {
+			for _, value := range response.Headers[name] {
+				lines = append(lines, name+": "+value)
+			}
+		}
+		if response.Body != "" {
+			lines = append(lines, "", response.Body)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactData hides the fields of a data file row named like secrets
+func redactData(row map[string]string) map[string]string {
+	if row == nil {
+		return nil
+	}
+	redacted := map[string]string{}
+	for name, value := range row {
+		if IsSensitive(name) {
+			value = REDACTED
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// WriteJUnit writes the report as JUnit XML, one test suite per iteration.
+// Failed assertions are failures, calls which could not be sent are errors.
+func (r Report) WriteJUnit(w io.Writer) error {
+	a := GetInstance()
+	suites := junitTestSuites{Name: r.Collection, Time: seconds(r.Duration)}
+
+	for _, result := range r.Results {
+		if len(suites.Suites) < result.Iteration {
+			name := r.Collection
+			if result.Iteration > 1 {
+				name = fmt.Sprintf("%s (iteration %d)", r.Collection, result.Iteration)
+			}
+			suite := junitTestSuite{Name: name, Timestamp: r.Started.Format(time.RFC3339)}
+			// the data file row of the iteration
+			data := redactData(result.Data)
+			for _, field := range sortedKeys(data) {
+				suite.Properties = append(suite.Properties, junitProperty{Name: field, Value: data[field]})
+			}
+			suites.Suites = append(suites.Suites, suite)
+		}
+		suite := &suites.Suites[len(suites.Suites)-1]
+
+		className := r.Collection
+		if result.Call.Folder != "" {
+			className += "." + strings.ReplaceAll(result.Call.Folder, "/", ".")
+		}
+		testCase := junitTestCase{Name: result.Call.Title(), ClassName: className, Time: seconds(result.Duration)}
+		if !result.Passed() {
+			failure := &junitFailure{Message: strings.SplitN(failureReason(result), "\n", 2)[0], Text: failureReason(result)}
+			if result.Err != nil {
+				failure.Type = "error"
+				testCase.Error = failure
+				suite.Errors++
+				suites.Errors++
+			} else {
+				failure.Type = "assertion"
+				testCase.Failure = failure
+				suite.Failures++
+				suites.Failures++
+			}
+			testCase.SystemOut = &junitOutput{Text: a.exchangeText(result)}
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+		suites.Tests++
+	}
+	for i := range suites.Suites {
+		var total time.Duration
+		for _, result := range r.Results {
+			if result.Iteration == i+1 {
+				total += result.Duration
+			}
+		}
+		suites.Suites[i].Time = seconds(total)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// tapYAML indents a multi line value as a YAML block scalar
+func tapYAML(key string, value string) string {
+	lines := []string{"  " + key + ": |"}
+	for _, line := range strings.Split(value, "\n") {
+		lines = append(lines, "    "+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteTAP writes the report in the Test Anything Protocol, version 13, with
+// a YAML block describing every failure
+func (r Report) WriteTAP(w io.Writer) error {
+	a := GetInstance()
+	lines := []string{"TAP version 13", fmt.Sprintf("1..%d", len(r.Results))}
+
+	for i, result := range r.Results {
+		status := "ok"
+		if !result.Passed() {
+			status = "not ok"
+		}
+		name := result.Call.Title()
+		if result.Iteration > 1 {
+			name = fmt.Sprintf("%s (iteration %d)", name, result.Iteration)
+		}
+		lines = append(lines, fmt.Sprintf("%s %d - %s", status, i+1, name))
+
+		if !result.Passed() {
+			lines = append(lines,
+				"  ---",
+				tapYAML("message", failureReason(result)),
+				fmt.Sprintf("  status: %d", result.Status),
+				fmt.Sprintf("  duration_ms: %d", result.Duration.Milliseconds()),
+			)
+			if exchange := a.exchangeText(result); exchange != "" {
+				lines = append(lines, tapYAML("exchange", exchange))
+			}
+			lines = append(lines, "  ...")
+		}
+	}
+	if r.Err != nil {
+		lines = append(lines, "Bail out! "+r.Err.Error())
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+type jsonAssertion struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"`
+}
+
+type jsonResult struct {
+	Iteration  int               `json:"iteration"`
+	Data       map[string]string `json:"data,omitempty"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Folder     string            `json:"folder,omitempty"`
+	Method     string            `json:"method"`
+	Status     int               `json:"status"`
+	DurationMs int64             `json:"duration_ms"`
+	Size       int64             `json:"size"`
+	Passed     bool              `json:"passed"`
+	Error      string            `json:"error,omitempty"`
+	Assertions []jsonAssertion   `json:"assertions,omitempty"`
+	Request    *reportRequest    `json:"request,omitempty"`
+	Response   *reportResponse   `json:"response,omitempty"`
+}
+
+type jsonReport struct {
+	Collection string       `json:"collection"`
+	Started    time.Time    `json:"started"`
+	DurationMs int64        `json:"duration_ms"`
+	Total      int          `json:"total"`
+	Passed     int          `json:"passed"`
+	Failed     int          `json:"failed"`
+	Error      string       `json:"error,omitempty"`
+	Results    []jsonResult `json:"results"`
+}
+
+// WriteJSON writes the report as a JSON document
+func (r Report) WriteJSON(w io.Writer) error {
+	a := GetInstance()
+	report := jsonReport{
+		Collection: r.Collection,
+		Started:    r.Started,
+		DurationMs: r.Duration.Milliseconds(),
+		Total:      len(r.Results),
+		Passed:     r.Passed(),
+		Failed:     len(r.Results) - r.Passed(),
+		Results:    []jsonResult{},
+	}
+	if r.Err != nil {
+		report.Error = r.Err.Error()
+	}
+
+	for _, result := range r.Results {
+		item := jsonResult{
+			Iteration:  result.Iteration,
+			Data:       redactData(result.Data),
+			ID:         result.Call.ID,
+			Name:       result.Call.Title(),
+			Folder:     result.Call.Folder,
+			Method:     result.Call.Method,
+			Status:     result.Status,
+			DurationMs: result.Duration.Milliseconds(),
+			Size:       result.Size,
+			Passed:     result.Passed(),
+		}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+		}
+		for _, assertion := range result.Assertions {
+			item.Assertions = append(item.Assertions, jsonAssertion{
+				Assertion: assertion.Assertion.String(),
+				Passed:    assertion.Passed,
+				Message:   assertion.Message,
+			})
+		}
+		if !result.Passed() {
+			item.Request, item.Response = a.exchange(result)
+		}
+		report.Results = append(report.Results, item)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactor(t *testing.T) {
+	isolateConfig(t)
+
+	a := GetInstance()
+	call := Call{ID: "r", Auth: &Auth{Type: "bearer_token", Token: "bearer-secret"}}
+	a.Collections = []Collection{{ID: "c", Calls: []Call{call}, Variables: map[string]string{"api_token": "tok-123", "user": "alice"}}}
+	t.Cleanup(func() { a.Collections = nil })
+
+	r := a.NewRedactor(&call)
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{r.String(`{"token": "tok-123", "user": "alice"}`), `{"token": "[REDACTED]", "user": "alice"}`},
+		{r.String("Bearer bearer-secret"), "Bearer [REDACTED]"},
+		{r.Header("Authorization", "Bearer anything"), REDACTED},
+		{r.Header("Set-Cookie", "SESSION=1"), REDACTED},
+		{r.Header("Content-Type", "application/json"), "application/json"},
+		{r.URL("http://x/a?page=2&api_key=abc&access_token=def#top"), "http://x/a?page=2&api_key=[REDACTED]&access_token=[REDACTED]#top"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("got %q, want %q", test.got, test.want)
+		}
+	}
+}
+
+func testReport() Report {
+	response := &Response{Status: 500, Headers: http.Header{"Set-Cookie": {"SESSION=abc"}}, Body: "boom"}
+	return Report{
+		Collection: "api",
+		Started:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:   30 * time.Millisecond,
+		Results: []RunResult{
+			{Iteration: 1, Call: Call{ID: "1", Name: "list", Method: "GET"}, Status: 200, Duration: 10 * time.Millisecond,
+				Assertions: []AssertionResult{{Assertion: Assertion{Subject: "status", Operator: "==", Value: "200"}, Passed: true}}},
+			{Iteration: 1, Call: Call{ID: "2", Name: "create", Method: "POST", Folder: "users/admin"}, Status: 500, Duration: 20 * time.Millisecond,
+				Assertions: []AssertionResult{{Assertion: Assertion{Subject: "status", Operator: "==", Value: "201"}, Message: "got status 500"}},
+				Request:    &SentRequest{Method: "POST", Url: "http://x/users?token=t", Headers: map[string]string{"Authorization": "Bearer t"}, Body: "{}"},
+				Response:   response},
+			{Iteration: 2, Data: map[string]string{"user": "bob", "password": "hunter2"}, Call: Call{ID: "1", Name: "list", Method: "GET"}, Err: errors.New("connection refused")},
+		},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := testReport().WriteJUnit(&out); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, out.String())
+	}
+	if suites.Tests != 3 || suites.Failures != 1 || suites.Errors != 1 || len(suites.Suites) != 2 {
+		t.Errorf("testsuites = %d tests, %d failures, %d errors, %d suites", suites.Tests, suites.Failures, suites.Errors, len(suites.Suites))
+	}
+	failed := suites.Suites[0].TestCases[1]
+	if failed.ClassName != "api.users.admin" || failed.Failure == nil || failed.Failure.Message != "status == 201: got status 500" {
+		t.Errorf("failed test case = %+v", failed)
+	}
+	if failed.SystemOut == nil || !strings.Contains(failed.SystemOut.Text, "Authorization: [REDACTED]") || strings.Contains(failed.SystemOut.Text, "SESSION=abc") {
+		t.Errorf("system-out is not redacted: %+v", failed.SystemOut)
+	}
+	if suites.Suites[1].TestCases[0].Error == nil {
+		t.Errorf("a call which could not be sent should be an error")
+	}
+	if props := suites.Suites[1].Properties; len(props) != 2 || props[0] != (junitProperty{"password", REDACTED}) || props[1] != (junitProperty{"user", "bob"}) {
+		t.Errorf("iteration properties = %+v", props)
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	var out bytes.Buffer
+	if err := testReport().WriteTAP(&out); err != nil {
+		t.Fatalf("WriteTAP() error = %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{"TAP version 13\n1..3\n", "ok 1 - list\n", "not ok 2 - create\n  ---\n", "not ok 3 - list (iteration 2)\n", "token=[REDACTED]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("TAP output does not contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := testReport().WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if report.Total != 3 || report.Passed != 1 || report.Failed != 2 {
+		t.Errorf("report = %d total, %d passed, %d failed", report.Total, report.Passed, report.Failed)
+	}
+	if report.Results[0].Request != nil {
+		t.Errorf("passed calls should not include the request")
+	}
+	failed := report.Results[1]
+	if failed.Request == nil || failed.Request.Headers["Authorization"] != REDACTED || failed.Response.Headers["Set-Cookie"][0] != REDACTED {
+		t.Errorf("failed result = %+v", failed)
+	}
+	if report.Results[2].Error != "connection refused" || report.Results[2].Data["password"] != REDACTED {
+		t.Errorf("error = %q", report.Results[2].Error)
+	}
+}
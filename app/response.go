@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"restman/utils"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+)
+
+// DefaultMaxBodyInMemory is the size of a response body kept in memory
+// before it is spilled to a temp file.
+const DefaultMaxBodyInMemory = 10 * 1024 * 1024
+
+// how often progress is reported while downloading a body
+const progressInterval = 100 * time.Millisecond
+
+// temp files holding spilled response bodies, removed on exit
+var tempFiles struct {
+	sync.Mutex
+	paths []string
+}
+
+// request is an HTTP request in flight, sending its call again cancels it
+// so a slow response never replaces the one of the newer request
+type request struct {
+	context.Context
+	cancel context.CancelFunc
+	call   string
+}
+
+// the requests in flight by call id
+var activeRequests struct {
+	sync.Mutex
+	requests map[string]*request
+}
+
+// startRequest cancels the request of the call still in flight and returns
+// the new one
+func startRequest(call *Call) *request {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &request{Context: ctx, cancel: cancel, call: call.ID}
+
+	activeRequests.Lock()
+	defer activeRequests.Unlock()
+	if activeRequests.requests == nil {
+		activeRequests.requests = map[string]*request{}
+	}
+	if previous := activeRequests.requests[call.ID]; previous != nil {
+		previous.cancel()
+	}
+	activeRequests.requests[call.ID] = r
+	return r
+}
+
+// release forgets the request once its response was read
+func (r *request) release() {
+	activeRequests.Lock()
+	defer activeRequests.Unlock()
+	if activeRequests.requests[r.call] == r {
+		delete(activeRequests.requests, r.call)
+	}
+	r.cancel()
+}
+
+// MaxBodyInMemory returns the configured in-memory cap for response bodies
+func MaxBodyInMemory() int64 {
+	if limit := viper.GetInt64("max_body_in_memory"); limit > 0 {
+		return limit
+	}
+	return DefaultMaxBodyInMemory
+}
+
+// streamResponse reads the response body in the background and returns a
+// channel delivering OnProgressMsg updates followed by a final OnResponseMsg.
+// The call assertions are evaluated once the body was read, against sent which
+// holds the variables set by the pre-request scripts. started is when the
+// request was sent. Nothing is sent when the request was replaced by a newer
+// one of the call.
+func (a *App) streamResponse(r *request, call *Call, sent *Call, response *http.Response, started time.Time) chan tea.Msg {
+	messages := make(chan tea.Msg)
+
+	go func() {
+		defer r.release()
+		defer response.Body.Close()
+
+		start := time.Now()
+		lastReport := start
+		body := utils.NewSpillBuffer(MaxBodyInMemory())
+		reader := &utils.ProgressReader{
+			Reader: response.Body,
+			OnProgress: func(total int64) {
+				if time.Since(lastReport) < progressInterval {
+					return
+				}
+				lastReport = time.Now()
+				msg := OnProgressMsg{
+					Call:  call,
+					Bytes: total,
+					Total: response.ContentLength,
+					Rate:  float64(total) / time.Since(start).Seconds(),
+					next:  waitForResponse(messages),
+				}
+				// skip the update if nobody is listening right now,
+				// the download should never wait for the UI
+				select {
+				case messages <- msg:
+				default:
+				}
+			},
+		}
+
+		_, err := io.Copy(body, reader)
+		path, closeErr := body.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if path != "" {
+			trackTempFile(path)
+		}
+		if r.Err() != nil {
+			close(messages)
+			return
+		}
+		duration := time.Since(started)
+
+		var results []AssertionResult
+		var changes []VariableChange
+		if err == nil {
+			snapshot := newResponse(response, body.String(), path)
+			results, changes = a.checkResponse(sent, snapshot, response.Cookies(), duration, body.Size())
+		}
+
+		messages <- OnResponseMsg{
+			Call:       call,
+			Body:       body.String(),
+			BodyFile:   path,
+			Bytes:      body.Size(),
+			Err:        err,
+			Response:   response,
+			Duration:   duration,
+			Assertions: results,
+			Variables:  changes,
+		}
+	}()
+
+	return messages
+}
+
+// checkResponse records a response in the history, evaluates the assertions,
+// extracts the variables and runs the post-response scripts. The call comes
+// from forkScope, the variables set on it since are returned to be applied by
+// the caller.
+func (a *App) checkResponse(call *Call, response Response, cookies []*http.Cookie, duration time.Duration, size int64) ([]AssertionResult, []VariableChange) {
+	recordResponse(call, response)
+	results := EvaluateAssertions(call.Assertions, response, duration, size)
+	a.applyExtractions(call, response, cookies)
+	results = append(results, a.runPostResponseScripts(call, response, duration, size)...)
+	return results, call.scope.recorded()
+}
+
+func waitForResponse(messages chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		// a closed channel means the stream was stopped
+		msg, ok := <-messages
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+func trackTempFile(path string) {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	tempFiles.paths = append(tempFiles.paths, path)
+}
+
+// Cleanup removes temp files created for spilled response bodies
+func (a *App) Cleanup() {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	for _, path := range tempFiles.paths {
+		os.Remove(path)
+	}
+	tempFiles.paths = nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"restman/utils"
+	"testing"
+	"time"
+)
+
+func TestStreamResponseReplaced(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first part"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	call := NewCall()
+	call.Url = server.URL
+
+	r := startRequest(call)
+	response, err := utils.MakeRequest(utils.HTTPRequestParams{Method: "GET", URL: server.URL, Context: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := GetInstance().streamResponse(r, call, call, response, time.Now())
+
+	// sending the call again cancels the slow response
+	startRequest(call).release()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if _, progress := msg.(OnProgressMsg); !progress {
+				t.Fatalf("Expected no response for the replaced request, got %#v", msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for the replaced request")
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"restman/utils"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// RunOptions select the calls of a collection run and how they are sent
+type RunOptions struct {
+	Folder        string
+	Tags          []string
+	StopOnFailure bool
+	Delay         time.Duration
+	Iterations    int
+	Environment   string
+	// rows of a data file, one iteration per row unless more iterations
+	// are asked for, then the rows are cycled
+	Data []map[string]string
+	// keep the request and response of passed calls too
+	KeepResponses bool
+}
+
+// IterationCount returns how many times the calls are run
+func (o RunOptions) IterationCount() int {
+	if len(o.Data) > 0 && o.Iterations <= 1 {
+		return len(o.Data)
+	}
+	return max(o.Iterations, 1)
+}
+
+// RunResult is the outcome of one call of a collection run
+type RunResult struct {
+	Iteration  int
+	Data       map[string]string // data file row of the iteration
+	Call       Call
+	Status     int
+	Duration   time.Duration
+	Size       int64
+	Assertions []AssertionResult
+	Err        error
+	// variables set by the extractions, kept for the rest of the run and
+	// applied to the collection by the caller
+	Variables []VariableChange
+	// what was sent and received, kept for failed calls unless the run
+	// keeps all responses
+	Request  *SentRequest
+	Response *Response
+}
+
+// SentRequest is the request as it was sent, after variables were
+// substituted and the pre-request scripts ran
+type SentRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Passed is true when the call was sent and all of its assertions passed
+func (r RunResult) Passed() bool {
+	return r.Err == nil && CountPassed(r.Assertions) == len(r.Assertions)
+}
+
+// InFolder reports whether the call is in the folder or in one of its
+// subfolders, folders are paths like auth/tokens
+func (i Call) InFolder(folder string) bool {
+	folder = strings.Trim(folder, "/")
+	return folder == "" || i.Folder == folder || strings.HasPrefix(i.Folder, folder+"/")
+}
+
+// HasTag reports whether the call is tagged with one of the tags, any call
+// matches when no tags are given
+func (i Call) HasTag(tags ...string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, t := range i.Tags {
+			if strings.EqualFold(t, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RunnerCalls returns the calls of the collection selected by the options,
+// in collection order
+func RunnerCalls(collection Collection, opts RunOptions) []Call {
+	calls := []Call{}
+	for _, call := range collection.Calls {
+		if call.InFolder(opts.Folder) && call.HasTag(opts.Tags...) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// FindCall returns the call of the collection with the given name or ID
+func (c Collection) FindCall(name string) *Call {
+	for i, call := range c.Calls {
+		if call.ID == name || strings.EqualFold(call.Name, name) {
+			return &c.Calls[i]
+		}
+	}
+	return nil
+}
+
+// FindCollection returns the collection with the given name or ID
+func (a *App) FindCollection(name string) *Collection {
+	for i, c := range a.Collections {
+		if c.ID == name || strings.EqualFold(c.Name, name) {
+			return &a.Collections[i]
+		}
+	}
+	return nil
+}
+
+// Execute sends the call and waits for the whole response. The scripts,
+// assertions and extractions of the call are run like for calls sent from
+// the UI.
+func (a *App) Execute(ctx context.Context, call *Call) RunResult {
+	result := RunResult{Call: *call}
+	started := time.Now()
+
+	if err := call.MissingPathParams(); err != nil {
+		result.Err = err
+		return result
+	}
+	if call.IsWebSocket() {
+		result.Err = fmt.Errorf("WebSocket calls cannot be run")
+		return result
+	}
+	if call.IsGRPC() {
+		// the status is only missing when the run was canceled
+		result.Err = context.Canceled
+		scoped := forkScope(call)
+		messages := []string{}
+		for msg := range a.invokeGRPC(ctx, scoped) {
+			switch msg := msg.(type) {
+			case OnGRPCMessageMsg:
+				messages = append(messages, msg.Message)
+			case OnGRPCStatusMsg:
+				result.Status = int(msg.Code)
+				result.Err = nil
+				if msg.Code != codes.OK {
+					result.Err = fmt.Errorf("%s %s", msg.Code, msg.Message)
+				}
+				snapshot := grpcResponse(msg, messages)
+				result.Response = &snapshot
+			}
+		}
+		result.Duration = time.Since(started)
+		if result.Response != nil {
+			result.Size = int64(len(result.Response.Body))
+			result.Assertions, result.Variables = a.checkResponse(scoped, *result.Response, nil, result.Duration, result.Size)
+			call.scope.set(result.Variables...)
+		}
+		return result
+	}
+
+	scoped := forkScope(call)
+	params := scoped.RequestParams()
+	params.Context = ctx
+	if err := a.runPreRequestScripts(scoped, &params); err != nil {
+		result.Err = err
+		return result
+	}
+
+	sent := &SentRequest{Method: params.Method, Url: params.URL, Headers: map[string]string{}}
+	for name, value := range params.Headers {
+		sent.Headers[name] = value
+	}
+	if params.Username != "" {
+		sent.Headers["Authorization"] = "Basic"
+	}
+	if params.Body != nil {
+		body, _ := io.ReadAll(params.Body)
+		sent.Body = string(body)
+		params.Body = strings.NewReader(sent.Body)
+	}
+	result.Request = sent
+
+	started = time.Now()
+	response, err := utils.MakeRequest(params)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer response.Body.Close()
+	result.Status = response.StatusCode
+
+	// event streams never end, only the status is checked
+	if isEventStream(response) {
+		result.Duration = time.Since(started)
+		return result
+	}
+
+	body := utils.NewSpillBuffer(MaxBodyInMemory())
+	_, err = io.Copy(body, response.Body)
+	path, closeErr := body.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if path != "" {
+		trackTempFile(path)
+	}
+	result.Duration = time.Since(started)
+	result.Size = body.Size()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	snapshot := newResponse(response, body.String(), path)
+	result.Response = &snapshot
+	result.Assertions, result.Variables = a.checkResponse(scoped, snapshot, response.Cookies(), result.Duration, result.Size)
+	call.scope.set(result.Variables...)
+	return result
+}
+
+// Run sends the selected calls of the collection in order, once per
+// iteration, and reports every result to progress as soon as it is known
+func (a *App) Run(ctx context.Context, collection Collection, opts RunOptions, progress func(RunResult)) ([]RunResult, error) {
+	// the variables set by the calls are kept for the rest of the run, the
+	// environment of the run and the data rows only apply to its calls
+	scope := &variableScope{}
+	if opts.Environment != "" {
+		environment := a.findEnvironment(opts.Environment)
+		if environment == nil {
+			return nil, fmt.Errorf("unknown environment %q", opts.Environment)
+		}
+		scope.environment = &Environment{ID: environment.ID, Name: environment.Name, Variables: maps.Clone(environment.Variables)}
+	}
+
+	calls := RunnerCalls(collection, opts)
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("no calls to run in %s", collection.Name)
+	}
+	for i := range calls {
+		calls[i].scope = scope
+	}
+
+	results := []RunResult{}
+	for iteration := 1; iteration <= opts.IterationCount(); iteration++ {
+		var row map[string]string
+		if len(opts.Data) > 0 {
+			row = opts.Data[(iteration-1)%len(opts.Data)]
+		}
+		scope.data = row
+
+		for i := range calls {
+			if len(results) > 0 && opts.Delay > 0 {
+				select {
+				case <-time.After(opts.Delay):
+				case <-ctx.Done():
+				}
+			}
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+
+			result := a.Execute(ctx, &calls[i])
+			result.Iteration = iteration
+			result.Data = row
+			// the request and response are only needed to report failures
+			if result.Passed() && !opts.KeepResponses {
+				result.Request, result.Response = nil, nil
+			}
+			results = append(results, result)
+			if progress != nil {
+				progress(result)
+			}
+			if opts.StopOnFailure && !result.Passed() {
+				return results, nil
+			}
+		}
+	}
+	return results, nil
+}
+
+// grpcResponse turns the outcome of a gRPC call into a response for the
+// assertions. The status is the gRPC code, the headers hold the header and
+// trailer metadata and the body is the response message, or a JSON array of
+// them for streams.
+func grpcResponse(status OnGRPCStatusMsg, messages []string) Response {
+	headers := http.Header{}
+	for _, md := range []metadata.MD{status.Header, status.Trailer} {
+		for name, values := range md {
+			for _, value := range values {
+				headers.Add(name, value)
+			}
+		}
+	}
+	body := strings.Join(messages, ",\n")
+	if len(messages) != 1 {
+		body = "[" + body + "]"
+	}
+	return Response{Status: int(status.Code), Headers: headers, Body: body, ReceivedAt: time.Now()}
+}
+
+// the run started from the UI, canceled when the runner is closed
+var activeRun struct {
+	cancel context.CancelFunc
+}
+
+// StartRun runs the collection in the background, every result is sent as
+// an OnRunProgressMsg followed by an OnRunFinishedMsg
+func (a *App) StartRun(collection Collection, opts RunOptions) tea.Cmd {
+	a.StopRun()
+	ctx, cancel := context.WithCancel(context.Background())
+	activeRun.cancel = cancel
+
+	messages := make(chan tea.Msg)
+	go func() {
+		defer close(messages)
+		total := len(RunnerCalls(collection, opts)) * opts.IterationCount()
+		results, err := a.Run(ctx, collection, opts, func(result RunResult) {
+			messages <- OnRunProgressMsg{Result: result, Total: total, next: waitForResponse(messages)}
+		})
+		if err == context.Canceled {
+			err = fmt.Errorf("run canceled")
+		}
+		messages <- OnRunFinishedMsg{Results: results, Err: err}
+	}()
+	return waitForResponse(messages)
+}
+
+// StopRun cancels the run started from the UI, if any
+func (a *App) StopRun() {
+	if activeRun.cancel != nil {
+		activeRun.cancel()
+		activeRun.cancel = nil
+	}
+}
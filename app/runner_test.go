@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRunnerCalls(t *testing.T) {
+	collection := Collection{Calls: []Call{
+		{ID: "1", Folder: "auth", Tags: []string{"smoke"}},
+		{ID: "2", Folder: "auth/tokens"},
+		{ID: "3", Folder: "users", Tags: []string{"Smoke"}},
+		{ID: "4"},
+	}}
+
+	tests := []struct {
+		opts RunOptions
+		want []string
+	}{
+		{RunOptions{}, []string{"1", "2", "3", "4"}},
+		{RunOptions{Folder: "auth"}, []string{"1", "2"}},
+		{RunOptions{Folder: "/auth/tokens/"}, []string{"2"}},
+		{RunOptions{Tags: []string{"smoke"}}, []string{"1", "3"}},
+		{RunOptions{Folder: "auth", Tags: []string{"smoke"}}, []string{"1"}},
+		{RunOptions{Folder: "au"}, []string{}},
+	}
+
+	for _, test := range tests {
+		got := []string{}
+		for _, call := range RunnerCalls(collection, test.opts) {
+			got = append(got, call.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("RunnerCalls(%+v) = %v, want %v", test.opts, got, test.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	isolateConfig(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			fmt.Fprint(w, `{"token": "abc"}`)
+		case "/me":
+			if r.Header.Get("Authorization") != "Bearer abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	status := func(code string) []Assertion {
+		return []Assertion{{Subject: ASSERT_STATUS, Operator: "==", Value: code}}
+	}
+	collection := Collection{ID: "c", Name: "api", BaseUrl: server.URL, Calls: []Call{
+		{ID: "login", Method: "POST", Url: "{{BASE_URL}}/login", Assertions: status("200"),
+			Extractions: []Extraction{{Variable: "token", Scope: SCOPE_COLLECTION, Source: EXTRACT_JSONPATH, Expression: "$.token"}}},
+		{ID: "me", Method: "GET", Url: "{{BASE_URL}}/me", Headers: Headers{{Key: "Authorization", Value: "Bearer {{token}}"}}, Assertions: status("200")},
+		{ID: "missing", Method: "GET", Url: "{{BASE_URL}}/missing", Assertions: status("200")},
+	}}
+
+	a := GetInstance()
+	a.Collections = []Collection{collection}
+	t.Cleanup(func() { a.Collections = nil })
+
+	progress := 0
+	results, err := a.Run(context.Background(), collection, RunOptions{Iterations: 2}, func(RunResult) { progress++ })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 6 || progress != 6 {
+		t.Fatalf("Run() = %d results, %d progress updates, want 6", len(results), progress)
+	}
+	for i, want := range []bool{true, true, false, true, true, false} {
+		if results[i].Passed() != want {
+			t.Errorf("result %d (%s) passed = %v, want %v", i, results[i].Call.ID, results[i].Passed(), want)
+		}
+	}
+	if results[0].Request != nil || results[2].Request == nil || results[2].Response.Status != http.StatusNotFound {
+		t.Errorf("only failed calls should keep the request and response")
+	}
+	if results[3].Iteration != 2 {
+		t.Errorf("Iteration = %d, want 2", results[3].Iteration)
+	}
+	// the token is passed on to the next calls but not stored
+	if len(results[0].Variables) != 1 || results[0].Variables[0].Value != "abc" {
+		t.Errorf("Variables = %+v, want the extracted token", results[0].Variables)
+	}
+	if _, ok := a.Collections[0].Variables["token"]; ok {
+		t.Errorf("a run should not store the variables")
+	}
+	a.ApplyVariables(&results[0].Call, results[0].Variables)
+	if a.Collections[0].Variables["token"] != "abc" {
+		t.Errorf("ApplyVariables() did not store the token, got %v", a.Collections[0].Variables)
+	}
+
+	results, _ = a.Run(context.Background(), collection, RunOptions{Iterations: 2, StopOnFailure: true}, nil)
+	if len(results) != 3 {
+		t.Errorf("Run() with stop on failure = %d results, want 3", len(results))
+	}
+
+	if _, err := a.Run(context.Background(), collection, RunOptions{Environment: "nope"}, nil); err == nil {
+		t.Errorf("Run() with an unknown environment should fail")
+	}
+}
+
+func TestRunWithEnvironment(t *testing.T) {
+	isolateConfig(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"stage": %q}`, r.URL.Query().Get("stage"))
+	}))
+	defer server.Close()
+
+	collection := Collection{ID: "c", Name: "api", BaseUrl: server.URL, Calls: []Call{
+		{ID: "stage", Method: "GET", Url: "{{BASE_URL}}/?stage={{stage}}",
+			Assertions:  []Assertion{{Subject: ASSERT_JSONPATH, Target: "$.stage", Operator: "==", Value: `"staging"`}},
+			Extractions: []Extraction{{Variable: "seen", Scope: SCOPE_ENVIRONMENT, Source: EXTRACT_JSONPATH, Expression: "$.stage"}}},
+	}}
+	a := GetInstance()
+	a.Collections = []Collection{collection}
+	a.Environments = []Environment{{Name: "dev", Variables: map[string]string{"stage": "dev"}}, {Name: "staging", Variables: map[string]string{"stage": "staging"}}}
+	a.SelectEnvironment("dev")
+	t.Cleanup(func() { a.Collections, a.Environments, a.SelectedEnvironment = nil, nil, nil })
+
+	results, err := a.Run(context.Background(), collection, RunOptions{Environment: "staging"}, nil)
+	if err != nil || len(results) != 1 || !results[0].Passed() {
+		t.Fatalf("Run() = %+v, %v", results, err)
+	}
+	// the selected environment is left alone, the variables go to the one
+	// of the run
+	if a.SelectedEnvironment.Name != "dev" {
+		t.Errorf("SelectedEnvironment = %s, want dev", a.SelectedEnvironment.Name)
+	}
+	a.ApplyVariables(&results[0].Call, results[0].Variables)
+	if a.Environments[1].Variables["seen"] != "staging" || a.Environments[0].Variables["seen"] != "" {
+		t.Errorf("environments after ApplyVariables() = %+v", a.Environments)
+	}
+}
+
+func TestRunWithData(t *testing.T) {
+	isolateConfig(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Role") != "admin" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	collection := Collection{ID: "c", Name: "api", BaseUrl: server.URL, Calls: []Call{
+		{ID: "user", Method: "GET", Url: "{{BASE_URL}}/users/{{id}}", Headers: Headers{{Key: "X-Role", Value: "{{role}}"}},
+			Assertions: []Assertion{{Subject: ASSERT_STATUS, Operator: "==", Value: "200"}}},
+	}}
+	a := GetInstance()
+	a.Collections = []Collection{collection}
+	t.Cleanup(func() { a.Collections = nil })
+
+	data := []map[string]string{{"id": "1", "role": "admin"}, {"id": "2", "role": "guest"}}
+	results, err := a.Run(context.Background(), collection, RunOptions{Data: data, KeepResponses: true}, nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Run() = %d results, want one per row", len(results))
+	}
+	for i, want := range []string{"/users/1", "/users/2"} {
+		if results[i].Response == nil || results[i].Response.Body != want {
+			t.Errorf("result %d response = %+v, want %s", i, results[i].Response, want)
+		}
+		if results[i].Data["id"] != data[i]["id"] || results[i].Iteration != i+1 {
+			t.Errorf("result %d = iteration %d, data %v", i, results[i].Iteration, results[i].Data)
+		}
+	}
+	if !results[0].Passed() || results[1].Passed() {
+		t.Errorf("only the admin row should pass")
+	}
+	if _, ok := a.Variables(&collection.Calls[0])["id"]; ok {
+		t.Errorf("data variables should be cleared after the run")
+	}
+
+	results, _ = a.Run(context.Background(), collection, RunOptions{Data: data, Iterations: 3}, nil)
+	if len(results) != 3 || results[2].Data["id"] != "1" {
+		t.Errorf("rows should be cycled for more iterations")
+	}
+}
@@ -0,0 +1,345 @@
+package app
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"restman/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+)
+
+const ASSERT_SCRIPT = "script"
+
+// how long a script may run before it is interrupted
+const scriptTimeout = 5 * time.Second
+
+// how many console entries are kept
+const maxScriptLogs = 500
+
+const (
+	LOG_INFO  = "info"
+	LOG_WARN  = "warn"
+	LOG_ERROR = "error"
+)
+
+// ScriptLog is a line written by a script with console.log and friends
+type ScriptLog struct {
+	Time    time.Time
+	Level   string
+	Source  string
+	Message string
+}
+
+var scriptLogs struct {
+	sync.Mutex
+	entries []ScriptLog
+}
+
+// ScriptLogs returns the console output of the scripts, oldest first
+func ScriptLogs() []ScriptLog {
+	scriptLogs.Lock()
+	defer scriptLogs.Unlock()
+	return append([]ScriptLog{}, scriptLogs.entries...)
+}
+
+// ClearScriptLogs empties the console
+func ClearScriptLogs() {
+	scriptLogs.Lock()
+	defer scriptLogs.Unlock()
+	scriptLogs.entries = nil
+}
+
+func logScript(level string, source string, message string) {
+	scriptLogs.Lock()
+	defer scriptLogs.Unlock()
+	scriptLogs.entries = append(scriptLogs.entries, ScriptLog{Time: time.Now(), Level: level, Source: source, Message: message})
+	if len(scriptLogs.entries) > maxScriptLogs {
+		scriptLogs.entries = scriptLogs.entries[len(scriptLogs.entries)-maxScriptLogs:]
+	}
+}
+
+// scriptRequest is the outgoing request as seen by rm.request
+type scriptRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// script runs JavaScript with the rm API. The runtime has no access to the
+// file system or the network, only to the request, the response and the
+// variables of the call.
+type script struct {
+	app     *App
+	call    *Call
+	source  string
+	vm      *goja.Runtime
+	results []AssertionResult
+}
+
+func (a *App) newScript(call *Call, source string) *script {
+	s := &script{app: a, call: call, source: source, vm: goja.New()}
+	s.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+
+	console := s.vm.NewObject()
+	console.Set("log", s.logger(LOG_INFO))
+	console.Set("info", s.logger(LOG_INFO))
+	console.Set("warn", s.logger(LOG_WARN))
+	console.Set("error", s.logger(LOG_ERROR))
+	s.vm.Set("console", console)
+
+	rm := s.vm.NewObject()
+	rm.Set("variables", s.variablesObject(SCOPE_COLLECTION))
+	rm.Set("environment", s.variablesObject(SCOPE_ENVIRONMENT))
+	rm.Set("log", s.logger(LOG_INFO))
+	rm.Set("test", s.test)
+	rm.Set("assert", s.assert)
+	rm.Set("uuid", uuid.NewString)
+	rm.Set("timestamp", func() int64 { return time.Now().Unix() })
+	rm.Set("base64", map[string]interface{}{
+		"encode": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"decode": func(s string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			return string(decoded), err
+		},
+	})
+	rm.Set("crypto", map[string]interface{}{
+		"md5":        digest(md5.New),
+		"sha1":       digest(sha1.New),
+		"sha256":     digest(sha256.New),
+		"hmacSHA1":   hmacDigest(sha1.New),
+		"hmacSHA256": hmacDigest(sha256.New),
+	})
+	s.vm.Set("rm", rm)
+	return s
+}
+
+func digest(h func() hash.Hash) func(string) string {
+	return func(data string) string {
+		sum := h()
+		sum.Write([]byte(data))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+}
+
+func hmacDigest(h func() hash.Hash) func(string, string) string {
+	return func(key string, data string) string {
+		mac := hmac.New(h, []byte(key))
+		mac.Write([]byte(data))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+}
+
+func (s *script) logger(level string) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := []string{}
+		for _, arg := range call.Arguments {
+			parts = append(parts, s.format(arg))
+		}
+		logScript(level, s.source, strings.Join(parts, " "))
+		return goja.Undefined()
+	}
+}
+
+// format prints objects as JSON and everything else as a string
+func (s *script) format(value goja.Value) string {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return fmt.Sprint(value)
+	}
+	if _, ok := value.(*goja.Object); ok {
+		if _, isFunc := goja.AssertFunction(value); !isFunc {
+			if data, err := json.Marshal(value.Export()); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return value.String()
+}
+
+func (s *script) variablesObject(scope string) map[string]interface{} {
+	return map[string]interface{}{
+		"get": func(name string) goja.Value {
+			if value, ok := s.app.Variables(s.call)[name]; ok {
+				return s.vm.ToValue(value)
+			}
+			return goja.Undefined()
+		},
+		// the scripts run in the background, the variables are collected
+		// in the scope of the call and applied once it is done
+		"set": func(name string, value goja.Value) {
+			s.call.scope.set(VariableChange{Scope: scope, Name: name, Value: s.format(value)})
+		},
+		"all": func() map[string]string {
+			return s.app.Variables(s.call)
+		},
+	}
+}
+
+// test runs fn and records whether it threw
+func (s *script) test(name string, fn goja.Callable) {
+	result := AssertionResult{Assertion: Assertion{Subject: ASSERT_SCRIPT, Target: name}, Passed: true}
+	if _, err := fn(goja.Undefined()); err != nil {
+		result.Passed = false
+		result.Message = scriptError(err)
+	}
+	s.results = append(s.results, result)
+}
+
+// assert throws when the condition is false, meant to be used inside rm.test
+func (s *script) assert(condition bool, message string) {
+	if !condition {
+		if message == "" {
+			message = "assertion failed"
+		}
+		panic(s.vm.NewGoError(fmt.Errorf("%s", message)))
+	}
+}
+
+// run executes the script, interrupting it after scriptTimeout
+func (s *script) run(code string) error {
+	timer := time.AfterFunc(scriptTimeout, func() {
+		s.vm.Interrupt(fmt.Sprintf("%s script timed out after %s", s.source, scriptTimeout))
+	})
+	defer timer.Stop()
+
+	_, err := s.vm.RunScript(s.source, code)
+	if err != nil {
+		logScript(LOG_ERROR, s.source, scriptError(err))
+		return fmt.Errorf("%s script: %s", s.source, scriptError(err))
+	}
+	return nil
+}
+
+// scriptError returns the message of a thrown error without the stack
+func scriptError(err error) string {
+	switch e := err.(type) {
+	case *goja.Exception:
+		if obj, ok := e.Value().(*goja.Object); ok {
+			if message := obj.Get("message"); message != nil && !goja.IsUndefined(message) {
+				return message.String()
+			}
+		}
+		return e.Value().String()
+	case *goja.InterruptedError:
+		return fmt.Sprint(e.Value())
+	}
+	return err.Error()
+}
+
+// scripts returns the collection script followed by the one of the call
+func (a *App) scripts(call *Call, pick func(scripts Scripts) string) []string {
+	scripts := []string{}
+	if collection := a.findCollection(call); collection != nil && collection.Scripts != nil {
+		if code := pick(*collection.Scripts); strings.TrimSpace(code) != "" {
+			scripts = append(scripts, code)
+		}
+	}
+	if call.Scripts != nil {
+		if code := pick(*call.Scripts); strings.TrimSpace(code) != "" {
+			scripts = append(scripts, code)
+		}
+	}
+	return scripts
+}
+
+// Scripts are the pre-request and post-response scripts of a collection or a
+// call, the ones of the collection run first
+type Scripts struct {
+	PreRequest   string `json:"pre_request,omitempty"`
+	PostResponse string `json:"post_response,omitempty"`
+}
+
+// runPreRequestScripts lets the scripts modify the request through
+// rm.request before it is sent
+func (a *App) runPreRequestScripts(call *Call, params *utils.HTTPRequestParams) error {
+	scripts := a.scripts(call, func(s Scripts) string { return s.PreRequest })
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	request := &scriptRequest{Method: params.Method, Url: params.URL, Headers: params.Headers}
+	if request.Headers == nil {
+		request.Headers = map[string]string{}
+	}
+	if params.Body != nil {
+		body, _ := io.ReadAll(params.Body)
+		request.Body = string(body)
+	}
+
+	for _, code := range scripts {
+		s := a.newScript(call, "pre-request")
+		s.vm.Get("rm").(*goja.Object).Set("request", request)
+		if err := s.run(code); err != nil {
+			return err
+		}
+	}
+
+	params.Method = strings.ToUpper(request.Method)
+	params.URL = request.Url
+	params.Headers = request.Headers
+	params.Body = nil
+	if request.Body != "" {
+		params.Body = bytes.NewReader([]byte(request.Body))
+	}
+	return nil
+}
+
+// runPostResponseScripts runs the scripts against a response, rm.test results
+// are returned along with a failed result for scripts that threw
+func (a *App) runPostResponseScripts(call *Call, response Response, duration time.Duration, size int64) []AssertionResult {
+	scripts := a.scripts(call, func(s Scripts) string { return s.PostResponse })
+
+	results := []AssertionResult{}
+	for _, code := range scripts {
+		s := a.newScript(call, "post-response")
+		s.vm.Get("rm").(*goja.Object).Set("response", a.scriptResponse(s.vm, response, duration, size))
+		err := s.run(code)
+		results = append(results, s.results...)
+		if err != nil {
+			results = append(results, AssertionResult{
+				Assertion: Assertion{Subject: ASSERT_SCRIPT, Target: "post-response"},
+				Message:   err.Error(),
+			})
+		}
+	}
+	return results
+}
+
+func (a *App) scriptResponse(vm *goja.Runtime, response Response, duration time.Duration, size int64) *goja.Object {
+	headers := map[string]string{}
+	for name := range response.Headers {
+		headers[strings.ToLower(name)] = response.Headers.Get(name)
+	}
+
+	object := vm.NewObject()
+	object.Set("status", response.Status)
+	object.Set("headers", headers)
+	object.Set("body", response.Content())
+	object.Set("time", duration.Milliseconds())
+	object.Set("size", size)
+	object.Set("header", func(name string) string {
+		return http.Header(response.Headers).Get(name)
+	})
+	object.Set("json", func() (interface{}, error) {
+		var document interface{}
+		if err := json.Unmarshal([]byte(response.Content()), &document); err != nil {
+			return nil, fmt.Errorf("body is not JSON")
+		}
+		return document, nil
+	})
+	return object
+}
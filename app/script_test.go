@@ -0,0 +1,132 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"restman/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreRequestScript(t *testing.T) {
+	isolateConfig(t)
+
+	a := GetInstance()
+	call := Call{ID: "signed", Scripts: &Scripts{PreRequest: `
+		rm.request.headers["X-Signature"] = rm.crypto.hmacSHA256("key", rm.request.body)
+		rm.request.url = rm.request.url + "?ts=" + rm.variables.get("ts")
+		rm.request.method = "put"
+		rm.variables.set("signed", true)
+		console.log("signed", {url: rm.request.url})
+	`}}
+	a.Collections = []Collection{{
+		ID:        "c",
+		Calls:     []Call{call},
+		Variables: map[string]string{"ts": "42"},
+		Scripts:   &Scripts{PreRequest: `rm.request.body = "payload"`},
+	}}
+	t.Cleanup(func() {
+		a.Collections = nil
+		ClearScriptLogs()
+	})
+
+	params := utils.HTTPRequestParams{Method: "POST", URL: "http://localhost/sign", Headers: map[string]string{}}
+	scoped := forkScope(&call)
+	if err := a.runPreRequestScripts(scoped, &params); err != nil {
+		t.Fatalf("runPreRequestScripts() error = %v", err)
+	}
+	if changes := scoped.scope.recorded(); len(changes) != 1 || changes[0] != (VariableChange{Scope: SCOPE_COLLECTION, Name: "signed", Value: "true"}) {
+		t.Errorf("variables set = %+v", changes)
+	}
+
+	body, _ := io.ReadAll(params.Body)
+	if string(body) != "payload" {
+		t.Errorf("body = %q, want payload", body)
+	}
+	if params.URL != "http://localhost/sign?ts=42" || params.Method != "PUT" {
+		t.Errorf("request = %s %s", params.Method, params.URL)
+	}
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte("payload"))
+	if params.Headers["X-Signature"] != hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("X-Signature = %q", params.Headers["X-Signature"])
+	}
+	logs := ScriptLogs()
+	if len(logs) != 1 || logs[0].Message != `signed {"url":"http://localhost/sign?ts=42"}` {
+		t.Errorf("ScriptLogs() = %+v", logs)
+	}
+}
+
+func TestPreRequestScriptError(t *testing.T) {
+	call := Call{ID: "broken", Scripts: &Scripts{PreRequest: `throw new Error("no token")`}}
+	t.Cleanup(ClearScriptLogs)
+
+	params := utils.HTTPRequestParams{Method: "GET", URL: "http://localhost"}
+	err := GetInstance().runPreRequestScripts(&call, &params)
+	if err == nil || !strings.Contains(err.Error(), "no token") {
+		t.Errorf("runPreRequestScripts() error = %v, want no token", err)
+	}
+}
+
+func TestPostResponseScript(t *testing.T) {
+	isolateConfig(t)
+
+	a := GetInstance()
+	call := Call{ID: "post", Scripts: &Scripts{PostResponse: `
+		const data = rm.response.json()
+		rm.variables.set("token", data.token)
+		rm.test("status is 200", () => rm.assert(rm.response.status === 200))
+		rm.test("has user", () => rm.assert(data.user !== undefined, "user is missing"))
+		rm.test("json header", () => rm.assert(rm.response.header("content-type") === "application/json"))
+	`}}
+	a.Collections = []Collection{{ID: "c", Calls: []Call{call}}}
+	t.Cleanup(func() {
+		a.Collections = nil
+		ClearScriptLogs()
+	})
+
+	response := Response{Status: 200, Headers: http.Header{"Content-Type": {"application/json"}}, Body: `{"token": "abc"}`}
+	scoped := forkScope(&call)
+	results := a.runPostResponseScripts(scoped, response, time.Millisecond, 10)
+
+	if len(results) != 3 {
+		t.Fatalf("runPostResponseScripts() = %+v, want 3 results", results)
+	}
+	if !results[0].Passed || results[1].Passed || !results[2].Passed {
+		t.Errorf("results = %+v", results)
+	}
+	if results[1].Message != "user is missing" {
+		t.Errorf("message = %q", results[1].Message)
+	}
+	if results[0].Assertion.String() != "script status is 200" {
+		t.Errorf("String() = %q", results[0].Assertion.String())
+	}
+	// the token is only stored once the changes are applied
+	if got := a.Variables(scoped)["token"]; got != "abc" {
+		t.Errorf("token = %q, want abc", got)
+	}
+	if _, ok := a.Variables(&call)["token"]; ok {
+		t.Errorf("the script should not store the token")
+	}
+	a.ApplyVariables(&call, scoped.scope.recorded())
+	if got := a.Variables(&call)["token"]; got != "abc" {
+		t.Errorf("token after ApplyVariables() = %q, want abc", got)
+	}
+}
+
+func TestScriptTimeout(t *testing.T) {
+	call := Call{ID: "loop", Scripts: &Scripts{PostResponse: `while (true) {}`}}
+	t.Cleanup(ClearScriptLogs)
+
+	s := GetInstance().newScript(&call, "post-response")
+	done := make(chan error)
+	go func() { done <- s.run(call.Scripts.PostResponse) }()
+	s.vm.Interrupt("stopped")
+	if err := <-done; err == nil {
+		t.Errorf("run() error = nil, want interrupted")
+	}
+}
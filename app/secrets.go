@@ -0,0 +1,468 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"restman/utils"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrSecretsLocked is returned when credentials have to be stored in the
+// secrets store before it was unlocked
+var ErrSecretsLocked = errors.New("secrets are locked, unlock them to save credentials")
+
+// NoticeSecretsLocked is shown when collections were saved without their
+// plaintext credentials as the secrets store is locked
+const NoticeSecretsLocked = "credentials are not saved until the secrets are unlocked"
+
+// ErrWrongPassphrase is returned when the secrets store cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase for the secrets store")
+
+// scrypt parameters deriving the key of the secrets store
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var secretNameReg = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// secretsFile is the content of secrets.json, the secrets are encrypted with
+// AES-GCM using a key derived with scrypt from the master passphrase
+type secretsFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// secrets holds the secrets once the store is unlocked, with the key they
+// are encrypted with again when they change
+var secrets struct {
+	sync.Mutex
+	values map[string]string
+	key    []byte
+	salt   []byte
+}
+
+func secretsPath() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "restman", "secrets.json")
+}
+
+// SecretReference returns the reference to the secret used in calls and
+// environments
+func SecretReference(name string) string {
+	return "{{secret:" + name + "}}"
+}
+
+// SecretsExist reports whether a secrets store was created
+func (a *App) SecretsExist() bool {
+	_, err := os.Stat(secretsPath())
+	return err == nil
+}
+
+// SecretsUnlocked reports whether the secrets can be used
+func (a *App) SecretsUnlocked() bool {
+	secrets.Lock()
+	defer secrets.Unlock()
+	return secrets.values != nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+}
+
+// UnlockSecrets decrypts the secrets store with the passphrase, the store is
+// created with it when there is none
+func (a *App) UnlockSecrets(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("the passphrase cannot be empty")
+	}
+
+	data, err := os.ReadFile(secretsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return err
+		}
+		key, err := deriveKey(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		secrets.Lock()
+		secrets.values, secrets.key, secrets.salt = map[string]string{}, key, salt
+		secrets.Unlock()
+		return a.saveSecrets()
+	}
+	if err != nil {
+		return err
+	}
+
+	stored := secretsFile{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("%s: %w", secretsPath(), err)
+	}
+	key, err := deriveKey(passphrase, stored.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, stored.Nonce, stored.Data, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	values := map[string]string{}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return err
+	}
+
+	secrets.Lock()
+	secrets.values, secrets.key, secrets.salt = values, key, stored.Salt
+	secrets.Unlock()
+	return nil
+}
+
+// UnlockSecretsWithKeyFile unlocks the secrets with the content of the key
+// file set as secrets_key_file in the config, if there is one
+func (a *App) UnlockSecretsWithKeyFile() error {
+	path := viper.GetString("secrets_key_file")
+	if path == "" || a.SecretsUnlocked() {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("secrets key file: %w", err)
+	}
+	return a.UnlockSecrets(strings.TrimSpace(string(data)))
+}
+
+// CheckSecrets sends SecretsLockedMsg when the secrets store has to be
+// unlocked for the session, or created to move plaintext credentials of
+// older collections into it
+func (a *App) CheckSecrets() tea.Cmd {
+	return func() tea.Msg {
+		if a.SecretsUnlocked() || (!a.SecretsExist() && !a.HasPlaintextCredentials()) {
+			return nil
+		}
+		return SecretsLockedMsg{}
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// saveSecrets encrypts the secrets with a new nonce and writes them, only
+// the user can read the file
+func (a *App) saveSecrets() error {
+	secrets.Lock()
+	defer secrets.Unlock()
+	if secrets.values == nil {
+		return ErrSecretsLocked
+	}
+
+	plain, err := json.Marshal(secrets.values)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(secrets.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(secretsFile{
+		Version: 1,
+		Salt:    secrets.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	}, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(secretsPath()), os.ModePerm); err != nil {
+		return err
+	}
+	return writeFileAtomic(secretsPath(), data, 0600)
+}
+
+// SetSecret stores the secret, the store has to be unlocked
+func (a *App) SetSecret(name string, value string) error {
+	if !secretNameReg.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q, use letters, digits and _ . : -", name)
+	}
+	secrets.Lock()
+	if secrets.values == nil {
+		secrets.Unlock()
+		return ErrSecretsLocked
+	}
+	secrets.values[name] = value
+	secrets.Unlock()
+	return a.saveSecrets()
+}
+
+// RemoveSecret removes the secret from the store
+func (a *App) RemoveSecret(name string) error {
+	secrets.Lock()
+	if secrets.values == nil {
+		secrets.Unlock()
+		return ErrSecretsLocked
+	}
+	if _, ok := secrets.values[name]; !ok {
+		secrets.Unlock()
+		return fmt.Errorf("secret %q not found", name)
+	}
+	delete(secrets.values, name)
+	secrets.Unlock()
+	return a.saveSecrets()
+}
+
+// SecretNames returns the sorted names of the secrets
+func (a *App) SecretNames() []string {
+	secrets.Lock()
+	defer secrets.Unlock()
+	names := []string{}
+	for name := range secrets.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// secretVariables returns the secrets as variables named secret:name, none
+// while the store is locked
+func secretVariables() map[string]string {
+	secrets.Lock()
+	defer secrets.Unlock()
+	variables := map[string]string{}
+	for name, value := range secrets.values {
+		variables["secret:"+name] = value
+	}
+	return variables
+}
+
+// secretValues returns the values of the secrets, to be redacted
+func secretValues() []string {
+	secrets.Lock()
+	defer secrets.Unlock()
+	values := []string{}
+	for _, value := range secrets.values {
+		values = append(values, value)
+	}
+	return values
+}
+
+// isPlaintextCredential reports whether a credential has to be moved to the
+// secrets store, values referencing secrets or variables stay as they are
+func isPlaintextCredential(value string) bool {
+	return value != "" && !strings.Contains(value, "{{")
+}
+
+// sealAuth moves the plaintext credentials of the auth to the secrets store,
+// named after the owner of the auth. A copy is returned as calls copied
+// into tabs share the auth with the collection.
+func sealAuth(auth *Auth, owner string, values map[string]string) *Auth {
+	if auth == nil {
+		return nil
+	}
+	sealed := *auth
+	fields := []struct {
+		name  string
+		value *string
+	}{{"password", &sealed.Password}, {"token", &sealed.Token}, {"api_key", &sealed.HeaderValue}}
+	for _, field := range fields {
+		if !isPlaintextCredential(*field.value) {
+			continue
+		}
+		// a secret with the name and another value is not overwritten
+		base := owner + "." + field.name
+		name := base
+		for n := 2; values[name] != "" && values[name] != *field.value; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		values[name] = *field.value
+		*field.value = SecretReference(name)
+	}
+	return &sealed
+}
+
+// stripAuth returns a copy of the auth without its plaintext credentials
+func stripAuth(auth *Auth) *Auth {
+	if auth == nil {
+		return nil
+	}
+	stripped := *auth
+	for _, value := range []*string{&stripped.Password, &stripped.Token, &stripped.HeaderValue} {
+		if isPlaintextCredential(*value) {
+			*value = ""
+		}
+	}
+	return &stripped
+}
+
+// storable returns the collections as they are written. While the secrets
+// store is locked their plaintext credentials are dropped from copies of
+// them, the ones in memory keep them until they are sealed.
+func (a *App) storable(collections []Collection) []Collection {
+	if a.SecretsUnlocked() || !hasPlaintextCredentials(collections) {
+		return collections
+	}
+	stored := make([]Collection, len(collections))
+	for i, collection := range collections {
+		collection.Auth = stripAuth(collection.Auth)
+		folders := append([]Folder{}, collection.Folders...)
+		for j := range folders {
+			folders[j].Auth = stripAuth(folders[j].Auth)
+		}
+		collection.Folders = folders
+		calls := append([]Call{}, collection.Calls...)
+		for j := range calls {
+			calls[j].Auth = stripAuth(calls[j].Auth)
+		}
+		collection.Calls = calls
+		stored[i] = collection
+	}
+	return stored
+}
+
+// plaintextAuth reports whether the auth holds plaintext credentials
+func plaintextAuth(auth *Auth) bool {
+	return auth != nil && (isPlaintextCredential(auth.Password) || isPlaintextCredential(auth.Token) || isPlaintextCredential(auth.HeaderValue))
+}
+
+// hasPlaintextCredentials reports whether credentials of the collections
+// still have to be moved to the secrets store
+func hasPlaintextCredentials(collections []Collection) bool {
+	for _, collection := range collections {
+		if plaintextAuth(collection.Auth) {
+			return true
+		}
+		for _, folder := range collection.Folders {
+			if plaintextAuth(folder.Auth) {
+				return true
+			}
+		}
+		for _, call := range collection.Calls {
+			if plaintextAuth(call.Auth) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPlaintextCredentials reports whether the secrets store has to be
+// unlocked to save the collections
+func (a *App) HasPlaintextCredentials() bool {
+	return hasPlaintextCredentials(a.Collections)
+}
+
+// sealSecrets replaces the plaintext credentials of the auth settings of the
+// collections with references to secrets holding them. While the store is
+// locked they are left as they are, saving is not blocked, and false is
+// returned.
+func (a *App) sealSecrets(collections []Collection) (bool, error) {
+	if !hasPlaintextCredentials(collections) {
+		return true, nil
+	}
+	secrets.Lock()
+	if secrets.values == nil {
+		secrets.Unlock()
+		return false, nil
+	}
+	for i := range collections {
+		collection := &collections[i]
+		prefix := slug(collection.Name)
+		collection.Auth = sealAuth(collection.Auth, prefix, secrets.values)
+
+		folders := append([]Folder{}, collection.Folders...)
+		for j := range folders {
+			owner := prefix
+			for _, segment := range strings.Split(folders[j].Path, "/") {
+				owner += "." + slug(segment)
+			}
+			folders[j].Auth = sealAuth(folders[j].Auth, owner, secrets.values)
+		}
+		collection.Folders = folders
+
+		calls := append([]Call{}, collection.Calls...)
+		for j := range calls {
+			name := calls[j].Name
+			if name == "" {
+				name = calls[j].Title()
+			}
+			calls[j].Auth = sealAuth(calls[j].Auth, prefix+"."+slug(name), secrets.values)
+			// the collections hold the saved calls
+			calls[j].hash = utils.ComputeHash(calls[j])
+		}
+		collection.Calls = calls
+	}
+	secrets.Unlock()
+	return true, a.saveSecrets()
+}
+
+// sealTab returns the call of a tab as it is stored in tabs.json, with its
+// plaintext credentials moved to the secrets store. They are dropped while
+// the store is locked, quitting is not blocked for tabs.
+func (a *App) sealTab(call Call) Call {
+	if !plaintextAuth(call.Auth) {
+		return call
+	}
+	secrets.Lock()
+	if secrets.values == nil {
+		secrets.Unlock()
+		call.Auth = stripAuth(call.Auth)
+		return call
+	}
+	call.Auth = sealAuth(call.Auth, "tabs."+slug(call.Title()), secrets.values)
+	secrets.Unlock()
+	a.saveSecrets()
+	return call
+}
+
+// redactingWriter replaces the values of the secrets in what is written
+type redactingWriter struct {
+	w io.Writer
+}
+
+// RedactSecrets returns a writer hiding the values of the secrets, for logs
+func RedactSecrets(w io.Writer) io.Writer {
+	return redactingWriter{w}
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	redacted := string(p)
+	for _, value := range secretValues() {
+		if len(value) >= minSecretLength {
+			redacted = strings.ReplaceAll(redacted, value, REDACTED)
+		}
+	}
+	if _, err := io.WriteString(r.w, redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// lockSecrets forgets the secrets unlocked by a test
+func lockSecrets(t *testing.T) {
+	t.Cleanup(func() {
+		secrets.Lock()
+		secrets.values, secrets.key, secrets.salt = nil, nil, nil
+		secrets.Unlock()
+	})
+}
+
+func TestUnlockSecrets(t *testing.T) {
+	isolateConfig(t)
+	lockSecrets(t)
+	a := &App{}
+
+	if err := a.SetSecret("token", "s3cr3t"); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("SetSecret() while locked = %v", err)
+	}
+	if err := a.UnlockSecrets("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetSecret("token", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetSecret("not a name", "value"); err == nil {
+		t.Errorf("Expected error for an invalid secret name")
+	}
+
+	data, _ := os.ReadFile(secretsPath())
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("Secret written in plaintext: %s", data)
+	}
+	if info, err := os.Stat(secretsPath()); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected secrets readable by the user only, got %v %v", info.Mode(), err)
+	}
+
+	secrets.values = nil
+	if err := a.UnlockSecrets("wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("UnlockSecrets() with a wrong passphrase = %v", err)
+	}
+	if err := a.UnlockSecrets("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if names := a.SecretNames(); len(names) != 1 || names[0] != "token" {
+		t.Errorf("SecretNames() = %v", names)
+	}
+	if err := a.RemoveSecret("token"); err != nil || len(a.SecretNames()) != 0 {
+		t.Errorf("RemoveSecret() = %v, names %v", err, a.SecretNames())
+	}
+}
+
+func TestSealSecrets(t *testing.T) {
+	isolateConfig(t)
+	lockSecrets(t)
+	a := &App{}
+
+	auth := &Auth{Type: "bearer_token", Token: "t0ken-value"}
+	collections := []Collection{{
+		ID:   "c",
+		Name: "My API",
+		Auth: &Auth{Type: "basic_auth", Username: "me", Password: "{{password}}"},
+		Calls: []Call{
+			{ID: "a", Name: "List", Auth: auth},
+			{ID: "b", Name: "Other", Auth: &Auth{Type: "bearer_token", Token: "another"}},
+		},
+	}}
+
+	// saving is not blocked while the store is locked
+	if sealed, err := a.sealSecrets(collections); sealed || err != nil {
+		t.Errorf("sealSecrets() while locked = %v, %v", sealed, err)
+	}
+	if collections[0].Calls[0].Auth.Token != "t0ken-value" {
+		t.Errorf("Expected the token to be left as it is, got %q", collections[0].Calls[0].Auth.Token)
+	}
+	if err := a.UnlockSecrets("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, err := a.sealSecrets(collections); !sealed || err != nil {
+		t.Fatalf("sealSecrets() = %v, %v", sealed, err)
+	}
+
+	sealed := collections[0].Calls[0]
+	if sealed.Auth.Token != SecretReference("my-api.list.token") {
+		t.Errorf("Expected reference to the secret, got %q", sealed.Auth.Token)
+	}
+	// tabs share the auth of the call, it is not changed
+	if auth.Token != "t0ken-value" {
+		t.Errorf("Expected the auth to be copied, got %q", auth.Token)
+	}
+	if sealed.WasChanged() {
+		t.Errorf("Expected sealed call to be the saved one")
+	}
+	if collections[0].Auth.Password != "{{password}}" {
+		t.Errorf("Expected variables to stay, got %q", collections[0].Auth.Password)
+	}
+	if hasPlaintextCredentials(collections) {
+		t.Errorf("Expected no plaintext credentials left")
+	}
+
+	if variables := a.Variables(&sealed); variables["secret:my-api.list.token"] != "t0ken-value" {
+		t.Errorf("Expected secret in the variables, got %v", variables)
+	}
+	if params := sealed.RequestParams(); params.Headers["Authorization"] != "Bearer t0ken-value" {
+		t.Errorf("Expected resolved token, got %q", params.Headers["Authorization"])
+	}
+}
+
+func TestSaveWhileSecretsLocked(t *testing.T) {
+	isolateConfig(t)
+	lockSecrets(t)
+	a := &App{Collections: []Collection{{ID: "c", Name: "api", Calls: []Call{
+		{ID: "a", Name: "List", Auth: &Auth{Type: "bearer_token", Token: "t0ken-value"}},
+	}}}}
+
+	msg := a.syncCollections(KeepMine, false).(FetchCollectionsSuccessMsg)
+	if msg.Err != nil || msg.Notice != NoticeSecretsLocked {
+		t.Fatalf("syncCollections() while locked = %v, notice %q", msg.Err, msg.Notice)
+	}
+	data, _ := os.ReadFile(collectionsPath())
+	if !strings.Contains(string(data), `"List"`) || strings.Contains(string(data), "t0ken-value") {
+		t.Errorf("Expected the collections to be saved without the token, got %s", data)
+	}
+	if info, err := os.Stat(collectionsPath()); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected collections.json only readable by the user, got %v", info.Mode())
+	}
+	if token := a.Collections[0].Calls[0].Auth.Token; token != "t0ken-value" {
+		t.Errorf("Expected the token to stay in memory, got %q", token)
+	}
+
+	if err := a.UnlockSecrets("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	msg = a.syncCollections(KeepMine, false).(FetchCollectionsSuccessMsg)
+	if msg.Err != nil || msg.Notice != "" {
+		t.Fatalf("syncCollections() = %v, notice %q", msg.Err, msg.Notice)
+	}
+	if data, _ := os.ReadFile(collectionsPath()); strings.Contains(string(data), "t0ken-value") || !strings.Contains(string(data), "secret:api.list.token") {
+		t.Errorf("Expected the token to be moved to the secrets, got %s", data)
+	}
+}
+
+func TestSealTab(t *testing.T) {
+	isolateConfig(t)
+	lockSecrets(t)
+	a := &App{}
+
+	call := Call{ID: "a", Name: "List", Auth: &Auth{Type: "bearer_token", Token: "t0ken-value"}}
+	if tab := a.sealTab(call); tab.Auth.Token != "" {
+		t.Errorf("Expected credentials dropped while locked, got %q", tab.Auth.Token)
+	}
+
+	if err := a.UnlockSecrets("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if tab := a.sealTab(call); tab.Auth.Token != SecretReference("tabs.list.token") {
+		t.Errorf("Expected reference to the secret, got %q", tab.Auth.Token)
+	}
+	if call.Auth.Token != "t0ken-value" {
+		t.Errorf("Expected the call to be left alone, got %q", call.Auth.Token)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	isolateConfig(t)
+	lockSecrets(t)
+	a := &App{}
+	if err := a.UnlockSecrets("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetSecret("token", "t0ken-value"); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	line := "Authorization: Bearer t0ken-value\n"
+	if n, err := RedactSecrets(buf).Write([]byte(line)); err != nil || n != len(line) {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	if buf.String() != "Authorization: Bearer "+REDACTED+"\n" {
+		t.Errorf("Unexpected redacted log %q", buf.String())
+	}
+}
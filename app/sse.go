@@ -0,0 +1,262 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"restman/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// default reconnection delay, servers can change it with the retry field
+const defaultRetry = 3 * time.Second
+
+// Event is a single Server-Sent Event
+type Event struct {
+	Name       string
+	ID         string
+	Data       string
+	ReceivedAt time.Time
+}
+
+// Transcript renders the event in the text/event-stream format, prefixed
+// with a comment holding the time it was received.
+func (e Event) Transcript() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ": %s\n", e.ReceivedAt.Format(time.RFC3339Nano))
+	if e.Name != "" && e.Name != "message" {
+		fmt.Fprintf(&b, "event: %s\n", e.Name)
+	}
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", e.ID)
+	}
+	for _, line := range strings.Split(e.Data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// EventReader parses a text/event-stream body
+type EventReader struct {
+	r           *bufio.Reader
+	LastEventID string
+	Retry       time.Duration
+}
+
+func NewEventReader(r io.Reader) *EventReader {
+	return &EventReader{r: bufio.NewReader(r)}
+}
+
+// Next blocks until the next event is dispatched by the stream
+func (s *EventReader) Next() (Event, error) {
+	var event Event
+	var data strings.Builder
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			return Event{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// an empty line dispatches the event
+		if line == "" {
+			if data.Len() == 0 {
+				event = Event{}
+				continue
+			}
+			event.ID = s.LastEventID
+			event.Data = strings.TrimSuffix(data.String(), "\n")
+			if event.Name == "" {
+				event.Name = "message"
+			}
+			return event, nil
+		}
+
+		// comment
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Name = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				s.LastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				s.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+func isEventStream(response *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// openStream is an event stream or gRPC call which is open
+type openStream struct {
+	call   *Call
+	cancel context.CancelFunc
+	grpc   bool
+}
+
+// the open event streams and gRPC calls by call ID, every tab can have one
+var activeStreams struct {
+	sync.Mutex
+	streams map[string]*openStream
+}
+
+// trackStream registers the stream of a call, replacing the one it had
+func trackStream(s *openStream) {
+	activeStreams.Lock()
+	defer activeStreams.Unlock()
+	if activeStreams.streams == nil {
+		activeStreams.streams = map[string]*openStream{}
+	}
+	if previous := activeStreams.streams[s.call.ID]; previous != nil {
+		previous.cancel()
+	}
+	activeStreams.streams[s.call.ID] = s
+}
+
+// untrackStream forgets the stream once it ended, unless it was replaced
+func untrackStream(s *openStream) {
+	activeStreams.Lock()
+	defer activeStreams.Unlock()
+	if activeStreams.streams[s.call.ID] == s {
+		delete(activeStreams.streams, s.call.ID)
+	}
+}
+
+// StopStream closes the running event stream or gRPC call of the call, if
+// any
+func (a *App) StopStream(call *Call) tea.Cmd {
+	activeStreams.Lock()
+	defer activeStreams.Unlock()
+	s := activeStreams.streams[call.ID]
+	if s == nil {
+		return nil
+	}
+	s.cancel()
+	delete(activeStreams.streams, call.ID)
+	if s.grpc {
+		return nil
+	}
+	return func() tea.Msg {
+		return OnEventStreamClosedMsg{Call: s.call}
+	}
+}
+
+// streamEvents reads events in the background and returns a channel
+// delivering OnEventStreamMsg, OnEventMsg and OnEventStreamClosedMsg. When
+// the server closes the connection it reconnects, honouring the retry delay
+// and sending the Last-Event-ID header. Sending the call again stops the
+// stream as it cancels its request.
+func (a *App) streamEvents(r *request, call *Call, response *http.Response) chan tea.Msg {
+	messages := make(chan tea.Msg)
+	ctx, cancel := context.WithCancel(r)
+
+	tracked := &openStream{call: call, cancel: cancel}
+	trackStream(tracked)
+
+	send := func(msg tea.Msg) bool {
+		select {
+		case messages <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer r.release()
+		defer close(messages)
+		defer untrackStream(tracked)
+
+		reader := NewEventReader(nil)
+		reader.Retry = defaultRetry
+
+		for {
+			reader.r = bufio.NewReader(response.Body)
+			// closing the body unblocks the reader once the stream is stopped
+			stop := context.AfterFunc(ctx, func() { response.Body.Close() })
+
+			if !send(OnEventStreamMsg{Call: call, Response: response, next: waitForResponse(messages)}) {
+				break
+			}
+
+			var err error
+			for {
+				var event Event
+				event, err = reader.Next()
+				if err != nil {
+					break
+				}
+				event.ReceivedAt = time.Now()
+				if !send(OnEventMsg{Call: call, Event: event, next: waitForResponse(messages)}) {
+					break
+				}
+			}
+			stop()
+			response.Body.Close()
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			// the server closed the stream, reconnect after the retry delay
+			for {
+				if !send(OnEventStreamClosedMsg{Call: call, Err: err, Reconnecting: true, next: waitForResponse(messages)}) {
+					break
+				}
+				select {
+				case <-ctx.Done():
+				case <-time.After(reader.Retry):
+				}
+				if ctx.Err() != nil {
+					break
+				}
+
+				params := call.RequestParams()
+				params.Context = ctx
+				if reader.LastEventID != "" {
+					params.Headers["Last-Event-ID"] = reader.LastEventID
+				}
+				response, err = utils.MakeRequest(params)
+				if err == nil && isEventStream(response) {
+					break
+				}
+				if err == nil {
+					response.Body.Close()
+					err = fmt.Errorf("unexpected response: %s", response.Status)
+				}
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}()
+
+	return messages
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventReader(t *testing.T) {
+	stream := ": comment\n" +
+		"retry: 1500\n" +
+		"data: first\n\n" +
+		"event: update\r\n" +
+		"id: 42\r\n" +
+		"data: line 1\r\n" +
+		"data:line 2\r\n\r\n" +
+		"data: without id change\n\n" +
+		"event: ignored\n\n" +
+		"data: last"
+
+	reader := NewEventReader(strings.NewReader(stream))
+
+	want := []Event{
+		{Name: "message", Data: "first"},
+		{Name: "update", ID: "42", Data: "line 1\nline 2"},
+		{Name: "message", ID: "42", Data: "without id change"},
+	}
+	for i, w := range want {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Next() #%d error = %v", i, err)
+		}
+		if got != w {
+			t.Errorf("Next() #%d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	// the last event is not terminated by an empty line, so it is dropped
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the stream, got %v", err)
+	}
+
+	if reader.Retry != 1500*time.Millisecond {
+		t.Errorf("Expected retry to be 1.5s, got %v", reader.Retry)
+	}
+}
+
+func TestStreamEventsReconnects(t *testing.T) {
+	lastEventIDs := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 10\nid: 7\ndata: hello\n\n")
+	}))
+	defer server.Close()
+
+	call := NewCall()
+	call.Url = server.URL
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEventStream(response) {
+		t.Fatalf("Expected response to be detected as event stream")
+	}
+
+	messages := GetInstance().streamEvents(startRequest(call), call, response)
+	defer GetInstance().StopStream(call)
+
+	expect := func(check func(msg interface{}) bool) {
+		select {
+		case msg := <-messages:
+			if !check(msg) {
+				t.Fatalf("Unexpected message %#v", msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+	}
+
+	expect(func(msg interface{}) bool { _, ok := msg.(OnEventStreamMsg); return ok })
+	expect(func(msg interface{}) bool { m, ok := msg.(OnEventMsg); return ok && m.Event.Data == "hello" })
+	expect(func(msg interface{}) bool { m, ok := msg.(OnEventStreamClosedMsg); return ok && m.Reconnecting })
+	expect(func(msg interface{}) bool { _, ok := msg.(OnEventStreamMsg); return ok })
+
+	<-lastEventIDs
+	if id := <-lastEventIDs; id != "7" {
+		t.Errorf("Expected Last-Event-ID to be 7 on reconnect, got %q", id)
+	}
+}
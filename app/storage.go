@@ -0,0 +1,197 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CollectionsVersion is the version of the schema of collections.json,
+// older files are migrated when they are read
+const CollectionsVersion = 2
+
+// backupCount is the number of backups of collections.json kept next to it,
+// collections.json.1 being the newest
+const backupCount = 5
+
+// errNewerSchema is returned for collections.json written by a newer
+// version of restman
+var errNewerSchema = errors.New("schema version is newer than the supported one, update restman")
+
+// storedCollections is the content of collections.json
+type storedCollections struct {
+	Version     int          `json:"version"`
+	Collections []Collection `json:"collections"`
+}
+
+// migrations upgrade collections.json from the version they are keyed by to
+// the next one. Add one whenever the schema changes in a way older files
+// cannot be read with.
+var migrations = map[int]func(data []byte) ([]byte, error){
+	// version 1 is a bare list of collections
+	1: func(data []byte) ([]byte, error) {
+		return json.Marshal(map[string]any{"version": 2, "collections": json.RawMessage(data)})
+	},
+}
+
+func collectionsPath() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "restman", "collections.json")
+}
+
+// schemaVersion returns the version of the content of collections.json
+func schemaVersion(data []byte) (int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return 1, nil
+	}
+	stored := struct {
+		Version int `json:"version"`
+	}{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return 0, err
+	}
+	if stored.Version < 1 {
+		return 0, fmt.Errorf("missing schema version")
+	}
+	return stored.Version, nil
+}
+
+// decodeCollections reads the content of collections.json, migrating it to
+// the current schema version first
+func decodeCollections(data []byte) ([]Collection, error) {
+	version, err := schemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > CollectionsVersion {
+		return nil, fmt.Errorf("%w (%d > %d)", errNewerSchema, version, CollectionsVersion)
+	}
+	for ; version < CollectionsVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from schema version %d", version)
+		}
+		if data, err = migrate(data); err != nil {
+			return nil, fmt.Errorf("migrating from schema version %d: %w", version, err)
+		}
+	}
+
+	stored := storedCollections{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Collections == nil {
+		stored.Collections = []Collection{}
+	}
+	return stored.Collections, nil
+}
+
+// readCollectionsFile reads the global collections. When collections.json
+// cannot be read the newest backup which can is used, the error tells which.
+// Without a usable backup, or when it was written by a newer restman,
+// collections.json is left untouched and writing is refused to not lose the
+// collections in it.
+func (a *App) readCollectionsFile() ([]Collection, error) {
+	path := collectionsPath()
+	a.storageErr = nil
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Collection{}, nil
+	}
+	if err == nil {
+		collections, decodeErr := decodeCollections(data)
+		if decodeErr == nil {
+			return collections, nil
+		}
+		err = decodeErr
+	}
+	err = fmt.Errorf("%s cannot be read: %w", path, err)
+
+	for i := 1; i <= backupCount && !errors.Is(err, errNewerSchema); i++ {
+		backup := fmt.Sprintf("%s.%d", path, i)
+		data, backupErr := os.ReadFile(backup)
+		if backupErr != nil {
+			continue
+		}
+		if collections, backupErr := decodeCollections(data); backupErr == nil {
+			return collections, fmt.Errorf("%w, restored from %s", err, filepath.Base(backup))
+		}
+	}
+
+	a.storageErr = fmt.Errorf("%w, it is not written until it is fixed", err)
+	return []Collection{}, a.storageErr
+}
+
+// writeCollectionsFile stores the global collections, the previous content
+// of collections.json is kept as a backup
+func (a *App) writeCollectionsFile(collections []Collection) error {
+	if a.storageErr != nil {
+		return a.storageErr
+	}
+	data, err := json.MarshalIndent(storedCollections{Version: CollectionsVersion, Collections: collections}, "", " ")
+	if err != nil {
+		return err
+	}
+
+	path := collectionsPath()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if err := rotateBackups(path, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// rotateBackups copies the file to its first backup, shifting the older
+// ones, unless its content is the one about to be written
+func rotateBackups(path string, data []byte) error {
+	current, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || bytes.Equal(current, data) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", path, backupCount))
+	for i := backupCount - 1; i >= 1; i-- {
+		// backups written before they were private are made so
+		older := fmt.Sprintf("%s.%d", path, i+1)
+		if os.Rename(fmt.Sprintf("%s.%d", path, i), older) == nil {
+			os.Chmod(older, 0600)
+		}
+	}
+	return writeFileAtomic(path+".1", current, 0600)
+}
+
+// writeFileAtomic writes the file through a temporary file renamed over it,
+// so it holds either the old or the new content when writing fails midway
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
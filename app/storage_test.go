@@ -0,0 +1,113 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateConfig points the config and home directories of the test to a
+// temporary directory. os.UserConfigDir reads XDG_CONFIG_HOME on Linux, HOME
+// on macOS and AppData on Windows.
+func isolateConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, ".config"))
+	t.Setenv("AppData", filepath.Join(dir, "AppData"))
+}
+
+func TestDecodeCollectionsMigrates(t *testing.T) {
+	collections, err := decodeCollections([]byte(`[{"id": "a", "name": "legacy", "calls": []}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 1 || collections[0].Name != "legacy" {
+		t.Errorf("decodeCollections() = %+v", collections)
+	}
+
+	if _, err := decodeCollections([]byte(`{"version": 99, "collections": []}`)); err == nil {
+		t.Errorf("Expected error for a newer schema version")
+	}
+	if _, err := decodeCollections([]byte(`{"collections": []}`)); err == nil {
+		t.Errorf("Expected error for a missing schema version")
+	}
+}
+
+func TestWriteCollectionsFile(t *testing.T) {
+	isolateConfig(t)
+	a := &App{}
+
+	for i := 0; i < backupCount+2; i++ {
+		collections := []Collection{{ID: "a", Name: strings.Repeat("a", i+1), Calls: []Call{}}}
+		if err := a.writeCollectionsFile(collections); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// writing the same collections again keeps the backups
+	if err := a.writeCollectionsFile([]Collection{{ID: "a", Name: strings.Repeat("a", backupCount+2), Calls: []Call{}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(collectionsPath() + ".1")
+	if version, _ := schemaVersion(data); version != CollectionsVersion || !strings.Contains(string(data), `"`+strings.Repeat("a", backupCount+1)+`"`) {
+		t.Errorf("Unexpected newest backup %s", data)
+	}
+	if _, err := os.Stat(collectionsPath() + ".5"); err != nil {
+		t.Errorf("Expected %d backups: %v", backupCount, err)
+	}
+	if _, err := os.Stat(collectionsPath() + ".6"); !os.IsNotExist(err) {
+		t.Errorf("Expected only %d backups, got %v", backupCount, err)
+	}
+	entries, _ := os.ReadDir(strings.TrimSuffix(collectionsPath(), "collections.json"))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Expected temporary file to be removed, got %s", entry.Name())
+		}
+	}
+}
+
+func TestReadCollectionsFileRestoresBackup(t *testing.T) {
+	isolateConfig(t)
+	a := &App{}
+
+	if collections, err := a.readCollectionsFile(); err != nil || len(collections) != 0 {
+		t.Errorf("readCollectionsFile() = %+v, %v, want no collections", collections, err)
+	}
+
+	a.writeCollectionsFile([]Collection{{ID: "a", Name: "saved", Calls: []Call{}}})
+	a.writeCollectionsFile([]Collection{{ID: "a", Name: "newer", Calls: []Call{}}})
+	os.WriteFile(collectionsPath(), []byte(`{"version": 2, "collec`), 0644)
+
+	collections, err := a.readCollectionsFile()
+	if err == nil || !strings.Contains(err.Error(), "collections.json.1") {
+		t.Errorf("Expected error telling the backup was restored, got %v", err)
+	}
+	if len(collections) != 1 || collections[0].Name != "saved" {
+		t.Errorf("readCollectionsFile() = %+v", collections)
+	}
+	if err := a.writeCollectionsFile(collections); err != nil {
+		t.Errorf("Expected restored collections to be written, got %v", err)
+	}
+}
+
+func TestReadCollectionsFileRefusesWrites(t *testing.T) {
+	isolateConfig(t)
+	a := &App{}
+	a.writeCollectionsFile([]Collection{})
+	// the backup of an older version is not restored over the newer file
+	a.writeCollectionsFile([]Collection{{ID: "a", Name: "older", Calls: []Call{}}})
+	os.WriteFile(collectionsPath(), []byte(`{"version": 3, "collections": []}`), 0644)
+
+	if _, err := a.readCollectionsFile(); err == nil {
+		t.Fatalf("Expected error for a newer schema version")
+	}
+	if err := a.writeCollectionsFile([]Collection{}); err == nil {
+		t.Errorf("Expected writes to be refused")
+	}
+	if data, _ := os.ReadFile(collectionsPath()); string(data) != `{"version": 3, "collections": []}` {
+		t.Errorf("Expected collections.json to be left untouched, got %s", data)
+	}
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"restman/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Resolution tells which changes win when a collection or call was changed
+// both in memory and on disk, e.g. by another restman or an editor
+type Resolution int
+
+const (
+	// ResolveAsk reports conflicts instead of saving
+	ResolveAsk Resolution = iota
+	KeepMine
+	TakeTheirs
+)
+
+// snapshot holds the hashes of the collections as they were last read from
+// or written to disk, it is the base changes on both sides are found from
+type snapshot struct {
+	// hash of all collections, their order included
+	hash        string
+	collections map[string]collectionSnapshot
+}
+
+type collectionSnapshot struct {
+	settings string
+	calls    map[string]string
+}
+
+// collectionKey identifies a collection, collections created by the
+// recorder before ids were set only have a name
+func collectionKey(c Collection) string {
+	if c.ID == "" {
+		return "name:" + c.Name
+	}
+	return c.ID
+}
+
+// settingsHash is the hash of a collection without its calls
+func settingsHash(c Collection) string {
+	c.Calls = nil
+	return utils.ComputeHash(c)
+}
+
+func takeSnapshot(collections []Collection) snapshot {
+	s := snapshot{hash: utils.ComputeHash(collections), collections: map[string]collectionSnapshot{}}
+	for _, collection := range collections {
+		calls := map[string]string{}
+		for _, call := range collection.Calls {
+			calls[call.ID] = utils.ComputeHash(call)
+		}
+		s.collections[collectionKey(collection)] = collectionSnapshot{settings: settingsHash(collection), calls: calls}
+	}
+	return s
+}
+
+// mergeItem picks the version of a collection or call, given by the hashes
+// of its base, mine and theirs, empty when it does not exist there. It
+// returns whether mine is kept, whether the item is kept at all and whether
+// both sides changed it differently.
+func mergeItem(base, mine, theirs string, resolution Resolution) (keepMine bool, keep bool, conflict bool) {
+	switch {
+	case mine == theirs:
+		return true, mine != "", false
+	case mine == base:
+		return false, theirs != "", false
+	case theirs == base:
+		return true, mine != "", false
+	}
+	if resolution == TakeTheirs {
+		return false, theirs != "", true
+	}
+	return true, mine != "", true
+}
+
+// mergeCollections merges the changes made on disk since base into the
+// collections in memory. Collections and calls are matched by id, changes
+// to different ones never conflict. Conflicts are resolved by the
+// resolution, mine win for ResolveAsk, and their names are returned.
+func mergeCollections(base snapshot, mine []Collection, theirs []Collection, resolution Resolution) ([]Collection, []string) {
+	conflicts := []string{}
+	mineByKey := map[string]Collection{}
+	for _, collection := range mine {
+		mineByKey[collectionKey(collection)] = collection
+	}
+
+	merged := []Collection{}
+	mergeCollection := func(key string, m *Collection, t *Collection) {
+		b, inBase := base.collections[key]
+
+		// a collection removed on one side conflicts with changes to it on
+		// the other side
+		if m == nil || t == nil {
+			present := m
+			if present == nil {
+				present = t
+			}
+			if inBase && unchanged(*present, b) {
+				return
+			}
+			if inBase {
+				conflicts = append(conflicts, present.Name)
+				if (resolution == TakeTheirs) == (t == nil) {
+					return
+				}
+			}
+			collection := *present
+			collection.Calls = append([]Call{}, present.Calls...)
+			for i := range collection.Calls {
+				collection.Calls[i].hash = utils.ComputeHash(collection.Calls[i])
+			}
+			merged = append(merged, collection)
+			return
+		}
+
+		keepMine, _, conflict := mergeItem(b.settings, settingsHash(*m), settingsHash(*t), resolution)
+		collection := *t
+		if keepMine {
+			collection = *m
+		}
+		if conflict {
+			conflicts = append(conflicts, collection.Name)
+		}
+		calls, callConflicts := mergeCalls(b.calls, m.Calls, t.Calls, resolution)
+		conflicts = append(conflicts, callConflicts...)
+		collection.Calls = calls
+		merged = append(merged, collection)
+	}
+
+	// the order on disk wins, collections only in memory follow
+	seen := map[string]bool{}
+	for i := range theirs {
+		key := collectionKey(theirs[i])
+		seen[key] = true
+		if m, ok := mineByKey[key]; ok {
+			mergeCollection(key, &m, &theirs[i])
+		} else {
+			mergeCollection(key, nil, &theirs[i])
+		}
+	}
+	for i := range mine {
+		if key := collectionKey(mine[i]); !seen[key] {
+			mergeCollection(key, &mine[i], nil)
+		}
+	}
+	return merged, conflicts
+}
+
+// mergeCalls merges the calls of a collection like mergeCollections merges
+// collections, the names of the calls in conflict are returned
+func mergeCalls(base map[string]string, mineCalls []Call, theirsCalls []Call, resolution Resolution) ([]Call, []string) {
+	mineByID := map[string]Call{}
+	for _, call := range mineCalls {
+		mineByID[call.ID] = call
+	}
+
+	calls, conflicts := []Call{}, []string{}
+	mergeCall := func(m *Call, t *Call) {
+		mineHash, theirsHash := "", ""
+		id := ""
+		if m != nil {
+			mineHash, id = utils.ComputeHash(*m), m.ID
+		}
+		if t != nil {
+			theirsHash, id = utils.ComputeHash(*t), t.ID
+		}
+		keepMine, keep, conflict := mergeItem(base[id], mineHash, theirsHash, resolution)
+		if !keep {
+			return
+		}
+		call := *t
+		if keepMine {
+			call = *m
+		}
+		if conflict {
+			conflicts = append(conflicts, call.Title())
+		}
+		// the merged calls are the saved ones
+		call.hash = utils.ComputeHash(call)
+		calls = append(calls, call)
+	}
+
+	seen := map[string]bool{}
+	for i := range theirsCalls {
+		seen[theirsCalls[i].ID] = true
+		if m, ok := mineByID[theirsCalls[i].ID]; ok {
+			mergeCall(&m, &theirsCalls[i])
+		} else {
+			mergeCall(nil, &theirsCalls[i])
+		}
+	}
+	for i := range mineCalls {
+		if !seen[mineCalls[i].ID] {
+			mergeCall(&mineCalls[i], nil)
+		}
+	}
+	return calls, conflicts
+}
+
+// unchanged reports whether the collection is the one of the snapshot
+func unchanged(collection Collection, base collectionSnapshot) bool {
+	if settingsHash(collection) != base.settings || len(collection.Calls) != len(base.calls) {
+		return false
+	}
+	for _, call := range collection.Calls {
+		if base.calls[call.ID] != utils.ComputeHash(call) {
+			return false
+		}
+	}
+	return true
+}
+
+// readDisk reads the collections as they are on disk now
+func (a *App) readDisk() ([]Collection, error) {
+	collections := []Collection{}
+	data, err := os.ReadFile(collectionsPath())
+	if err == nil {
+		collections, err = decodeCollections(data)
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.workspace != "" {
+		shared, err := a.loadWorkspace()
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, shared...)
+	}
+	return collections, nil
+}
+
+// syncCollections merges the collections on disk into the ones in memory
+// and writes the result, holding the lock on the collections meanwhile.
+// Nothing changes when there are conflicts to ask about. When reloading,
+// collections which cannot be read are left alone as an editor may be
+// writing them, and nothing is sent when they did not change.
+func (a *App) syncCollections(resolution Resolution, reload bool) tea.Msg {
+	unlock, err := lockCollections()
+	if err != nil {
+		return FetchCollectionsSuccessMsg{Collections: a.Collections, Err: err}
+	}
+	defer unlock()
+
+	merged := a.Collections
+	theirs, err := a.readDisk()
+	switch {
+	case err != nil && reload:
+		return FetchCollectionsSuccessMsg{Collections: a.Collections, Err: err}
+	case err == nil:
+		if reload && takeSnapshot(theirs).hash == a.base.hash {
+			return nil
+		}
+		var conflicts []string
+		merged, conflicts = mergeCollections(a.base, a.Collections, theirs, resolution)
+		if len(conflicts) > 0 && resolution == ResolveAsk {
+			return CollectionsConflictMsg{Names: conflicts}
+		}
+	}
+	// credentials go to the secrets store, or are left out of the files
+	// while it is locked
+	sealed, sealErr := a.sealSecrets(merged)
+	if sealErr != nil {
+		return FetchCollectionsSuccessMsg{Collections: a.Collections, Err: sealErr}
+	}
+
+	a.setCollections(merged)
+	stored := a.storable(merged)
+	// a reload only writes back the changes which were not on disk yet
+	if !reload || takeSnapshot(stored).hash != takeSnapshot(theirs).hash {
+		err = a.writeCollections()
+	}
+	if err == nil {
+		a.base = takeSnapshot(stored)
+	}
+	msg := FetchCollectionsSuccessMsg{Collections: a.Collections, Err: err}
+	if !sealed && err == nil {
+		msg.Notice = NoticeSecretsLocked
+	}
+	return msg
+}
+
+// setCollections replaces the collections, keeping the selected one
+func (a *App) setCollections(collections []Collection) {
+	selected := a.SelectedCollection
+	a.Collections = collections
+	if selected != nil {
+		a.SelectedCollection = a.findCollectionByID(selected.ID)
+	}
+}
+
+// ReloadCollections merges the collections changed on disk into the ones in
+// memory, CollectionsConflictMsg is sent when both changed the same ones
+func (a *App) ReloadCollections() tea.Cmd {
+	return func() tea.Msg {
+		return a.syncCollections(ResolveAsk, true)
+	}
+}
+
+// ResolveConflicts saves the collections, conflicting changes are resolved
+// by the resolution
+func (a *App) ResolveConflicts(resolution Resolution) tea.Cmd {
+	return func() tea.Msg {
+		return a.syncCollections(resolution, false)
+	}
+}
+
+// lockCollections takes the advisory lock coordinating the restman
+// instances writing the collections, it waits for the others to finish
+func lockCollections() (func(), error) {
+	path := filepath.Join(filepath.Dir(collectionsPath()), "collections.lock")
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func syncCollection(calls ...Call) Collection {
+	return Collection{ID: "api", Name: "api", Calls: calls}
+}
+
+func callNames(collections []Collection) map[string][]string {
+	names := map[string][]string{}
+	for _, collection := range collections {
+		names[collection.Name] = []string{}
+		for _, call := range collection.Calls {
+			names[collection.Name] = append(names[collection.Name], call.ID+":"+call.Url)
+		}
+	}
+	return names
+}
+
+func TestMergeCollections(t *testing.T) {
+	base := []Collection{syncCollection(
+		Call{ID: "a", Url: "/a"}, Call{ID: "b", Url: "/b"}, Call{ID: "d", Url: "/d"},
+	)}
+	mine := []Collection{syncCollection(
+		Call{ID: "a", Url: "/a-mine"}, Call{ID: "b", Url: "/b"}, Call{ID: "d", Url: "/d"},
+	), {ID: "new", Name: "new", Calls: []Call{}}}
+	theirs := []Collection{syncCollection(
+		Call{ID: "a", Url: "/a"}, Call{ID: "b", Url: "/b-theirs"}, Call{ID: "c", Url: "/c"},
+	)}
+
+	merged, conflicts := mergeCollections(takeSnapshot(base), mine, theirs, ResolveAsk)
+	if len(conflicts) != 0 {
+		t.Errorf("Unexpected conflicts %v", conflicts)
+	}
+	want := map[string][]string{"api": {"a:/a-mine", "b:/b-theirs", "c:/c"}, "new": {}}
+	if got := callNames(merged); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeCollections() = %v, want %v", got, want)
+	}
+	if merged[0].Calls[1].WasChanged() {
+		t.Errorf("Expected merged calls to be saved")
+	}
+}
+
+func TestMergeCollectionsConflicts(t *testing.T) {
+	base := takeSnapshot([]Collection{syncCollection(Call{ID: "a", Url: "/a"}), {ID: "old", Name: "old", Calls: []Call{{ID: "x"}}}})
+	mine := []Collection{syncCollection(Call{ID: "a", Url: "/a-mine"})}
+	// the old collection was removed here and a call was added to it on disk
+	theirs := []Collection{syncCollection(Call{ID: "a", Url: "/a-theirs"}), {ID: "old", Name: "old", Calls: []Call{{ID: "x"}, {ID: "y"}}}}
+
+	_, conflicts := mergeCollections(base, mine, theirs, ResolveAsk)
+	if len(conflicts) != 2 {
+		t.Errorf("Expected a conflict for the call and the collection, got %v", conflicts)
+	}
+
+	merged, _ := mergeCollections(base, mine, theirs, KeepMine)
+	if want := map[string][]string{"api": {"a:/a-mine"}}; !reflect.DeepEqual(callNames(merged), want) {
+		t.Errorf("mergeCollections(KeepMine) = %v, want %v", callNames(merged), want)
+	}
+	merged, _ = mergeCollections(base, mine, theirs, TakeTheirs)
+	if want := map[string][]string{"api": {"a:/a-theirs"}, "old": {"x:", "y:"}}; !reflect.DeepEqual(callNames(merged), want) {
+		t.Errorf("mergeCollections(TakeTheirs) = %v, want %v", callNames(merged), want)
+	}
+}
+
+func TestSyncCollections(t *testing.T) {
+	isolateConfig(t)
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(cwd)
+
+	// two restman instances sharing the collections
+	first, second := &App{}, &App{}
+	first.LoadCollections()
+	second.LoadCollections()
+
+	first.Collections = append(first.Collections, syncCollection(Call{ID: "a", Name: "get a", Url: "/a"}))
+	first.SaveCollections()()
+	second.Collections = append(second.Collections, Collection{ID: "other", Name: "other", Calls: []Call{}})
+	if msg := second.SaveCollections()().(FetchCollectionsSuccessMsg); msg.Err != nil || len(msg.Collections) != 2 {
+		t.Fatalf("Expected the collections of both to be saved, got %+v", msg)
+	}
+
+	if msg := first.ReloadCollections()(); msg == nil || len(first.Collections) != 2 {
+		t.Errorf("Expected the collection of the other instance to be reloaded, got %+v", first.Collections)
+	}
+	if msg := first.ReloadCollections()(); msg != nil {
+		t.Errorf("Expected nothing to be reloaded without changes, got %+v", msg)
+	}
+
+	first.Collections[0].Calls[0].Url = "/first"
+	first.SaveCollections()()
+	second.Collections[0].Calls[0].Url = "/second"
+	msg, ok := second.SaveCollections()().(CollectionsConflictMsg)
+	if !ok || !reflect.DeepEqual(msg.Names, []string{"get a"}) {
+		t.Fatalf("Expected conflict for the call, got %+v", msg)
+	}
+
+	second.ResolveConflicts(TakeTheirs)()
+	if url := second.Collections[0].Calls[0].Url; url != "/first" {
+		t.Errorf("Expected the changes on disk to win, got %q", url)
+	}
+	disk, _ := second.readDisk()
+	if url := disk[0].Calls[0].Url; url != "/first" {
+		t.Errorf("Expected the resolved collections to be written, got %q", url)
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openTab is a request tab saved when restman quits, the call is stored
+// with its unsaved edits
+type openTab struct {
+	Call     Call `json:"call"`
+	Modified bool `json:"modified,omitempty"`
+}
+
+type openTabs struct {
+	Tabs   []openTab `json:"tabs"`
+	Active int       `json:"active"`
+}
+
+func openTabsPath() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "restman", "tabs.json")
+}
+
+// SaveOpenTabs stores the calls of the open request tabs to be restored on
+// the next start, nil calls are empty tabs
+func (a *App) SaveOpenTabs(calls []*Call, active int) error {
+	saved := openTabs{Tabs: []openTab{}, Active: active}
+	for _, call := range calls {
+		tab := openTab{Call: Call{}}
+		if call != nil {
+			tab = openTab{Call: *call, Modified: call.WasChanged()}
+			// the saved call holds references to the secrets already
+			if stored := a.findCall(call.ID); stored != nil && !tab.Modified {
+				tab.Call = *stored
+			}
+			tab.Call = a.sealTab(tab.Call)
+		}
+		saved.Tabs = append(saved.Tabs, tab)
+	}
+	data, err := json.MarshalIndent(saved, "", " ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(openTabsPath()), os.ModePerm)
+	return writeFileAtomic(openTabsPath(), data, 0644)
+}
+
+// ReadOpenTabs returns the calls of the request tabs open when restman
+// quit. Calls of a collection are read from it again, unless they had
+// unsaved edits, which are kept and still marked as changed.
+func (a *App) ReadOpenTabs() ([]*Call, int) {
+	data, err := os.ReadFile(openTabsPath())
+	if err != nil {
+		return nil, 0
+	}
+	saved := openTabs{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, 0
+	}
+
+	calls := []*Call{}
+	for _, tab := range saved.Tabs {
+		if tab.Call.ID == "" {
+			calls = append(calls, nil)
+			continue
+		}
+		call := tab.Call
+		if stored := a.findCall(call.ID); stored != nil {
+			if tab.Modified {
+				call.hash = stored.hash
+			} else {
+				call = *stored
+			}
+		}
+		calls = append(calls, &call)
+	}
+	return calls, max(min(saved.Active, len(calls)-1), 0)
+}
+
+// findCall returns a copy of the call with the id from the collections
+func (a *App) findCall(id string) *Call {
+	for _, collection := range a.Collections {
+		for _, call := range collection.Calls {
+			if call.ID == id {
+				return &call
+			}
+		}
+	}
+	return nil
+}
+
+// MessageCall returns the call a response message belongs to, or nil for
+// other messages
+func MessageCall(msg tea.Msg) *Call {
+	switch msg := msg.(type) {
+	case OnLoadingMsg:
+		return msg.Call
+	case OnResponseMsg:
+		return msg.Call
+	case OnProgressMsg:
+		return msg.Call
+	case OnEventStreamMsg:
+		return msg.Call
+	case OnEventMsg:
+		return msg.Call
+	case OnEventStreamClosedMsg:
+		return msg.Call
+	case OnWebSocketOpenMsg:
+		return msg.Call
+	case OnFrameMsg:
+		return msg.Call
+	case OnWebSocketClosedMsg:
+		return msg.Call
+	case OnGRPCMessageMsg:
+		return msg.Call
+	case OnGRPCStatusMsg:
+		return msg.Call
+	}
+	return nil
+}
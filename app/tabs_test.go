@@ -0,0 +1,48 @@
+package app
+
+import (
+	"restman/utils"
+	"testing"
+)
+
+func TestOpenTabs(t *testing.T) {
+	isolateConfig(t)
+
+	saved := Call{ID: "saved", Name: "saved", Url: "http://localhost/a"}
+	saved.hash = utils.ComputeHash(saved)
+	edited := Call{ID: "edited", Name: "edited", Url: "http://localhost/b"}
+	edited.hash = utils.ComputeHash(edited)
+	a := &App{Collections: []Collection{{Name: "test", Calls: []Call{saved, edited}}}}
+
+	changed := edited
+	changed.Url = "http://localhost/c"
+	if err := a.SaveOpenTabs([]*Call{&saved, nil, &changed}, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the saved call changes in the collection meanwhile
+	a.Collections[0].Calls[0].Url = "http://localhost/new"
+
+	calls, active := a.ReadOpenTabs()
+	if len(calls) != 3 || active != 2 {
+		t.Fatalf("ReadOpenTabs() = %v, %d", calls, active)
+	}
+	if calls[0].Url != "http://localhost/new" {
+		t.Errorf("Expected unchanged tab to be read from the collection, got %q", calls[0].Url)
+	}
+	if calls[1] != nil {
+		t.Errorf("Expected empty tab, got %+v", calls[1])
+	}
+	if calls[2].Url != "http://localhost/c" || !calls[2].WasChanged() {
+		t.Errorf("Expected edits to be kept and marked as changed, got %q", calls[2].Url)
+	}
+}
+
+func TestReadOpenTabsMissing(t *testing.T) {
+	isolateConfig(t)
+
+	calls, active := (&App{}).ReadOpenTabs()
+	if len(calls) != 0 || active != 0 {
+		t.Errorf("ReadOpenTabs() = %v, %d, want no tabs", calls, active)
+	}
+}
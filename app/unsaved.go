@@ -0,0 +1,32 @@
+package app
+
+// HasUnsavedChanges reports whether the call is saved in a collection and
+// was edited since
+func (a *App) HasUnsavedChanges(call *Call) bool {
+	return call != nil && call.WasChanged() && a.findCall(call.ID) != nil
+}
+
+// RevertCall restores the call as it is saved in its collection, it reports
+// whether the call was found
+func (a *App) RevertCall(call *Call) bool {
+	saved := a.findCall(call.ID)
+	if saved == nil {
+		return false
+	}
+	*call = *saved
+	return true
+}
+
+// SetUnsavedCalls marks the calls whose edits are not saved yet, the
+// collections list shows them as dirty
+func (a *App) SetUnsavedCalls(calls []*Call) {
+	a.unsaved = map[string]bool{}
+	for _, call := range calls {
+		a.unsaved[call.ID] = true
+	}
+}
+
+// IsUnsaved reports whether the call with the id has unsaved edits
+func (a *App) IsUnsaved(id string) bool {
+	return a.unsaved[id]
+}
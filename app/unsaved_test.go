@@ -0,0 +1,33 @@
+package app
+
+import (
+	"restman/utils"
+	"testing"
+)
+
+func TestRevertCall(t *testing.T) {
+	saved := Call{ID: "saved", Url: "http://localhost/a"}
+	saved.hash = utils.ComputeHash(saved)
+	a := &App{Collections: []Collection{{Name: "test", Calls: []Call{saved}}}}
+
+	call := saved
+	if a.HasUnsavedChanges(&call) {
+		t.Errorf("Expected saved call to have no unsaved changes")
+	}
+
+	call.Url = "http://localhost/b"
+	if !a.HasUnsavedChanges(&call) {
+		t.Errorf("Expected edited call to have unsaved changes")
+	}
+
+	if !a.RevertCall(&call) || call.Url != "http://localhost/a" || a.HasUnsavedChanges(&call) {
+		t.Errorf("Expected call to be reverted, got %q", call.Url)
+	}
+
+	// calls which are not in a collection cannot be saved or reverted
+	adhoc := NewCall()
+	adhoc.Url = "http://localhost/c"
+	if a.HasUnsavedChanges(adhoc) || a.RevertCall(adhoc) {
+		t.Errorf("Expected call without collection to be left alone")
+	}
+}
@@ -0,0 +1,535 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"restman/utils"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+)
+
+const (
+	SCOPE_COLLECTION  = "collection"
+	SCOPE_ENVIRONMENT = "environment"
+)
+
+const (
+	EXTRACT_JSONPATH = "jsonpath"
+	EXTRACT_HEADER   = "header"
+	EXTRACT_COOKIE   = "cookie"
+	EXTRACT_REGEX    = "regex"
+)
+
+// Environment is a named set of variables, e.g. staging or production.
+// Environment variables take precedence over collection variables.
+type Environment struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+}
+
+// Extraction stores a value of a successful response into a variable,
+// written as a single line, e.g.
+//
+//	set token = jsonpath $.access_token
+//	set env.session = cookie SESSION
+//	set request_id = header X-Request-Id
+//	set id = regex "id=(\d+)"
+type Extraction struct {
+	Variable   string `json:"variable"`
+	Scope      string `json:"scope,omitempty"`
+	Source     string `json:"source"`
+	Expression string `json:"expression"`
+}
+
+func (e Extraction) String() string {
+	variable := e.Variable
+	if e.Scope == SCOPE_ENVIRONMENT {
+		variable = "env." + variable
+	}
+	return fmt.Sprintf("set %s = %s %s", variable, e.Source, e.Expression)
+}
+
+var variableNameReg = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ParseExtraction reads an extraction from its single line form
+func ParseExtraction(line string) (Extraction, error) {
+	var e Extraction
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "set ")
+	if !ok {
+		return e, fmt.Errorf("extractions start with set")
+	}
+	variable, rest, ok := strings.Cut(rest, "=")
+	if !ok {
+		return e, fmt.Errorf("expected set <variable> = <source> <expression>")
+	}
+
+	e.Variable = strings.TrimSpace(variable)
+	e.Scope = SCOPE_COLLECTION
+	if name, ok := strings.CutPrefix(e.Variable, "env."); ok {
+		e.Variable = name
+		e.Scope = SCOPE_ENVIRONMENT
+	}
+	if !variableNameReg.MatchString(e.Variable) {
+		return e, fmt.Errorf("invalid variable name %q", e.Variable)
+	}
+
+	e.Source, e.Expression = nextField(rest)
+	if e.Expression == "" {
+		return e, fmt.Errorf("expression is missing after %s", e.Source)
+	}
+	switch e.Source {
+	case EXTRACT_JSONPATH:
+		if _, err := utils.JSONPath(nil, e.Expression); err != nil {
+			return e, err
+		}
+	case EXTRACT_REGEX:
+		if _, err := regexp.Compile(trimQuotes(e.Expression)); err != nil {
+			return e, err
+		}
+	case EXTRACT_HEADER, EXTRACT_COOKIE:
+	default:
+		return e, fmt.Errorf("unknown source %q, use jsonpath, header, cookie or regex", e.Source)
+	}
+	return e, nil
+}
+
+// ParseTests reads the Tests tab of a call, lines starting with set are
+// extractions and every other line is an assertion. Errors are returned per
+// line number, starting at 1.
+func ParseTests(text string) ([]Assertion, []Extraction, map[int]error) {
+	assertions := []Assertion{}
+	extractions := []Extraction{}
+	errors := map[int]error{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "set ") {
+			extraction, err := ParseExtraction(line)
+			if err != nil {
+				errors[i+1] = err
+				continue
+			}
+			extractions = append(extractions, extraction)
+			continue
+		}
+		assertion, err := ParseAssertion(line)
+		if err != nil {
+			errors[i+1] = err
+			continue
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions, extractions, errors
+}
+
+// FormatTests writes assertions and extractions in the form read by ParseTests
+func FormatTests(assertions []Assertion, extractions []Extraction) string {
+	lines := []string{}
+	if len(assertions) > 0 {
+		lines = append(lines, FormatAssertions(assertions))
+	}
+	for _, e := range extractions {
+		lines = append(lines, e.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Extract returns the value selected by the extraction in a response
+func (e Extraction) Extract(response Response, cookies []*http.Cookie) (string, error) {
+	switch e.Source {
+	case EXTRACT_JSONPATH:
+		var document interface{}
+		if err := json.Unmarshal([]byte(response.Content()), &document); err != nil {
+			return "", fmt.Errorf("body is not JSON")
+		}
+		matches, err := utils.JSONPath(document, e.Expression)
+		if err != nil {
+			return "", err
+		}
+		if len(matches) == 0 {
+			return "", fmt.Errorf("%s not found", e.Expression)
+		}
+		// strings are stored without their quotes
+		if s, ok := matches[0].(string); ok {
+			return s, nil
+		}
+		return utils.FormatValue(matches[0]), nil
+
+	case EXTRACT_HEADER:
+		if values := response.Headers.Values(e.Expression); len(values) > 0 {
+			return values[0], nil
+		}
+		return "", fmt.Errorf("header %s is missing", e.Expression)
+
+	case EXTRACT_COOKIE:
+		for _, cookie := range cookies {
+			if cookie.Name == e.Expression {
+				return cookie.Value, nil
+			}
+		}
+		return "", fmt.Errorf("cookie %s is missing", e.Expression)
+
+	case EXTRACT_REGEX:
+		// hand edited collections skip the validation of ParseExtraction
+		pattern, err := regexp.Compile(trimQuotes(e.Expression))
+		if err != nil {
+			return "", err
+		}
+		match := pattern.FindStringSubmatch(response.Content())
+		if match == nil {
+			return "", fmt.Errorf("%s does not match", e.Expression)
+		}
+		// the first group if there is one, the whole match otherwise
+		return match[len(match)-1], nil
+	}
+	return "", fmt.Errorf("unknown source %q", e.Source)
+}
+
+// VariableChange is a variable set by an extraction or a script while a call
+// is sent. The responses are read in the background, so the caller applies
+// the changes with ApplyVariables.
+type VariableChange struct {
+	Scope string
+	Name  string
+	Value string
+	// environment holding the variable, the selected one when empty
+	Environment string
+}
+
+// variableScope holds the variables set on a call which are not applied yet,
+// they override the variables of the app for the call. A run shares one
+// between its calls, so later calls see the values extracted by earlier
+// ones without storing them.
+type variableScope struct {
+	// environment of a run, the selected one is used without
+	environment *Environment
+	// fields of the data file row of the running iteration
+	data    map[string]string
+	changes []VariableChange
+	// the changes before base were made before the scope was forked
+	base int
+}
+
+// forkScope returns a copy of the call recording the variables set on it,
+// on top of the ones set in its scope already
+func forkScope(call *Call) *Call {
+	forked := *call
+	forked.scope = &variableScope{}
+	if call.scope != nil {
+		forked.scope.environment = call.scope.environment
+		forked.scope.data = call.scope.data
+		forked.scope.changes = append([]VariableChange{}, call.scope.changes...)
+		forked.scope.base = len(call.scope.changes)
+	}
+	return &forked
+}
+
+// set records the changes, a call without scope drops them
+func (s *variableScope) set(changes ...VariableChange) {
+	if s == nil {
+		return
+	}
+	for _, change := range changes {
+		if change.Scope == SCOPE_ENVIRONMENT && change.Environment == "" && s.environment != nil {
+			change.Environment = s.environment.Name
+		}
+		s.changes = append(s.changes, change)
+	}
+}
+
+// recorded returns the changes made since the scope was forked
+func (s *variableScope) recorded() []VariableChange {
+	if s == nil {
+		return nil
+	}
+	return s.changes[s.base:]
+}
+
+// variables set while no collection or environment could hold them, they
+// live until restman exits
+var variables struct {
+	sync.Mutex
+	session map[string]string
+}
+
+// Variables returns the variables visible to the call. Fields of a data file
+// row override the variables set during a run, which override environment
+// variables, which override the variables of the folders of the call and of
+// its collection, which override the ones kept in the session.
+func (a *App) Variables(call *Call) map[string]string {
+	variables.Lock()
+	defer variables.Unlock()
+
+	merged := map[string]string{}
+	for k, v := range variables.session {
+		merged[k] = v
+	}
+	if collection := a.findCollection(call); collection != nil {
+		for k, v := range collection.Variables {
+			merged[k] = v
+		}
+		for _, folder := range collection.folderChain(call.Folder) {
+			for k, v := range folder.Variables {
+				merged[k] = v
+			}
+		}
+	}
+	environment := a.SelectedEnvironment
+	if call.scope != nil && call.scope.environment != nil {
+		environment = call.scope.environment
+	}
+	if environment != nil {
+		for k, v := range environment.Variables {
+			merged[k] = v
+		}
+	}
+	if call.scope != nil {
+		for _, change := range call.scope.changes {
+			merged[change.Name] = change.Value
+		}
+		for k, v := range call.scope.data {
+			merged[k] = v
+		}
+	}
+
+	// secrets are referenced as {{secret:name}}, in the values of variables
+	// too, e.g. to keep the token of an environment in the secrets store
+	stored := secretVariables()
+	for k, v := range merged {
+		merged[k] = utils.SubstituteVariables(v, stored)
+	}
+	for k, v := range stored {
+		merged[k] = v
+	}
+	return merged
+}
+
+// findCollection returns the collection holding the call, not a copy of it
+func (a *App) findCollection(call *Call) *Collection {
+	for i, c := range a.Collections {
+		for _, other := range c.Calls {
+			if other.ID == call.ID {
+				return &a.Collections[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyVariables stores the variables set on the call in their scope, the
+// environments and collections which changed are saved once. Without a
+// selected environment or a collection holding the call they are kept for
+// the session. It changes the app, so it runs on the UI side and not where
+// the response was read.
+func (a *App) ApplyVariables(call *Call, changes []VariableChange) tea.Cmd {
+	saveEnvironments, saveCollections := a.setVariables(call, changes)
+	cmds := []tea.Cmd{}
+	if saveEnvironments {
+		cmds = append(cmds, a.SaveEnvironments())
+	}
+	if saveCollections {
+		cmds = append(cmds, a.SaveCollections())
+	}
+	return tea.Batch(cmds...)
+}
+
+// setVariables stores the variables and reports whether the environments or
+// the collections have to be saved
+func (a *App) setVariables(call *Call, changes []VariableChange) (environments bool, collections bool) {
+	variables.Lock()
+	defer variables.Unlock()
+
+	for _, change := range changes {
+		environment := a.SelectedEnvironment
+		if change.Environment != "" {
+			environment = a.findEnvironment(change.Environment)
+		}
+		if change.Scope == SCOPE_ENVIRONMENT && environment != nil {
+			if environment.Variables == nil {
+				environment.Variables = map[string]string{}
+			}
+			environment.Variables[change.Name] = change.Value
+			environments = true
+			continue
+		}
+		if collection := a.findCollection(call); collection != nil && change.Scope == SCOPE_COLLECTION {
+			if collection.Variables == nil {
+				collection.Variables = map[string]string{}
+			}
+			collection.Variables[change.Name] = change.Value
+			collections = true
+			continue
+		}
+
+		if variables.session == nil {
+			variables.session = map[string]string{}
+		}
+		variables.session[change.Name] = change.Value
+	}
+	return environments, collections
+}
+
+// SaveVariables stores the variables set by the calls of a run and writes
+// them, for the commands which have no UI side. Runs only keep them in
+// memory otherwise.
+func (a *App) SaveVariables(results []RunResult) error {
+	saveEnvironments, saveCollections := false, false
+	for _, result := range results {
+		environments, collections := a.setVariables(&result.Call, result.Variables)
+		saveEnvironments = saveEnvironments || environments
+		saveCollections = saveCollections || collections
+	}
+
+	var errs []error
+	if saveEnvironments {
+		errs = append(errs, a.writeEnvironments())
+	}
+	if saveCollections {
+		if msg, ok := a.syncCollections(KeepMine, false).(FetchCollectionsSuccessMsg); ok {
+			errs = append(errs, msg.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyExtractions runs the extraction rules of the call against a
+// successful response, the values are set in the scope of the call
+func (a *App) applyExtractions(call *Call, response Response, cookies []*http.Cookie) {
+	if response.Status >= 400 {
+		return
+	}
+	for _, e := range call.Extractions {
+		value, err := e.Extract(response, cookies)
+		if err != nil {
+			continue
+		}
+		call.scope.set(VariableChange{Scope: e.Scope, Name: e.Variable, Value: value})
+	}
+}
+
+// trimQuotes removes the double quotes around a regular expression, escapes
+// like \d are kept as they are
+func trimQuotes(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func environmentsPath() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "restman", "environments.json")
+}
+
+// ReadEnvironments loads the environments and selects the one named in the
+// environment config key
+func (a *App) ReadEnvironments() {
+	file, err := os.ReadFile(environmentsPath())
+	if err != nil {
+		return
+	}
+	json.Unmarshal(file, &a.Environments)
+	a.SelectEnvironment(viper.GetString("environment"))
+}
+
+// SelectEnvironment selects the environment with the given name, an empty
+// or unknown name selects none
+func (a *App) SelectEnvironment(name string) {
+	a.SelectedEnvironment = a.findEnvironment(name)
+}
+
+// findEnvironment returns the environment with the given name, nil when
+// there is none
+func (a *App) findEnvironment(name string) *Environment {
+	var found *Environment
+	for i := range a.Environments {
+		if a.Environments[i].Name == name {
+			found = &a.Environments[i]
+		}
+	}
+	return found
+}
+
+// NextEnvironment cycles over the environments and no environment
+func (a *App) NextEnvironment() tea.Cmd {
+	next := 0
+	for i := range a.Environments {
+		if &a.Environments[i] == a.SelectedEnvironment {
+			next = i + 1
+		}
+	}
+	a.SelectedEnvironment = nil
+	if next < len(a.Environments) {
+		a.SelectedEnvironment = &a.Environments[next]
+	}
+	return func() tea.Msg {
+		return EnvironmentSelectedMsg{Environment: a.SelectedEnvironment}
+	}
+}
+
+func (a *App) SaveEnvironments() tea.Cmd {
+	data, _ := json.MarshalIndent(a.Environments, "", " ")
+	return func() tea.Msg {
+		_ = writeEnvironmentsFile(data)
+		return nil
+	}
+}
+
+// writeEnvironments stores the environments right away
+func (a *App) writeEnvironments() error {
+	data, err := json.MarshalIndent(a.Environments, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeEnvironmentsFile(data)
+}
+
+// writeEnvironmentsFile writes environments.json, only the user can read it
+// as variables often hold tokens
+func writeEnvironmentsFile(data []byte) error {
+	os.MkdirAll(filepath.Dir(environmentsPath()), os.ModePerm)
+	return writeFileAtomic(environmentsPath(), data, 0600)
+}
+
+// ParseVariablesText reads variables written as "name = value" lines, lines
+// without a name are skipped
+func ParseVariablesText(text string) map[string]string {
+	parsed := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		name, value, _ := strings.Cut(line, "=")
+		if name = strings.TrimSpace(name); name != "" {
+			parsed[name] = strings.TrimSpace(value)
+		}
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	return parsed
+}
+
+// VariablesText writes variables as sorted "name = value" lines
+func VariablesText(variables map[string]string) string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		lines = append(lines, name+" = "+variables[name])
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseExtraction(t *testing.T) {
+	tests := []struct {
+		line string
+		want Extraction
+		err  string
+	}{
+		{"set token = jsonpath $.access_token", Extraction{Variable: "token", Scope: SCOPE_COLLECTION, Source: "jsonpath", Expression: "$.access_token"}, ""},
+		{"set env.session = cookie SESSION", Extraction{Variable: "session", Scope: SCOPE_ENVIRONMENT, Source: "cookie", Expression: "SESSION"}, ""},
+		{"set id=header X-Request-Id", Extraction{Variable: "id", Scope: SCOPE_COLLECTION, Source: "header", Expression: "X-Request-Id"}, ""},
+		{`set id = regex "id=(\d+)"`, Extraction{Variable: "id", Scope: SCOPE_COLLECTION, Source: "regex", Expression: `"id=(\d+)"`}, ""},
+		{"set token jsonpath $.a", Extraction{}, "expected set"},
+		{"set a b = header X", Extraction{}, "invalid variable name"},
+		{"set token = xpath //a", Extraction{}, "unknown source"},
+		{"set token = jsonpath", Extraction{}, "expression is missing"},
+		{"set token = jsonpath items", Extraction{}, "path must start with $"},
+		{"set token = regex (", Extraction{}, "error parsing regexp"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseExtraction(test.line)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseExtraction(%q) error = %v, want %q", test.line, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseExtraction(%q) error = %v", test.line, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseExtraction(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+		if again, _ := ParseExtraction(got.String()); again != got {
+			t.Errorf("ParseExtraction(%q) does not round trip, got %+v", got.String(), again)
+		}
+	}
+}
+
+func TestParseTests(t *testing.T) {
+	assertions, extractions, errors := ParseTests("status == 200\nset token = jsonpath $.token\nset bad\n")
+	if len(assertions) != 1 || len(extractions) != 1 {
+		t.Errorf("ParseTests() = %v, %v, want one assertion and one extraction", assertions, extractions)
+	}
+	if len(errors) != 1 || errors[3] == nil {
+		t.Errorf("ParseTests() errors = %v, want an error on line 3", errors)
+	}
+	if got := FormatTests(assertions, extractions); got != "status == 200\nset token = jsonpath $.token" {
+		t.Errorf("FormatTests() = %q", got)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	response := Response{
+		Status:  200,
+		Headers: http.Header{"X-Request-Id": {"abc"}},
+		Body:    `{"token": "secret", "user": {"id": 42}, "next": "/items?id=7"}`,
+	}
+	cookies := []*http.Cookie{{Name: "SESSION", Value: "s1"}}
+
+	tests := []struct {
+		line string
+		want string
+		err  bool
+	}{
+		{"set v = jsonpath $.token", "secret", false},
+		{"set v = jsonpath $.user.id", "42", false},
+		{"set v = jsonpath $.missing", "", true},
+		{"set v = header x-request-id", "abc", false},
+		{"set v = header X-Missing", "", true},
+		{"set v = cookie SESSION", "s1", false},
+		{"set v = cookie OTHER", "", true},
+		{`set v = regex "id=(\d+)"`, "7", false},
+		{`set v = regex "items"`, "items", false},
+		{`set v = regex "nope"`, "", true},
+	}
+
+	for _, test := range tests {
+		extraction, err := ParseExtraction(test.line)
+		if err != nil {
+			t.Fatalf("ParseExtraction(%q) error = %v", test.line, err)
+		}
+		got, err := extraction.Extract(response, cookies)
+		if (err != nil) != test.err {
+			t.Errorf("%q error = %v, want error %v", test.line, err, test.err)
+		}
+		if got != test.want {
+			t.Errorf("%q = %q, want %q", test.line, got, test.want)
+		}
+	}
+
+	// hand edited collections skip the validation of ParseExtraction
+	invalid := Extraction{Variable: "v", Source: EXTRACT_REGEX, Expression: "("}
+	if _, err := invalid.Extract(response, cookies); err == nil {
+		t.Errorf("Extract() with an invalid pattern should fail")
+	}
+}
+
+func TestVariables(t *testing.T) {
+	isolateConfig(t)
+
+	a := GetInstance()
+	call := Call{ID: "call", Method: "GET", Url: "{{host}}/users/{{ id }}", Headers: Headers{{Key: "Authorization", Value: "Bearer {{token}}"}}}
+	a.Collections = []Collection{{ID: "c", Calls: []Call{call}, Variables: map[string]string{"host": "http://collection", "token": "t1"}}}
+	a.Environments = []Environment{{Name: "staging", Variables: map[string]string{"host": "http://staging"}}}
+	a.SelectedEnvironment = nil
+	t.Cleanup(func() {
+		a.Collections, a.Environments, a.SelectedEnvironment = nil, nil, nil
+		variables.session = nil
+	})
+
+	a.ApplyVariables(&Call{ID: "unsaved"}, []VariableChange{{Scope: SCOPE_COLLECTION, Name: "id", Value: "7"}})
+	if got := call.GetUrl(); got != "http://collection/users/7" {
+		t.Errorf("GetUrl() = %q", got)
+	}
+
+	a.SelectEnvironment("staging")
+	if got := call.GetUrl(); got != "http://staging/users/7" {
+		t.Errorf("GetUrl() with environment = %q", got)
+	}
+
+	if save := a.ApplyVariables(&call, []VariableChange{{Scope: SCOPE_COLLECTION, Name: "token", Value: "t2"}}); save != nil {
+		save()
+	}
+	if got := strings.TrimSpace(call.RequestParams().Headers["Authorization"]); got != "Bearer t2" {
+		t.Errorf("Authorization = %q, want Bearer t2", got)
+	}
+
+	a.NextEnvironment()
+	if a.SelectedEnvironment != nil {
+		t.Errorf("NextEnvironment() after the last one = %v, want none", a.SelectedEnvironment)
+	}
+}
+
+func TestWriteEnvironments(t *testing.T) {
+	isolateConfig(t)
+	a := &App{Environments: []Environment{{Name: "staging", Variables: map[string]string{"token": "t0ken-value"}}}}
+
+	if err := a.writeEnvironments(); err != nil {
+		t.Fatal(err)
+	}
+	// variables often hold tokens
+	if info, err := os.Stat(environmentsPath()); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected environments readable by the user only, got %v %v", info.Mode(), err)
+	}
+}
+
+func TestRequestParamsSubstitutesBody(t *testing.T) {
+	isolateConfig(t)
+
+	a := GetInstance()
+	call := Call{ID: "body", Method: "POST", Url: "http://localhost", DataType: "JSON", Data: `{"name": "{{name}}"}`}
+	a.Collections = []Collection{{ID: "c", Calls: []Call{call}, Variables: map[string]string{"name": "restman"}}}
+	t.Cleanup(func() { a.Collections = nil })
+
+	body, _ := io.ReadAll(call.RequestParams().Body)
+	if string(body) != `{"name": "restman"}` {
+		t.Errorf("body = %s", body)
+	}
+}
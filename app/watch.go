@@ -0,0 +1,105 @@
+package app
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long changes on disk have to settle before they are
+// reloaded, saving a workspace writes many files
+const watchDelay = 200 * time.Millisecond
+
+// WatchCollections watches collections.json and the workspace for changes
+// made outside restman. The command waits for the next change and sends
+// CollectionsChangedMsg, it is returned again to wait for the one after.
+// Nothing is watched when the watcher cannot be started.
+func (a *App) WatchCollections() tea.Cmd {
+	if a.changes == nil {
+		changes, err := a.watch()
+		if err != nil {
+			return nil
+		}
+		a.changes = changes
+	}
+	return func() tea.Msg {
+		<-a.changes
+		return CollectionsChangedMsg{}
+	}
+}
+
+func (a *App) watch() (chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	configDir := filepath.Dir(collectionsPath())
+	os.MkdirAll(configDir, os.ModePerm)
+	if err := watcher.Add(configDir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	workspace := ""
+	if a.workspace != "" {
+		workspace = filepath.Join(a.workspace, "collections")
+		watchTree(watcher, workspace)
+	}
+
+	// the files of collections and calls, not the temporary files they are
+	// written through
+	watched := func(name string) bool {
+		if filepath.Dir(name) == configDir {
+			return filepath.Base(name) == "collections.json"
+		}
+		return workspace != "" && strings.HasPrefix(name, workspace) && filepath.Ext(name) == ".json"
+	}
+
+	changes := make(chan struct{}, 1)
+	notify := time.AfterFunc(time.Hour, func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+	notify.Stop()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// fsnotify does not watch directories created meanwhile
+				if event.Has(fsnotify.Create) && workspace != "" {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						watchTree(watcher, event.Name)
+					}
+				}
+				if watched(event.Name) {
+					notify.Reset(watchDelay)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// watchTree watches the directory and the ones inside it
+func watchTree(watcher *fsnotify.Watcher, dir string) {
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			watcher.Add(path)
+		}
+		return nil
+	})
+}
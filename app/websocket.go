@@ -0,0 +1,197 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	FRAME_SENT     = "sent"
+	FRAME_RECEIVED = "received"
+)
+
+// Frame is a single WebSocket frame shown in the log
+type Frame struct {
+	Direction string
+	Type      string
+	Data      string
+	Time      time.Time
+}
+
+// IsWebSocketUrl reports whether the URL uses the ws:// or wss:// scheme
+func IsWebSocketUrl(rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "ws" || u.Scheme == "wss"
+}
+
+// the open WebSocket connections by call ID, every tab can have one
+var activeSockets struct {
+	sync.Mutex
+	conns map[string]*websocket.Conn
+}
+
+func frameType(messageType int) string {
+	switch messageType {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	case websocket.CloseMessage:
+		return "close"
+	}
+	return "unknown"
+}
+
+// handshakeHeaders turns the call headers and auth into the headers sent with
+// the opening handshake
+func handshakeHeaders(call *Call) http.Header {
+	params := call.RequestParams()
+	headers := http.Header{}
+	for k, v := range params.Headers {
+		headers.Set(k, v)
+	}
+	if params.Username != "" && params.Password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(params.Username + ":" + params.Password))
+		headers.Set("Authorization", "Basic "+auth)
+	}
+	return headers
+}
+
+// connectWebSocket dials the call URL and returns a channel delivering
+// OnWebSocketOpenMsg, OnFrameMsg for every received frame and finally
+// OnWebSocketClosedMsg.
+func (a *App) connectWebSocket(call *Call) chan tea.Msg {
+	messages := make(chan tea.Msg)
+
+	go func() {
+		defer close(messages)
+
+		conn, response, err := websocket.DefaultDialer.Dial(call.GetUrl(), handshakeHeaders(call))
+		if err != nil {
+			messages <- OnResponseMsg{Call: call, Err: err, Response: response}
+			return
+		}
+
+		activeSockets.Lock()
+		if activeSockets.conns == nil {
+			activeSockets.conns = map[string]*websocket.Conn{}
+		}
+		if previous := activeSockets.conns[call.ID]; previous != nil {
+			previous.Close()
+		}
+		activeSockets.conns[call.ID] = conn
+		activeSockets.Unlock()
+
+		// control frames are handled by the read loop, so they can be sent
+		// straight to the channel
+		conn.SetPingHandler(func(data string) error {
+			messages <- OnFrameMsg{Call: call, Frame: Frame{FRAME_RECEIVED, "ping", data, time.Now()}, next: waitForResponse(messages)}
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		conn.SetPongHandler(func(data string) error {
+			messages <- OnFrameMsg{Call: call, Frame: Frame{FRAME_RECEIVED, "pong", data, time.Now()}, next: waitForResponse(messages)}
+			return nil
+		})
+
+		messages <- OnWebSocketOpenMsg{Call: call, Response: response, next: waitForResponse(messages)}
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				closed := OnWebSocketClosedMsg{Call: call}
+				var closeErr *websocket.CloseError
+				if errors.As(err, &closeErr) {
+					closed.Code = closeErr.Code
+					closed.Reason = closeErr.Text
+				} else {
+					closed.Err = err
+				}
+				conn.Close()
+
+				activeSockets.Lock()
+				if activeSockets.conns[call.ID] == conn {
+					delete(activeSockets.conns, call.ID)
+				}
+				activeSockets.Unlock()
+
+				messages <- closed
+				return
+			}
+			messages <- OnFrameMsg{
+				Call:  call,
+				Frame: Frame{FRAME_RECEIVED, frameType(messageType), string(data), time.Now()},
+				next:  waitForResponse(messages),
+			}
+		}
+	}()
+
+	return messages
+}
+
+func (a *App) writeFrame(call *Call, messageType int, data string) tea.Cmd {
+	return func() tea.Msg {
+		activeSockets.Lock()
+		defer activeSockets.Unlock()
+
+		conn := activeSockets.conns[call.ID]
+		if conn == nil {
+			return OnWebSocketClosedMsg{Call: call, Err: errors.New("connection is not open")}
+		}
+
+		var err error
+		if messageType == websocket.TextMessage {
+			err = conn.WriteMessage(messageType, []byte(data))
+		} else {
+			err = conn.WriteControl(messageType, []byte(data), time.Now().Add(time.Second))
+		}
+		if err != nil {
+			return OnWebSocketClosedMsg{Call: call, Err: err}
+		}
+		if messageType == websocket.CloseMessage {
+			// the payload is the binary close code, not worth showing
+			data = ""
+		}
+		return OnFrameMsg{Call: call, Frame: Frame{FRAME_SENT, frameType(messageType), data, time.Now()}}
+	}
+}
+
+// SendFrame sends a text frame over the open WebSocket connection of the call
+func (a *App) SendFrame(call *Call, data string) tea.Cmd {
+	return a.writeFrame(call, websocket.TextMessage, data)
+}
+
+// Ping sends a ping frame over the open WebSocket connection of the call
+func (a *App) Ping(call *Call) tea.Cmd {
+	return a.writeFrame(call, websocket.PingMessage, "")
+}
+
+// CloseWebSocket starts the closing handshake of the connection of the call,
+// the server answers with a close frame which ends the read loop
+func (a *App) CloseWebSocket(call *Call) tea.Cmd {
+	return a.writeFrame(call, websocket.CloseMessage, string(websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+}
+
+// closeSocket drops the open connection of the call without the closing
+// handshake
+func closeSocket(call *Call) {
+	activeSockets.Lock()
+	defer activeSockets.Unlock()
+	if conn := activeSockets.conns[call.ID]; conn != nil {
+		conn.Close()
+		delete(activeSockets.conns, call.ID)
+	}
+}
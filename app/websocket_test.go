@@ -0,0 +1,114 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newEchoServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+}
+
+func TestIsWebSocketUrl(t *testing.T) {
+	tests := map[string]bool{
+		"ws://localhost:8080/socket": true,
+		"wss://example.com":          true,
+		"https://example.com":        false,
+		"example.com":                false,
+	}
+	for url, want := range tests {
+		if got := IsWebSocketUrl(url); got != want {
+			t.Errorf("IsWebSocketUrl(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Close()
+
+	call := NewCall()
+	call.Url = "ws" + strings.TrimPrefix(server.URL, "http")
+	call.Auth = &Auth{Type: "bearer_token", Token: "secret"}
+
+	messages := GetInstance().connectWebSocket(call)
+	defer closeSocket(call)
+
+	next := func() interface{} {
+		select {
+		case msg := <-messages:
+			return msg
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+		return nil
+	}
+
+	if msg, ok := next().(OnWebSocketOpenMsg); !ok {
+		t.Fatalf("Expected OnWebSocketOpenMsg, got %#v", msg)
+	}
+
+	// the connection belongs to the call, other tabs have their own
+	if msg, ok := GetInstance().SendFrame(NewCall(), "other")().(OnWebSocketClosedMsg); !ok || msg.Err == nil {
+		t.Fatalf("Expected no connection for another call, got %#v", msg)
+	}
+
+	sent := GetInstance().SendFrame(call, `{"hello": "world"}`)()
+	if msg, ok := sent.(OnFrameMsg); !ok || msg.Frame.Direction != FRAME_SENT {
+		t.Fatalf("Expected sent frame, got %#v", sent)
+	}
+
+	msg, ok := next().(OnFrameMsg)
+	if !ok || msg.Frame.Direction != FRAME_RECEIVED || msg.Frame.Type != "text" || msg.Frame.Data != `{"hello": "world"}` {
+		t.Fatalf("Expected echoed text frame, got %#v", msg)
+	}
+
+	GetInstance().Ping(call)()
+	if msg, ok := next().(OnFrameMsg); !ok || msg.Frame.Type != "pong" {
+		t.Fatalf("Expected pong frame, got %#v", msg)
+	}
+
+	GetInstance().CloseWebSocket(call)()
+	closed, ok := next().(OnWebSocketClosedMsg)
+	if !ok || closed.Code != websocket.CloseNormalClosure {
+		t.Fatalf("Expected normal closure, got %#v", closed)
+	}
+}
+
+func TestWebSocketHandshakeFailure(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Close()
+
+	call := NewCall()
+	call.Url = "ws" + strings.TrimPrefix(server.URL, "http")
+
+	msg := <-GetInstance().connectWebSocket(call)
+	response, ok := msg.(OnResponseMsg)
+	if !ok || response.Err == nil || response.Response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected failed handshake with 401, got %#v", msg)
+	}
+}
@@ -0,0 +1,376 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WorkspaceDir is the directory of a project holding its collections, one
+// file per collection and per call, so they can be committed and reviewed
+// with the code. It is found by walking up from the working directory.
+const WorkspaceDir = ".restman"
+
+// collectionFile holds the settings of a collection in its directory
+const collectionFile = "collection.json"
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// workspaceCollection is a collection as stored in its collection.json, the
+// calls are listed by their files to keep their order
+type workspaceCollection struct {
+	Collection
+	Calls []string `json:"calls"`
+}
+
+// workspaceCall is a call as stored in its file. Its examples are stored
+// without what changes with every response, so saving them again leaves the
+// file as it is.
+type workspaceCall struct {
+	Call
+	Examples []workspaceExample `json:"examples,omitempty"`
+}
+
+// workspaceExample is a saved example without the time it was received
+type workspaceExample struct {
+	Response
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+}
+
+// headers of examples which change with every response
+var volatileHeaders = []string{"Date", "Age", "Expires", "Set-Cookie"}
+
+func newWorkspaceCall(call Call) workspaceCall {
+	stored := workspaceCall{Call: call}
+	for _, example := range call.Examples {
+		headers := example.Headers.Clone()
+		for _, name := range volatileHeaders {
+			headers.Del(name)
+		}
+		if len(headers) == 0 {
+			headers = nil
+		}
+		example.Headers = headers
+		stored.Examples = append(stored.Examples, workspaceExample{Response: example})
+	}
+	return stored
+}
+
+// FindWorkspace returns the workspace in dir or in the closest of its
+// parents, empty when there is none. Only .restman directories holding a
+// collections directory are workspaces, $HOME/.restman holds the config.
+func FindWorkspace(dir string) string {
+	for {
+		workspace := filepath.Join(dir, WorkspaceDir)
+		if info, err := os.Stat(filepath.Join(workspace, "collections")); err == nil && info.IsDir() {
+			return workspace
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Workspace returns the workspace the collections are read from, empty when
+// only global collections are used
+func (a *App) Workspace() string {
+	return a.workspace
+}
+
+// InWorkspace reports whether the collection is stored in the workspace
+func (c Collection) InWorkspace() bool {
+	return c.workspace
+}
+
+// InitWorkspace creates a workspace in dir and moves the global collections
+// with the names into it. Workspaces cannot be nested.
+func (a *App) InitWorkspace(dir string, names ...string) (string, error) {
+	if a.workspace != "" {
+		return "", fmt.Errorf("workspace %s exists already", a.workspace)
+	}
+	moved := []*Collection{}
+	for _, name := range names {
+		collection := a.FindCollection(name)
+		if collection == nil {
+			return "", fmt.Errorf("collection %q not found", name)
+		}
+		moved = append(moved, collection)
+	}
+
+	a.workspace = filepath.Join(dir, WorkspaceDir)
+	if err := os.MkdirAll(filepath.Join(a.workspace, "collections"), os.ModePerm); err != nil {
+		return "", err
+	}
+	for _, collection := range moved {
+		collection.workspace = true
+	}
+	if msg, ok := a.syncCollections(KeepMine, false).(FetchCollectionsSuccessMsg); ok && msg.Err != nil {
+		return "", msg.Err
+	}
+	return a.workspace, nil
+}
+
+// slug turns a name into a file name which reads well in a repository
+func slug(name string) string {
+	name = strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if name == "" {
+		return "untitled"
+	}
+	return name
+}
+
+// uniqueName returns the name, or the name with a number when it is used
+// already, and marks it as used
+func uniqueName(used map[string]bool, name string, ext string) string {
+	unique := name + ext
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d%s", name, n, ext)
+	}
+	used[unique] = true
+	return unique
+}
+
+// marshalWorkspace writes indented JSON with a final newline, URLs are kept
+// readable. Struct fields keep their order and map keys are sorted, so files
+// only change with the collection.
+func marshalWorkspace(v any) ([]byte, error) {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(v)
+	return buf.Bytes(), err
+}
+
+// workspaceFiles returns the files of a collection directory keyed by their
+// slash separated path. Calls are put in directories named after their
+// folders, the folder in the file is the one which counts.
+func workspaceFiles(collection Collection) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	stored := workspaceCollection{Collection: collection, Calls: []string{}}
+	used := map[string]bool{collectionFile: true}
+
+	for _, call := range collection.Calls {
+		dir := ""
+		if folder := CleanFolderPath(call.Folder); folder != "" {
+			for _, segment := range strings.Split(folder, "/") {
+				dir = path.Join(dir, slug(segment))
+			}
+		}
+		name := call.Name
+		if name == "" {
+			name = call.Title()
+		}
+		file := uniqueName(used, path.Join(dir, slug(name)), ".json")
+
+		data, err := marshalWorkspace(newWorkspaceCall(call))
+		if err != nil {
+			return nil, err
+		}
+		files[file] = data
+		stored.Calls = append(stored.Calls, file)
+	}
+
+	data, err := marshalWorkspace(stored)
+	if err != nil {
+		return nil, err
+	}
+	files[collectionFile] = data
+	return files, nil
+}
+
+// readWorkspace returns the collections of the workspace and the files they
+// were read from, as slash separated paths below its collections directory.
+// The collections which could be read are returned along with an error
+// naming the files which could not.
+func readWorkspace(workspace string) ([]Collection, map[string]bool, error) {
+	collections := []Collection{}
+	loaded := map[string]bool{}
+	var errs []error
+	entries, err := os.ReadDir(filepath.Join(workspace, "collections"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return collections, loaded, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		collection, files, err := readWorkspaceCollection(filepath.Join(workspace, "collections", entry.Name()))
+		if files == nil && errors.Is(err, os.ErrNotExist) {
+			// not a collection
+			continue
+		}
+		for _, file := range files {
+			loaded[path.Join(entry.Name(), file)] = true
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if files != nil {
+			collections = append(collections, collection)
+		}
+	}
+	return collections, loaded, errors.Join(errs...)
+}
+
+// readWorkspaceCollection reads a collection directory. The calls listed in
+// collection.json come first, files added by hand follow sorted by path.
+// Collections and calls without an id get their path as id. The files read
+// are returned, none when collection.json cannot be read. Calls which cannot
+// be read are left out and reported in the error.
+func readWorkspaceCollection(dir string) (Collection, []string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, collectionFile))
+	if err != nil {
+		return Collection{}, nil, err
+	}
+	stored := workspaceCollection{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return Collection{}, nil, fmt.Errorf("%s: %w", filepath.Join(dir, collectionFile), err)
+	}
+
+	collection := stored.Collection
+	collection.workspace = true
+	collection.Calls = []Call{}
+	if collection.ID == "" {
+		collection.ID = filepath.Base(dir)
+	}
+
+	files := []string{collectionFile}
+	var errs []error
+	read := map[string]bool{collectionFile: true}
+	readCall := func(file string) {
+		read[file] = true
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if errors.Is(err, os.ErrNotExist) {
+			// removed by hand, it is dropped from the list
+			return
+		}
+		call := Call{}
+		if err == nil {
+			err = json.Unmarshal(data, &call)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Join(dir, filepath.FromSlash(file)), err))
+			return
+		}
+		if call.ID == "" {
+			call.ID = collection.ID + "/" + file
+		}
+		collection.Calls = append(collection.Calls, call)
+		files = append(files, file)
+	}
+
+	for _, file := range stored.Calls {
+		if !read[file] {
+			readCall(file)
+		}
+	}
+	filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(file) != ".json" {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, file)
+		if rel = filepath.ToSlash(rel); !read[rel] {
+			readCall(rel)
+		}
+		return nil
+	})
+	return collection, files, errors.Join(errs...)
+}
+
+// saveWorkspace writes the collections of the workspace and returns the
+// files written, as slash separated paths below its collections directory.
+// Of the files loaded by the last read, the ones of calls which are gone and
+// of collections which were removed or renamed are removed, with the
+// directories they leave empty. Other files are never removed.
+func saveWorkspace(workspace string, collections []Collection, loaded map[string]bool) (map[string]bool, error) {
+	root := filepath.Join(workspace, "collections")
+	used := map[string]bool{}
+	written := map[string]bool{}
+
+	for _, collection := range collections {
+		name := uniqueName(used, slug(collection.Name), "")
+		files, err := workspaceFiles(collection)
+		if err != nil {
+			return nil, err
+		}
+		for file, data := range files {
+			written[path.Join(name, file)] = true
+			path := filepath.Join(root, name, filepath.FromSlash(file))
+			// files are only touched when they change
+			if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				return nil, err
+			}
+			if err := writeFileAtomic(path, data, 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	removeStale(root, loaded, written)
+	return written, nil
+}
+
+// removeStale removes the loaded files which were not written and the
+// directories left empty by it
+func removeStale(root string, loaded map[string]bool, written map[string]bool) {
+	dirs := map[string]bool{}
+	for file := range loaded {
+		if written[file] {
+			continue
+		}
+		os.Remove(filepath.Join(root, filepath.FromSlash(file)))
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	// the deepest directories first, removing ones which are not empty fails
+	sorted := []string{}
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+	for _, dir := range sorted {
+		os.Remove(filepath.Join(root, filepath.FromSlash(dir)))
+	}
+}
+
+// loadWorkspace reads the collections of the workspace. While a file of it
+// cannot be read the workspace is not written, it may hold calls which are
+// not loaded. Only the files of the last read which succeeded are removed
+// when saving.
+func (a *App) loadWorkspace() ([]Collection, error) {
+	collections, loaded, err := readWorkspace(a.workspace)
+	if err != nil {
+		a.workspaceErr = fmt.Errorf("%w, the workspace is not written until it is fixed", err)
+		return collections, a.workspaceErr
+	}
+	a.workspaceErr = nil
+	a.workspaceFiles = loaded
+	return collections, nil
+}
+
+// writeWorkspace stores the collections of the workspace
+func (a *App) writeWorkspace(collections []Collection) error {
+	written, err := saveWorkspace(a.workspace, collections, a.workspaceFiles)
+	if err != nil {
+		return err
+	}
+	a.workspaceFiles = written
+	return nil
+}
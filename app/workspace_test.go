@@ -0,0 +1,203 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveWorkspace(t *testing.T) {
+	workspace := t.TempDir()
+	collection := folderCollection()
+	collection.Name = "Shop API"
+	collection.Calls[0].Name = "List users"
+	collection.Calls[1].Name = "Admins"
+	collection.Calls = append(collection.Calls, Call{ID: "users-2", Name: "list users", Url: "{{BASE_URL}}/users?a=1&b=2", Folder: "users"})
+
+	if _, err := saveWorkspace(workspace, []Collection{collection}, nil); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(workspace, "collections", "shop-api")
+	for _, file := range []string{"collection.json", "users/list-users.json", "users/list-users-2.json", "users/admin/admins.json", "ops/checks/health.json"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("Expected %s to be written: %v", file, err)
+		}
+	}
+
+	read, loaded, err := readWorkspace(workspace)
+	if err != nil || len(read) != 1 || !read[0].InWorkspace() || len(loaded) != 5 {
+		t.Fatalf("readWorkspace() = %+v, %v, %v", read, loaded, err)
+	}
+	// headers are read as an empty list
+	for i := range collection.Calls {
+		if collection.Calls[i].Headers == nil {
+			collection.Calls[i].Headers = Headers{}
+		}
+	}
+	got, _ := marshalWorkspace(read[0])
+	want, _ := marshalWorkspace(collection)
+	if !bytes.Equal(got, want) {
+		t.Errorf("readWorkspace() = %s, want %s", got, want)
+	}
+
+	// calls which are gone and folders left empty are removed
+	collection.Calls = collection.Calls[:2]
+	if loaded, err = saveWorkspace(workspace, []Collection{collection}, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ops")); !os.IsNotExist(err) {
+		t.Errorf("Expected ops folder to be removed, got %v", err)
+	}
+
+	// removed collections are removed with their directory
+	if _, err := saveWorkspace(workspace, []Collection{}, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected collection directory to be removed, got %v", err)
+	}
+}
+
+func TestSaveWorkspaceExamples(t *testing.T) {
+	workspace := t.TempDir()
+	example := func(date string) Response {
+		return Response{Name: "ok", Status: 200, Body: `{"id": 1}`, ReceivedAt: time.Now(), Headers: http.Header{
+			"Content-Type": {"application/json"}, "Date": {date}, "Set-Cookie": {"session=" + date},
+		}}
+	}
+	collection := Collection{ID: "c", Name: "api", Calls: []Call{{ID: "a", Name: "a", Url: "http://localhost/a"}}}
+	file := filepath.Join(workspace, "collections", "api", "a.json")
+
+	collection.Calls[0].Examples = []Response{example("Mon, 01 Jan 2024 10:00:00 GMT")}
+	if _, err := saveWorkspace(workspace, []Collection{collection}, nil); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile(file)
+
+	// the example saved again from a later response
+	collection.Calls[0].Examples = []Response{example("Tue, 02 Jan 2024 12:00:00 GMT")}
+	if _, err := saveWorkspace(workspace, []Collection{collection}, nil); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(file)
+	if !bytes.Equal(first, second) {
+		t.Errorf("Saving the example again changed the file from %s to %s", first, second)
+	}
+	for _, volatile := range []string{"received_at", "Date", "Set-Cookie"} {
+		if strings.Contains(string(second), volatile) {
+			t.Errorf("Expected %s to be left out, got %s", volatile, second)
+		}
+	}
+	if !strings.Contains(string(second), "application/json") {
+		t.Errorf("Expected the other headers to be kept, got %s", second)
+	}
+}
+
+func TestReadWorkspaceAddedByHand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "collections", "manual")
+	os.MkdirAll(filepath.Join(dir, "users"), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "collection.json"), []byte(`{"name": "manual", "calls": ["b.json"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"id": "b", "name": "b", "url": "http://localhost/b"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "users", "a.json"), []byte(`{"url": "http://localhost/a", "folder": "users"}`), 0644)
+
+	collection, files, err := readWorkspaceCollection(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collection.ID != "manual" || len(collection.Calls) != 2 || len(files) != 3 {
+		t.Fatalf("readWorkspaceCollection() = %+v, %v", collection, files)
+	}
+	if collection.Calls[0].ID != "b" || collection.Calls[1].ID != "manual/users/a.json" {
+		t.Errorf("Unexpected calls %+v", collection.Calls)
+	}
+}
+
+func TestWorkspaceWithInvalidFiles(t *testing.T) {
+	isolateConfig(t)
+	root := t.TempDir()
+	dir := filepath.Join(root, WorkspaceDir, "collections", "api")
+	os.MkdirAll(dir, os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "collection.json"), []byte(`{"name": "api", "calls": ["a.json", "b.json"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"id": "a", "name": "a", "url": "http://localhost/a"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"id": "b",`), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep me"), 0644)
+
+	cwd, _ := os.Getwd()
+	os.Chdir(root)
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	a := &App{}
+	err := a.LoadCollections()
+	if err == nil || !strings.Contains(err.Error(), "b.json") {
+		t.Fatalf("LoadCollections() error = %v, want b.json to be reported", err)
+	}
+	if len(a.Collections) != 1 || len(a.Collections[0].Calls) != 1 {
+		t.Fatalf("LoadCollections() = %+v, want the calls which could be read", a.Collections)
+	}
+
+	// the call which cannot be read is not lost by saving
+	a.Collections[0].Calls[0].Url = "http://localhost/changed"
+	if msg := a.syncCollections(KeepMine, false).(FetchCollectionsSuccessMsg); msg.Err == nil {
+		t.Errorf("syncCollections() should refuse to write the workspace")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "b.json")); string(data) != `{"id": "b",` {
+		t.Errorf("b.json = %s, want it untouched", data)
+	}
+
+	// once fixed the workspace is written, files which were not loaded are
+	// kept
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"id": "b", "name": "b", "url": "http://localhost/b"}`), 0644)
+	a.Collections[0].Calls = append(a.Collections[0].Calls, Call{ID: "b", Name: "b", Url: "http://localhost/b"})
+	if msg := a.syncCollections(KeepMine, false).(FetchCollectionsSuccessMsg); msg.Err != nil {
+		t.Fatalf("syncCollections() error = %v", msg.Err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("notes.txt should be kept: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.json")); !strings.Contains(string(data), "changed") {
+		t.Errorf("a.json = %s, want the change", data)
+	}
+}
+
+func TestFindWorkspace(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "src", "api")
+	os.MkdirAll(nested, os.ModePerm)
+	if got := FindWorkspace(nested); got != "" {
+		t.Errorf("FindWorkspace() = %q, want none", got)
+	}
+
+	// a .restman directory without collections is not a workspace
+	os.MkdirAll(filepath.Join(nested, WorkspaceDir), os.ModePerm)
+	os.MkdirAll(filepath.Join(root, WorkspaceDir, "collections"), os.ModePerm)
+	if got := FindWorkspace(nested); got != filepath.Join(root, WorkspaceDir) {
+		t.Errorf("FindWorkspace() = %q", got)
+	}
+}
+
+func TestInitWorkspace(t *testing.T) {
+	isolateConfig(t)
+	dir := t.TempDir()
+	a := &App{Collections: []Collection{{ID: "a", Name: "global", Calls: []Call{}}, {ID: "b", Name: "project", Calls: []Call{}}}}
+
+	if _, err := a.InitWorkspace(dir, "missing"); err == nil {
+		t.Errorf("Expected error for a missing collection")
+	}
+	workspace, err := a.InitWorkspace(dir, "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Collections[0].InWorkspace() || !a.Collections[1].InWorkspace() {
+		t.Errorf("Expected only the project collection to be moved, got %+v", a.Collections)
+	}
+	if read, _, err := readWorkspace(workspace); err != nil || len(read) != 1 || read[0].ID != "b" {
+		t.Errorf("readWorkspace() = %+v, %v", read, err)
+	}
+	if _, err := a.InitWorkspace(dir); err == nil {
+		t.Errorf("Expected error for an existing workspace")
+	}
+}
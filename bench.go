@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"restman/app"
+	"restman/components/config"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [collection] <call>",
+	Short: "Send a saved call many times and report latencies",
+	Long: `Send a saved call many times from concurrent workers and report the
+throughput, latency percentiles, a latency histogram, the status codes and
+errors. The collection can be left out when the call name is unique.
+
+This is a quick sanity check, connections are kept alive and reused and the
+responses are not checked by the tests of the call.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.SetVersion(version)
+		readConfig()
+
+		a := readCollections()
+		call, err := findCall(a, args)
+		if err != nil {
+			return err
+		}
+		if env, _ := cmd.Flags().GetString("env"); env != "" {
+			a.SelectEnvironment(env)
+			if a.SelectedEnvironment == nil {
+				return fmt.Errorf("unknown environment %q", env)
+			}
+		}
+
+		opts := app.BenchOptions{}
+		opts.Requests, _ = cmd.Flags().GetInt("requests")
+		opts.Duration, _ = cmd.Flags().GetDuration("duration")
+		opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+		opts.Rate, _ = cmd.Flags().GetFloat64("rate")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		// the live line is only shown on a terminal
+		var progress func(app.BenchStats)
+		if isatty.IsTerminal(os.Stderr.Fd()) {
+			progress = func(s app.BenchStats) {
+				fmt.Fprintf(os.Stderr, "\r%d requests · %.1f req/s · p50 %s · %d errors ",
+					s.Requests, s.Throughput(), formatLatency(s.Percentile(50)), s.ErrorCount())
+			}
+		}
+		stats, err := a.Bench(ctx, call, opts, progress)
+		if progress != nil {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+		if err != nil && err != context.Canceled {
+			return err
+		}
+		printBenchStats(os.Stdout, call, stats)
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntP("requests", "n", 100, "Number of requests")
+	benchCmd.Flags().DurationP("duration", "d", 0, "Send requests for a duration instead, e.g. 30s")
+	benchCmd.Flags().IntP("concurrency", "c", 10, "Number of requests sent at the same time")
+	benchCmd.Flags().Float64("rate", 0, "Requests per second, unlimited by default")
+	benchCmd.Flags().StringP("env", "e", "", "Environment to use instead of the selected one")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// findCall finds the call of the arguments, in the given collection or in
+// any collection when only the call is given
+func findCall(a *app.App, args []string) (*app.Call, error) {
+	if len(args) == 2 {
+		collection := a.FindCollection(args[0])
+		if collection == nil {
+			return nil, fmt.Errorf("collection %q not found", args[0])
+		}
+		call := collection.FindCall(args[1])
+		if call == nil {
+			return nil, fmt.Errorf("call %q not found in %s", args[1], collection.Name)
+		}
+		return call, nil
+	}
+
+	var found *app.Call
+	for _, collection := range a.Collections {
+		if call := collection.FindCall(args[0]); call != nil {
+			if found != nil {
+				return nil, fmt.Errorf("call %q is in more than one collection, give the collection too", args[0])
+			}
+			found = call
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("call %q not found", args[0])
+	}
+	return found, nil
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+func printBenchStats(out io.Writer, call *app.Call, s app.BenchStats) {
+	fmt.Fprintf(out, "%s %s\n\n", call.Method, call.Title())
+	fmt.Fprintf(out, "Requests     %d in %s\n", s.Requests, s.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(out, "Throughput   %.1f req/s\n", s.Throughput())
+	fmt.Fprintf(out, "Latency      p50 %s  p90 %s  p99 %s  mean %s\n\n",
+		formatLatency(s.Percentile(50)), formatLatency(s.Percentile(90)), formatLatency(s.Percentile(99)), formatLatency(s.Mean()))
+
+	histogram := s.Histogram(10)
+	biggest := 0
+	for _, bucket := range histogram {
+		biggest = max(biggest, bucket.Count)
+	}
+	for _, bucket := range histogram {
+		bar := strings.Repeat("■", bucket.Count*40/max(biggest, 1))
+		fmt.Fprintf(out, "%12s %s %d\n", formatLatency(bucket.To), runGrayStyle.Render(bar), bucket.Count)
+	}
+	if len(histogram) > 0 {
+		fmt.Fprintln(out)
+	}
+
+	statuses := []int{}
+	for status := range s.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		style := runPassedStyle
+		if status >= 400 {
+			style = runFailedStyle
+		}
+		fmt.Fprintf(out, "%s × %d\n", style.Render(fmt.Sprint(status)), s.Statuses[status])
+	}
+
+	messages := []string{}
+	for message := range s.Errors {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	for _, message := range messages {
+		fmt.Fprintln(out, runFailedStyle.Render(fmt.Sprintf("%d × %s", s.Errors[message], message)))
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"log"
 	neturl "net/url"
 	"os"
 	"restman/app"
@@ -11,6 +12,7 @@ import (
 	"restman/components/footer"
 	"restman/components/request"
 	"restman/components/results"
+	"restman/components/tabs"
 	"restman/components/url"
 	"restman/utils"
 	"strings"
@@ -86,57 +88,45 @@ Restman is a CLI tool for RESTful API.`,
 		if headers != nil {
 			// split headers into key-value pairs
 			// to check authorization for bearer token
-			processed_headers := []string{}
+			processed_headers := app.Headers{}
 			for _, h := range headers {
 				if h == "" {
 					continue
 				}
-				pair := strings.Split(h, ":")
-				if len(pair) == 2 {
-					if strings.ToLower(pair[0]) == "authorization" && strings.Contains(pair[1], "Bearer") {
-						call.Auth = &app.Auth{Type: "bearer_token", Token: strings.TrimSpace(strings.ReplaceAll(pair[1], "Bearer", ""))}
-						continue
-					}
-
-					if strings.ToLower(pair[0]) == "content-type" && strings.Contains(pair[1], "application/json") {
-						call.DataType = "JSON"
-						call.Data = utils.FormatJSON(call.Data)
-					}
+				header := app.ParseHeader(h)
+				if strings.ToLower(header.Key) == "authorization" && strings.HasPrefix(header.Value, "Bearer") {
+					call.Auth = &app.Auth{Type: "bearer_token", Token: strings.TrimSpace(strings.TrimPrefix(header.Value, "Bearer"))}
+					continue
+				}
+
+				if strings.ToLower(header.Key) == "content-type" && strings.Contains(header.Value, "application/json") {
+					call.DataType = "JSON"
+					call.Data = utils.FormatJSON(call.Data)
 				}
-				processed_headers = append(processed_headers, h)
+				processed_headers = append(processed_headers, header)
 			}
 			call.Headers = processed_headers
 		}
 
-		viper.SetConfigName("config")         // name of config file (without extension)
-		viper.SetConfigType("json")           // REQUIRED if the config file does not have the extension in the name
-		viper.AddConfigPath("/etc/restman/")  // path to look for the config file in
-		viper.AddConfigPath("$HOME/.restman") // call multiple times to add many search paths
-		err := viper.ReadInConfig()           // Find and read the config file
-		if err != nil {                       // Handle errors reading the config file
-			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-				// NOTE: ignore if config file is not found
-			} else {
-				panic(fmt.Errorf("fatal error config file: %w", err))
-			}
-		}
+		readConfig()
 
 		var default_headers map[string]string = viper.GetStringMapString("default_headers")
 		for k, v := range default_headers {
-			call.Headers = append(call.Headers, fmt.Sprintf("%s: %s", k, v))
+			call.Headers = append(call.Headers, app.Header{Key: k, Value: v})
 		}
 
 		// ----
 		zone.NewGlobal()
 
 		// layout-tree defintion
-		m := Model{tui: boxer.Boxer{}, focused: "url", initialCall: call}
+		m := Model{tui: boxer.Boxer{}, focused: "url", initialCall: call, tabs: []requestTab{{}}}
 
 		url := url.New()
 		resultsBox := results.New()
 		requestBox := request.New()
 		footerBox := footer.New()
 		colBox := collections.New()
+		tabsBox := tabs.New()
 
 		splitNode := boxer.CreateNoBorderNode()
 		splitNode.SizeFunc = func(node boxer.Node, widthOrHeight int) []int {
@@ -155,11 +145,13 @@ Restman is a CLI tool for RESTful API.`,
 		centerNode.VerticalStacked = true
 		centerNode.SizeFunc = func(node boxer.Node, widthOrHeight int) []int {
 			return []int{
+				1,
 				3,
-				widthOrHeight - 3,
+				widthOrHeight - 4,
 			}
 		}
 		centerNode.Children = []boxer.Node{
+			stripErr(m.tui.CreateLeaf("tabs", tabsBox)),
 			stripErr(m.tui.CreateLeaf("url", url)),
 			splitNode,
 		}
@@ -201,6 +193,8 @@ Restman is a CLI tool for RESTful API.`,
 			os.Exit(1)
 		} else {
 			defer f.Close()
+			// values of the secrets never reach the debug log
+			log.SetOutput(app.RedactSecrets(f))
 		}
 
 		p := tea.NewProgram(
@@ -209,9 +203,46 @@ Restman is a CLI tool for RESTful API.`,
 			tea.WithMouseCellMotion(), // turn on mouse support so we can track the mouse wheel
 		)
 
-		if _, err := p.Run(); err != nil {
+		final, err := p.Run()
+		switch final := final.(type) {
+		case Model:
+			final.saveTabs()
+		case *Model:
+			final.saveTabs()
+		}
+		app.GetInstance().Cleanup()
+		if err != nil {
 			fmt.Println("could not run program:", err)
 			os.Exit(1)
 		}
 	},
 }
+
+// readConfig reads the optional config file into viper
+func readConfig() {
+	viper.SetConfigName("config")         // name of config file (without extension)
+	viper.SetConfigType("json")           // REQUIRED if the config file does not have the extension in the name
+	viper.AddConfigPath("/etc/restman/")  // path to look for the config file in
+	viper.AddConfigPath("$HOME/.restman") // call multiple times to add many search paths
+	err := viper.ReadInConfig()           // Find and read the config file
+	if err != nil {                       // Handle errors reading the config file
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			// NOTE: ignore if config file is not found
+		} else {
+			panic(fmt.Errorf("fatal error config file: %w", err))
+		}
+	}
+}
+
+// readCollections loads the collections for the commands, problems reading
+// them are printed as warnings
+func readCollections() *app.App {
+	a := app.GetInstance()
+	if err := a.LoadCollections(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	if err := unlockSecrets(a, false); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: secrets are locked:", err)
+	}
+	return a
+}
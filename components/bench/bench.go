@@ -0,0 +1,301 @@
+package bench
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"restman/components/overlay"
+	"restman/components/popup"
+	"restman/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	REQUESTS_IDX = iota
+	DURATION_IDX
+	CONCURRENCY_IDX
+	RATE_IDX
+	CANCEL_IDX
+	OK_IDX
+)
+
+const NUM_OF_INPUTS = 6
+
+var (
+	general = lipgloss.NewStyle().
+		UnsetAlign().
+		Padding(0, 1, 0, 1).
+		Foreground(config.COLOR_FOREGROUND).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(config.COLOR_HIGHLIGHT)
+
+	grayStyle   = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	barStyle    = lipgloss.NewStyle().Foreground(config.COLOR_HIGHLIGHT)
+	passedStyle = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	failedStyle = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+)
+
+// Bench is a popup benchmarking a call, first showing the options and then
+// the live stats
+type Bench struct {
+	call    app.Call
+	width   int
+	bgRaw   string
+	focused int
+	inputs  []textinput.Model
+	errors  []string
+
+	running  bool
+	finished bool
+	stats    app.BenchStats
+	err      error
+}
+
+func New(call app.Call, bgRaw string, width int) Bench {
+	inputs := make([]textinput.Model, RATE_IDX+1)
+	placeholders := []string{"100", "e.g. 30s, instead of requests", "10", "unlimited, requests per second"}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = "󱞩 "
+		inputs[i].Placeholder = placeholders[i]
+	}
+	inputs[REQUESTS_IDX].Focus()
+
+	return Bench{
+		call:   call,
+		width:  width,
+		bgRaw:  bgRaw,
+		inputs: inputs,
+	}
+}
+
+// Init initializes the popup.
+func (c Bench) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// options reads the benchmark options from the inputs
+func (c Bench) options() (app.BenchOptions, []string) {
+	errors := []string{}
+	opts := app.BenchOptions{Requests: 100, Concurrency: 10}
+
+	positive := func(idx int, name string) int {
+		value := strings.TrimSpace(c.inputs[idx].Value())
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			errors = append(errors, name+" must be a positive number")
+		}
+		return n
+	}
+	if n := positive(REQUESTS_IDX, "Requests"); n > 0 {
+		opts.Requests = n
+	}
+	if n := positive(CONCURRENCY_IDX, "Concurrency"); n > 0 {
+		opts.Concurrency = n
+	}
+	if value := strings.TrimSpace(c.inputs[DURATION_IDX].Value()); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			errors = append(errors, "Duration must be a duration like 30s or 1m")
+		}
+		opts.Duration = duration
+	}
+	if value := strings.TrimSpace(c.inputs[RATE_IDX].Value()); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 || rate > app.MaxBenchRate {
+			errors = append(errors, fmt.Sprintf("Rate must be a positive number up to %g", app.MaxBenchRate))
+		}
+		opts.Rate = rate
+	}
+	return opts, errors
+}
+
+// Update handles messages.
+func (c Bench) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.OnBenchProgressMsg:
+		c.stats = msg.Stats
+		return c, nil
+
+	case app.OnBenchFinishedMsg:
+		c.running = false
+		c.finished = true
+		c.stats = msg.Stats
+		c.err = msg.Err
+		return c, nil
+
+	case tea.KeyMsg:
+		if c.running || c.finished {
+			switch msg.String() {
+			case "esc", "enter", "q":
+				app.GetInstance().StopBench()
+				return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+			}
+			return c, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyShiftTab, tea.KeyCtrlP, tea.KeyUp:
+			c.focused = (c.focused - 1 + NUM_OF_INPUTS) % NUM_OF_INPUTS
+
+		case tea.KeyTab, tea.KeyCtrlN, tea.KeyDown:
+			c.focused = (c.focused + 1) % NUM_OF_INPUTS
+
+		case tea.KeyEnter:
+			if c.focused == CANCEL_IDX {
+				return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+			}
+			var opts app.BenchOptions
+			opts, c.errors = c.options()
+			if len(c.errors) > 0 {
+				return c, nil
+			}
+			c.running = true
+			return c, app.GetInstance().StartBench(c.call, opts)
+
+		case tea.KeyEsc:
+			return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i := range c.inputs {
+		if i == c.focused {
+			c.inputs[i].Focus()
+		} else {
+			c.inputs[i].Blur()
+		}
+		var cmd tea.Cmd
+		c.inputs[i], cmd = c.inputs[i].Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return c, tea.Batch(cmds...)
+}
+
+func (c Bench) optionsView() string {
+	labels := []string{"Requests:", "Duration:", "Concurrency:", "Rate:"}
+	rows := []string{}
+	for i, input := range c.inputs {
+		rows = append(rows, config.LabelStyle.Render(labels[i]), config.InputStyle.Render(input.View()))
+	}
+
+	okButtonStyle := config.ButtonStyle
+	cancelButtonStyle := config.ButtonStyle
+	if c.focused == CANCEL_IDX {
+		cancelButtonStyle = config.ActiveButtonStyle
+	} else if c.focused == OK_IDX {
+		okButtonStyle = config.ActiveButtonStyle
+	}
+	footer := lipgloss.PlaceHorizontal(
+		c.width,
+		lipgloss.Right,
+		lipgloss.JoinHorizontal(lipgloss.Right, cancelButtonStyle.Render("Cancel"), " ", okButtonStyle.Render("Start")),
+	)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		append(rows, utils.RenderErrors(c.errors), footer)...,
+	)
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// statsView renders the throughput, latencies, histogram, status codes and
+// errors of the benchmark
+func (c Bench) statsView() string {
+	s := c.stats
+	rows := []string{
+		fmt.Sprintf("Requests    %d in %s · %.1f req/s · %s", s.Requests, s.Elapsed.Round(time.Millisecond), s.Throughput(), utils.ByteCountIEC(s.Bytes)),
+		fmt.Sprintf("Latency     p50 %s · p90 %s · p99 %s · mean %s",
+			formatLatency(s.Percentile(50)), formatLatency(s.Percentile(90)), formatLatency(s.Percentile(99)), formatLatency(s.Mean())),
+		"",
+	}
+
+	// the bars are scaled to the biggest bucket
+	histogram := s.Histogram(10)
+	biggest := 0
+	for _, bucket := range histogram {
+		biggest = max(biggest, bucket.Count)
+	}
+	barWidth := max(c.width-36, 10)
+	for _, bucket := range histogram {
+		bar := strings.Repeat("■", bucket.Count*barWidth/max(biggest, 1))
+		rows = append(rows, fmt.Sprintf("%12s %s %d", formatLatency(bucket.To), barStyle.Render(bar), bucket.Count))
+	}
+	if len(histogram) > 0 {
+		rows = append(rows, "")
+	}
+
+	statuses := []int{}
+	for status := range s.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	codes := []string{}
+	for _, status := range statuses {
+		style := passedStyle
+		if status >= 400 {
+			style = failedStyle
+		}
+		codes = append(codes, style.Render(strconv.Itoa(status))+fmt.Sprintf(" × %d", s.Statuses[status]))
+	}
+	rows = append(rows, "Status      "+strings.Join(codes, " · "))
+
+	if errors := s.ErrorCount(); errors > 0 {
+		rows = append(rows, failedStyle.Render(fmt.Sprintf("Errors      %d", errors)))
+		messages := []string{}
+		for message := range s.Errors {
+			messages = append(messages, message)
+		}
+		sort.Strings(messages)
+		for _, message := range messages {
+			line := fmt.Sprintf("  %d × %s", s.Errors[message], message)
+			rows = append(rows, grayStyle.MaxWidth(c.width).Render(line))
+		}
+	}
+
+	summary := "Running…"
+	help := "esc cancel"
+	if c.finished {
+		summary = "Finished"
+		if c.err != nil {
+			summary = failedStyle.Render(c.err.Error())
+		}
+		help = "enter close"
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		append(rows, "", summary, grayStyle.Render(help))...,
+	)
+}
+
+func (c Bench) View() string {
+	content := c.optionsView()
+	if c.running || c.finished {
+		content = c.statsView()
+	}
+
+	formView := lipgloss.JoinVertical(
+		lipgloss.Left,
+		config.BoxHeader.Render("Benchmark "+c.call.Title()),
+		"",
+		content,
+	)
+
+	view := general.Width(c.width).Render(formView)
+	startCol, startRow := utils.GetStartColRow(view, c.bgRaw)
+	return overlay.PlaceOverlay(startCol, startRow, view, c.bgRaw)
+}
@@ -5,9 +5,11 @@ import (
 	"io"
 	"restman/app"
 	"restman/components/config"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
@@ -16,8 +18,48 @@ import (
 var (
 	itemStyle         = lipgloss.NewStyle().Faint(true).Bold(false).Foreground(config.COLOR_GRAY)
 	selectedItemStyle = lipgloss.NewStyle().Faint(false).Bold(true).Foreground(config.COLOR_FOREGROUND)
+	unsavedStyle      = lipgloss.NewStyle().Foreground(config.COLOR_WARNING)
+	folderStyle       = lipgloss.NewStyle().Foreground(config.COLOR_HIGHLIGHT)
+	hintStyle         = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
 )
 
+const (
+	browse = iota
+	newFolder
+	move
+)
+
+// folderItem is a folder row of the calls tree
+type folderItem struct {
+	path     string
+	depth    int
+	expanded bool
+	// number of calls inside, nested ones included
+	calls int
+}
+
+func (f folderItem) FilterValue() string { return f.path }
+
+// callItem is a call row of the calls tree
+type callItem struct {
+	app.Call
+	depth int
+}
+
+// moveTarget is a folder a call can be moved to, an empty folder is the top
+// level of the collection
+type moveTarget struct {
+	collection app.Collection
+	folder     string
+}
+
+func (t moveTarget) title() string {
+	if t.folder == "" {
+		return "󰉋 " + t.collection.Name
+	}
+	return strings.Repeat("  ", strings.Count(t.folder, "/")+1) + "󰉋 " + app.FolderName(t.folder)
+}
+
 type itemDelegate struct{}
 
 func (d itemDelegate) Height() int                             { return 1 }
@@ -26,25 +68,44 @@ func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
 	maxWidth := m.Width()
 
-	str := list.Item(listItem).(app.Call).Title()
-	method := list.Item(listItem).(app.Call).MethodShortView()
-	methodName := listItem.(app.Call).Method
-	prefix := " " + method + " "
-	prefixWidth := len(methodName) + 3
+	var str, prefix, suffix string
+	var prefixWidth int
+	switch item := listItem.(type) {
+	case folderItem:
+		icon := "󰉋 "
+		if item.expanded {
+			icon = "󰝰 "
+		}
+		str = app.FolderName(item.path)
+		prefix = strings.Repeat("  ", item.depth) + folderStyle.Render(icon)
+		prefixWidth = 2*item.depth + 3
+		suffix = hintStyle.Render(fmt.Sprintf(" %d", item.calls))
+
+	case callItem:
+		str = item.Title()
+		prefix = strings.Repeat("  ", item.depth) + " " + item.MethodShortView() + " "
+		prefixWidth = 2*item.depth + len(item.Method) + 3
+
+		// calls edited in a tab and not saved yet
+		if app.GetInstance().IsUnsaved(item.ID) {
+			suffix = unsavedStyle.Render(" ●")
+			prefixWidth += 2
+		}
+	}
 
 	style := itemStyle
 	if index == m.Index() {
 		style = selectedItemStyle
-		prefix = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL).Render("") + prefix
+		prefix = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL).Render("") + prefix
 	} else {
 		prefix = " " + prefix
 	}
 
 	// truncate str if it's too long
 	if len(str) > maxWidth-prefixWidth {
-		str = str[:maxWidth-prefixWidth-1] + "…"
+		str = str[:max(maxWidth-prefixWidth-1, 0)] + "…"
 	}
-	item := style.Render(prefix + style.Render(str))
+	item := style.Render(prefix+style.Render(str)) + suffix
 
 	fmt.Fprint(w, item)
 }
@@ -52,6 +113,14 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 type callModel struct {
 	list       list.Model
 	collection *app.Collection
+	// folders shown with their content
+	expanded map[string]bool
+	mode     int
+	input    textinput.Model
+	// call being moved and the folders it can be moved to
+	moving  app.Call
+	targets []moveTarget
+	target  int
 }
 
 func NewCallModel() callModel {
@@ -65,20 +134,167 @@ func NewCallModel() callModel {
 				key.WithKeys("esc"),
 				key.WithHelp("esc", "go back"),
 			),
+			key.NewBinding(
+				key.WithKeys("b"),
+				key.WithHelp("b", "benchmark"),
+			),
+			key.NewBinding(
+				key.WithKeys("n"),
+				key.WithHelp("n", "new folder"),
+			),
+			key.NewBinding(
+				key.WithKeys("m"),
+				key.WithHelp("m", "move call"),
+			),
+			key.NewBinding(
+				key.WithKeys("e"),
+				key.WithHelp("e", "edit folder"),
+			),
 		}
 	}
 	callsList.DisableQuitKeybindings()
 	callsList.SetShowHelp(false)
 
+	input := textinput.New()
+	input.Prompt = "󰉋 "
+	input.Placeholder = "folder/subfolder"
+
 	return callModel{
-		list: callsList,
+		list:     callsList,
+		expanded: map[string]bool{},
+		input:    input,
+	}
+}
+
+// items returns the rows of the tree, folders come before the calls next to
+// them. All folders are expanded while the list is filtered.
+func (m callModel) items() []list.Item {
+	all := m.list.FilterState() != list.Unfiltered
+
+	children := map[string][]string{}
+	for _, path := range m.collection.FolderPaths() {
+		parent := app.FolderParent(path)
+		children[parent] = append(children[parent], path)
+	}
+	calls := map[string][]app.Call{}
+	for _, call := range m.collection.Calls {
+		folder := app.CleanFolderPath(call.Folder)
+		calls[folder] = append(calls[folder], call)
+	}
+	count := func(path string) int {
+		n := 0
+		for folder, inside := range calls {
+			if folder == path || strings.HasPrefix(folder, path+"/") {
+				n += len(inside)
+			}
+		}
+		return n
+	}
+
+	items := []list.Item{}
+	var add func(path string, depth int)
+	add = func(path string, depth int) {
+		for _, child := range children[path] {
+			expanded := all || m.expanded[child]
+			items = append(items, folderItem{path: child, depth: depth, expanded: expanded, calls: count(child)})
+			if expanded {
+				add(child, depth+1)
+			}
+		}
+		for _, call := range calls[path] {
+			items = append(items, callItem{Call: call, depth: depth})
+		}
+	}
+	add("", 0)
+	return items
+}
+
+// refresh shows the collection, the selection stays on the same row
+func (m callModel) refresh() (callModel, tea.Cmd) {
+	m.list.Title = zone.Mark("collections_minify", "󰅁 "+m.collection.Name)
+	return m, m.list.SetItems(m.items())
+}
+
+// expand shows the folder and the folders around it
+func (m *callModel) expand(path string) {
+	for ; path != ""; path = app.FolderParent(path) {
+		m.expanded[path] = true
+	}
+}
+
+// currentFolder returns the folder under the cursor or holding the call
+// under it
+func (m callModel) currentFolder() string {
+	switch item := m.list.SelectedItem().(type) {
+	case folderItem:
+		return item.path
+	case callItem:
+		return app.CleanFolderPath(item.Folder)
+	}
+	return ""
+}
+
+func (m callModel) startMoving(call app.Call) callModel {
+	m.mode = move
+	m.moving = call
+	m.targets = []moveTarget{}
+	m.target = 0
+	for _, collection := range app.GetInstance().Collections {
+		for _, folder := range append([]string{""}, collection.FolderPaths()...) {
+			target := moveTarget{collection: collection, folder: folder}
+			if collection.ID == m.collection.ID && folder == app.CleanFolderPath(call.Folder) {
+				m.target = len(m.targets)
+			}
+			m.targets = append(m.targets, target)
+		}
 	}
+	return m
 }
 
 func (m callModel) Init() tea.Cmd {
 	return nil
 }
 
+func (m callModel) updateNewFolder(msg tea.KeyMsg) (callModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = browse
+		return m, nil
+
+	case "enter":
+		m.mode = browse
+		path := app.CleanFolderPath(m.input.Value())
+		m.expand(path)
+		return m, app.GetInstance().CreateFolder(m.collection.ID, path)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m callModel) updateMove(msg tea.KeyMsg) (callModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = browse
+
+	case "up", "k":
+		m.target = max(m.target-1, 0)
+
+	case "down", "j":
+		m.target = min(m.target+1, len(m.targets)-1)
+
+	case "enter":
+		m.mode = browse
+		target := m.targets[m.target]
+		if target.collection.ID == m.collection.ID {
+			m.expand(target.folder)
+		}
+		return m, app.GetInstance().MoveCall(m.moving.ID, target.collection.ID, target.folder)
+	}
+	return m, nil
+}
+
 func (m callModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -86,56 +302,149 @@ func (m callModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case app.CollectionSelectedMsg:
 		m.collection = msg.Collection
-
-		items := []list.Item{}
-		for _, call := range msg.Collection.Calls {
-			items = append(items, call)
-		}
-
-		m.list.Title = zone.Mark("collections_minify", "󰅁 "+m.collection.Name)
-		return m, m.list.SetItems(items)
+		m.mode = browse
+		return m.refresh()
 
 	case app.FetchCollectionsSuccessMsg:
-		items := []list.Item{}
 		for _, c := range msg.Collections {
 			if c.ID == m.collection.ID {
-				for _, call := range c.Calls {
-					items = append(items, call)
-				}
+				m.collection = &c
 				break
 			}
 		}
-
-		m.list.Title = zone.Mark("collections_minify", "󰅁 "+m.collection.Name)
-		return m, m.list.SetItems(items)
+		return m.refresh()
 
 	case tea.WindowSizeMsg:
 		x, y := appStyle.GetFrameSize()
 		m.list.SetSize(msg.Width-x-4, msg.Height-y-2)
+		m.input.Width = msg.Width - x - 10
 
 	case tea.KeyMsg:
+		switch m.mode {
+		case newFolder:
+			return m.updateNewFolder(msg)
+		case move:
+			return m.updateMove(msg)
+		}
 
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
 
+		folder, isFolder := m.list.SelectedItem().(folderItem)
+		call, isCall := m.list.SelectedItem().(callItem)
+
 		switch msg.String() {
 		case "esc":
+			if m.list.FilterState() == list.FilterApplied {
+				break
+			}
 			return m, app.GetInstance().SetSelectedCollection(nil)
 
 		case "enter":
-			i, _ := m.list.SelectedItem().(app.Call)
-			return m, app.GetInstance().SetSelectedCall(&i)
+			if isFolder {
+				m.expanded[folder.path] = !folder.expanded
+				return m.refresh()
+			}
+			if isCall {
+				return m, app.GetInstance().SetSelectedCall(&call.Call)
+			}
+			return m, nil
+
+		case "right":
+			if isFolder && !folder.expanded {
+				m.expanded[folder.path] = true
+				return m.refresh()
+			}
+			return m, nil
+
+		case "left":
+			if isFolder && folder.expanded {
+				m.expanded[folder.path] = false
+				return m.refresh()
+			}
+			// go to the folder holding the row
+			parent := app.CleanFolderPath(call.Folder)
+			if isFolder {
+				parent = app.FolderParent(folder.path)
+			}
+			for i, item := range m.list.Items() {
+				if item, ok := item.(folderItem); ok && parent != "" && item.path == parent {
+					m.list.Select(i)
+				}
+			}
+			return m, nil
+
+		case "n":
+			m.mode = newFolder
+			m.input.SetValue("")
+			if folder := m.currentFolder(); folder != "" {
+				m.input.SetValue(folder + "/")
+			}
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+
+		case "m":
+			if isCall {
+				return m.startMoving(call.Call), nil
+			}
+			return m, nil
+
+		case "e":
+			if isFolder {
+				collection := *m.collection
+				return m, func() tea.Msg {
+					return app.FolderEditMsg{Collection: &collection, Path: folder.path}
+				}
+			}
+			return m, nil
+
+		case "b":
+			if isCall {
+				return m, func() tea.Msg { return app.BenchCallMsg{Call: &call.Call} }
+			}
 		}
 	}
 
+	filtered := m.list.FilterState() != list.Unfiltered
 	newListModel, cmd := m.list.Update(msg)
 	m.list = newListModel
 	cmds = append(cmds, cmd)
 
+	// the filter looks into collapsed folders too
+	if filtered != (m.list.FilterState() != list.Unfiltered) && m.collection != nil {
+		cmds = append(cmds, m.list.SetItems(m.items()))
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
 func (m callModel) View() string {
+	switch m.mode {
+	case newFolder:
+		return appStyle.Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Title,
+			"",
+			"New folder in "+m.collection.Name+":",
+			config.InputStyle.Render(m.input.View()),
+			hintStyle.Render("enter create · esc cancel"),
+		))
+
+	case move:
+		rows := []string{m.list.Title, "", "Move " + m.moving.Title() + " to:", ""}
+		for i, target := range m.targets {
+			style := itemStyle
+			prefix := " "
+			if i == m.target {
+				style = selectedItemStyle
+				prefix = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL).Render("")
+			}
+			rows = append(rows, prefix+style.Render(target.title()))
+		}
+		rows = append(rows, "", hintStyle.Render("enter move · esc cancel"))
+		return appStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	}
+
 	return appStyle.Render(m.list.View())
 }
@@ -33,13 +33,23 @@ func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 				return func() tea.Msg {
 					return app.CollectionEditMsg{Collection: &i}
 				}
+
+			case key.Matches(msg, keys.run):
+				return func() tea.Msg {
+					return app.RunCollectionMsg{Collection: &i}
+				}
+
+			case key.Matches(msg, keys.mock):
+				return func() tea.Msg {
+					return app.MockCollectionMsg{Collection: &i}
+				}
 			}
 		}
 
 		return nil
 	}
 
-	help := []key.Binding{keys.choose, keys.remove}
+	help := []key.Binding{keys.choose, keys.remove, keys.run, keys.mock}
 
 	d.ShortHelpFunc = func() []key.Binding {
 		return help
@@ -56,6 +66,8 @@ type delegateKeyMap struct {
 	choose key.Binding
 	remove key.Binding
 	edit   key.Binding
+	run    key.Binding
+	mock   key.Binding
 }
 
 // Additional short help entries. This satisfies the help.KeyMap interface and
@@ -65,6 +77,8 @@ func (d delegateKeyMap) ShortHelp() []key.Binding {
 		d.choose,
 		d.remove,
 		d.edit,
+		d.run,
+		d.mock,
 	}
 }
 
@@ -76,6 +90,8 @@ func (d delegateKeyMap) FullHelp() [][]key.Binding {
 			d.choose,
 			d.remove,
 			d.edit,
+			d.run,
+			d.mock,
 		},
 	}
 }
@@ -94,5 +110,13 @@ func newDelegateKeyMap() *delegateKeyMap {
 			key.WithKeys("e"),
 			key.WithHelp("e", "edit"),
 		),
+		run: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "run"),
+		),
+		mock: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mock"),
+		),
 	}
 }
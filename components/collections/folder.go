@@ -0,0 +1,260 @@
+package collections
+
+import (
+	"restman/app"
+	"restman/components/config"
+	"restman/components/overlay"
+	"restman/utils"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const INHERIT = "inherit"
+
+const folderFormWidth = 70
+
+// FolderForm is a popup editing the auth, headers and variables of a folder,
+// which are inherited by the calls inside it
+type FolderForm struct {
+	bgRaw        string
+	collectionID string
+	folder       app.Folder
+	method       string
+	inputs       []textinput.Model
+	headers      textarea.Model
+	variables    textarea.Model
+	focused      int
+	footer       Footer
+}
+
+func NewFolderForm(collection app.Collection, path string, bgRaw string) FolderForm {
+	folder := app.Folder{Path: path}
+	if stored := collection.Folder(path); stored != nil {
+		folder = *stored
+	}
+
+	method := INHERIT
+	if folder.Auth != nil {
+		method = folder.Auth.Type
+	}
+
+	newArea := func(placeholder string, value string) textarea.Model {
+		area := textarea.New()
+		area.CharLimit = 0
+		area.Prompt = ""
+		area.ShowLineNumbers = false
+		area.Placeholder = placeholder
+		area.SetWidth(folderFormWidth - 2)
+		area.SetHeight(4)
+		area.SetValue(value)
+		return area
+	}
+
+	f := FolderForm{
+		bgRaw:        bgRaw,
+		collectionID: collection.ID,
+		folder:       folder,
+		method:       method,
+		headers:      newArea("X-Api-Version: 2\n# Disabled-Header: value", folder.Headers.Text()),
+		variables:    newArea("userId = 42", app.VariablesText(folder.Variables)),
+		footer:       Footer{CancelText: "Cancel", OkText: "Save", Width: folderFormWidth},
+	}
+	f.setBasedOnMethod()
+	return f.focus()
+}
+
+func (f *FolderForm) setBasedOnMethod() {
+	auth := app.Auth{}
+	if f.folder.Auth != nil {
+		auth = *f.folder.Auth
+	}
+
+	newInput := func(placeholder string, prompt string, value string) textinput.Model {
+		input := textinput.New()
+		input.Placeholder = placeholder
+		input.Prompt = prompt
+		input.SetValue(value)
+		return input
+	}
+
+	switch f.method {
+	case BASIC_AUTH:
+		f.inputs = []textinput.Model{
+			newInput("username", "  ", auth.Username),
+			newInput("password", "󰌆  ", auth.Password),
+		}
+	case BEARER_TOKEN:
+		f.inputs = []textinput.Model{newInput("token", "󰌆  ", auth.Token)}
+	case API_KEY:
+		f.inputs = []textinput.Model{
+			newInput("header name", "  ", auth.HeaderName),
+			newInput("value", "󰌆  ", auth.HeaderValue),
+		}
+	default:
+		f.inputs = []textinput.Model{}
+	}
+}
+
+func (f *FolderForm) nextMethod() {
+	switch f.method {
+	case INHERIT:
+		f.method = NONE
+	case NONE:
+		f.method = BASIC_AUTH
+	case BASIC_AUTH:
+		f.method = BEARER_TOKEN
+	case BEARER_TOKEN:
+		f.method = API_KEY
+	default:
+		f.method = INHERIT
+	}
+	f.setBasedOnMethod()
+	f.focused = 0
+}
+
+// the fields are the auth inputs, the headers, the variables and the buttons
+func (f FolderForm) numberOfFields() int {
+	return len(f.inputs) + 4
+}
+
+func (f FolderForm) focus() FolderForm {
+	for i := range f.inputs {
+		f.inputs[i].Blur()
+	}
+	f.headers.Blur()
+	f.variables.Blur()
+
+	switch index := f.focused - len(f.inputs); {
+	case index < 0:
+		f.inputs[f.focused].Focus()
+	case index == 0:
+		f.headers.Focus()
+	case index == 1:
+		f.variables.Focus()
+	}
+
+	f.footer.CancelFocused = f.focused == f.numberOfFields()-2
+	f.footer.OkFocused = f.focused == f.numberOfFields()-1
+	return f
+}
+
+// value returns the folder with the values of the form
+func (f FolderForm) value() app.Folder {
+	folder := f.folder
+	folder.Auth = nil
+	if f.method != INHERIT {
+		folder.Auth = &app.Auth{Type: f.method}
+		switch f.method {
+		case BASIC_AUTH:
+			folder.Auth.Username = f.inputs[0].Value()
+			folder.Auth.Password = f.inputs[1].Value()
+		case BEARER_TOKEN:
+			folder.Auth.Token = f.inputs[0].Value()
+		case API_KEY:
+			folder.Auth.HeaderName = f.inputs[0].Value()
+			folder.Auth.HeaderValue = f.inputs[1].Value()
+		}
+	}
+	folder.Headers = app.ParseHeadersText(f.headers.Value())
+	folder.Variables = app.ParseVariablesText(f.variables.Value())
+	return folder
+}
+
+func (f FolderForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f FolderForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEsc:
+			return f, func() tea.Msg { return CreateResultMsg{false} }
+
+		case tea.KeyShiftTab:
+			f.focused = (f.focused - 1 + f.numberOfFields()) % f.numberOfFields()
+			return f.focus(), nil
+
+		case tea.KeyTab:
+			f.focused = (f.focused + 1) % f.numberOfFields()
+			return f.focus(), nil
+
+		case tea.KeyCtrlT:
+			f.nextMethod()
+			return f.focus(), nil
+
+		case tea.KeyCtrlS:
+			return f.save()
+
+		case tea.KeyEnter:
+			if f.focused == f.numberOfFields()-2 {
+				return f, func() tea.Msg { return CreateResultMsg{false} }
+			}
+			if f.focused == f.numberOfFields()-1 {
+				return f.save()
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch index := f.focused - len(f.inputs); {
+	case index < 0:
+		f.inputs[f.focused], cmd = f.inputs[f.focused].Update(msg)
+	case index == 0:
+		f.headers, cmd = f.headers.Update(msg)
+	case index == 1:
+		f.variables, cmd = f.variables.Update(msg)
+	}
+	return f, cmd
+}
+
+func (f FolderForm) save() (tea.Model, tea.Cmd) {
+	return f, tea.Batch(
+		app.GetInstance().UpdateFolder(f.collectionID, f.value()),
+		func() tea.Msg { return CreateResultMsg{false} },
+	)
+}
+
+func (f FolderForm) methodName() string {
+	switch f.method {
+	case INHERIT:
+		return "Inherit"
+	case BASIC_AUTH:
+		return "Basic Auth"
+	case BEARER_TOKEN:
+		return "Bearer Token"
+	case API_KEY:
+		return "API Key"
+	}
+	return "None"
+}
+
+func (f FolderForm) View() string {
+	auth := []string{
+		"Authentication method: " + methodStyle.Padding(0, 1).Render(f.methodName()+" ") + stepStyle.Render("  ctrl+t to change"),
+	}
+	for _, input := range f.inputs {
+		auth = append(auth, config.InputStyle.Render(input.View()))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		config.BoxHeader.Render("Edit folder "+f.folder.Path),
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, auth...),
+		" ",
+		config.LabelStyle.Render("Headers:"),
+		f.headers.View(),
+		" ",
+		config.LabelStyle.Render("Variables:"),
+		f.variables.View(),
+		" ",
+		f.footer.View(),
+	)
+
+	popup := general.Render(content)
+	startCol, startRow := utils.GetStartColRow(popup, f.bgRaw)
+	return overlay.PlaceOverlay(startCol, startRow, popup, f.bgRaw)
+}
@@ -27,6 +27,8 @@ const (
 	PUT    = "PUT"
 	DELETE = "DELETE"
 	PATCH  = "PATCH"
+	WS     = "WS"
+	GRPC   = "GRPC"
 )
 
 var methodColors = map[string]string{
@@ -35,6 +37,8 @@ var methodColors = map[string]string{
 	PUT:    "#F2C94C",
 	DELETE: "#F25C54",
 	PATCH:  "#6C9EF8",
+	WS:     "#C792EA",
+	GRPC:   "#4FD1C5",
 }
 
 var BoxHeader = lipgloss.NewStyle().
@@ -98,6 +102,8 @@ var Methods = map[string]string{
 	"PUT":    MethodStyle.Background(lipgloss.Color(methodColors["PUT"])).Render("PUT"),
 	"DELETE": MethodStyle.Background(lipgloss.Color(methodColors["DELETE"])).Render("DELETE"),
 	"PATCH":  MethodStyle.Background(lipgloss.Color(methodColors["PATCH"])).Render("PATCH"),
+	"WS":     MethodStyle.Background(lipgloss.Color(methodColors["WS"])).Render("WS"),
+	"GRPC":   MethodStyle.Background(lipgloss.Color(methodColors["GRPC"])).Render("GRPC"),
 }
 
 var MethodsShort = map[string]string{
@@ -106,6 +112,8 @@ var MethodsShort = map[string]string{
 	"PUT":    MethodStyleShort.Foreground(lipgloss.Color(methodColors["PUT"])).Render("PUT"),
 	"DELETE": MethodStyleShort.Foreground(lipgloss.Color(methodColors["DELETE"])).Render("DEL"),
 	"PATCH":  MethodStyleShort.Foreground(lipgloss.Color(methodColors["PATCH"])).Render("PAT"),
+	"WS":     MethodStyleShort.Foreground(lipgloss.Color(methodColors["WS"])).Render("WS "),
+	"GRPC":   MethodStyleShort.Foreground(lipgloss.Color(methodColors["GRPC"])).Render("RPC"),
 }
 
 type WindowFocusedMsg struct {
@@ -123,6 +131,15 @@ type KeyMap struct {
 	ChangeActivePanel key.Binding
 	Save              key.Binding
 	ChangeToggle      key.Binding
+	Diff              key.Binding
+	SaveExample       key.Binding
+	Environment       key.Binding
+	Bench             key.Binding
+	NewTab            key.Binding
+	CloseTab          key.Binding
+	NextTab           key.Binding
+	PrevTab           key.Binding
+	Revert            key.Binding
 }
 
 func SetVersion(v string) {
@@ -140,7 +157,8 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right, k.ChangeActivePanel, k.Help, k.Quit},
-		{k.NewCollection, k.Save, k.ChangeToggle},
+		{k.NewCollection, k.Save, k.ChangeToggle, k.Diff, k.SaveExample, k.Environment, k.Bench},
+		{k.NewTab, k.CloseTab, k.NextTab, k.PrevTab, k.Revert},
 	}
 }
 
@@ -185,4 +203,40 @@ var Keys = KeyMap{
 		key.WithKeys("ctrl+t"),
 		key.WithHelp("ctrl+t", "change toggle"),
 	),
+	Diff: key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "diff responses"),
+	),
+	SaveExample: key.NewBinding(
+		key.WithKeys("ctrl+k"),
+		key.WithHelp("ctrl+k", "save as example"),
+	),
+	Environment: key.NewBinding(
+		key.WithKeys("alt+e"),
+		key.WithHelp("alt+e", "next environment"),
+	),
+	Bench: key.NewBinding(
+		key.WithKeys("alt+b"),
+		key.WithHelp("alt+b", "benchmark call"),
+	),
+	NewTab: key.NewBinding(
+		key.WithKeys("alt+t"),
+		key.WithHelp("alt+t", "new tab"),
+	),
+	CloseTab: key.NewBinding(
+		key.WithKeys("alt+w"),
+		key.WithHelp("alt+w", "close tab"),
+	),
+	NextTab: key.NewBinding(
+		key.WithKeys("alt+l"),
+		key.WithHelp("alt+l", "next tab"),
+	),
+	PrevTab: key.NewBinding(
+		key.WithKeys("alt+h"),
+		key.WithHelp("alt+h", "previous tab"),
+	),
+	Revert: key.NewBinding(
+		key.WithKeys("alt+r"),
+		key.WithHelp("alt+r", "revert to saved"),
+	),
 }
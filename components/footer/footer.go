@@ -5,6 +5,7 @@ import (
 	"restman/components/config"
 	"restman/utils"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/stopwatch"
@@ -25,6 +26,9 @@ var (
 
 	nameStyle = lipgloss.NewStyle().
 			Foreground(config.COLOR_HIGHLIGHT).Underline(true)
+
+	envStyle = lipgloss.NewStyle().
+			Foreground(config.COLOR_SPECIAL)
 )
 
 // model represents the properties of the UI.
@@ -37,6 +41,10 @@ type model struct {
 	loading    bool
 	statusCode int
 	error      error
+	notice     string
+	rate       float64
+	grpcStatus string
+	env        string
 }
 
 // New creates a new instance of the UI.
@@ -60,10 +68,53 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case app.OnLoadingMsg:
 		m.error = nil
+		m.notice = ""
 		m.url = msg.Call.Url
 		m.loading = true
+		m.bytes = 0
+		m.rate = 0
+		m.grpcStatus = ""
 		return m, tea.Sequence(m.stopwatch.Reset(), m.stopwatch.Start())
 
+	case app.OnEventStreamMsg:
+		m.statusCode = msg.Response.StatusCode
+		m.loading = false
+		return m, m.stopwatch.Stop()
+
+	case app.OnWebSocketOpenMsg:
+		m.statusCode = msg.Response.StatusCode
+		m.loading = false
+		return m, m.stopwatch.Stop()
+
+	case app.OnGRPCStatusMsg:
+		m.statusCode = 0
+		m.grpcStatus = msg.Code.String()
+		if msg.Message != "" {
+			m.grpcStatus += " " + msg.Message
+		}
+		m.loading = false
+		return m, m.stopwatch.Stop()
+
+	case app.FetchCollectionsSuccessMsg:
+		// environments are read together with the collections
+		if env := app.GetInstance().SelectedEnvironment; env != nil {
+			m.env = env.Name
+		}
+		if msg.Err != nil {
+			m.error = msg.Err
+		}
+		m.notice = msg.Notice
+
+	case app.EnvironmentSelectedMsg:
+		m.env = ""
+		if msg.Environment != nil {
+			m.env = msg.Environment.Name
+		}
+
+	case app.OnProgressMsg:
+		m.bytes = msg.Bytes
+		m.rate = msg.Rate
+
 	case app.OnResponseMsg:
 		if msg.Err == nil {
 			m.statusCode = msg.Response.StatusCode
@@ -85,10 +136,22 @@ func (m model) View() string {
 	var color string
 	if m.loading {
 		status = "󰞉 LOADING"
+		if m.bytes > 0 {
+			status = status + "   RECEIVED: " + utils.ByteCountIEC(m.bytes) + "   RATE: " + utils.ByteCountIEC(int64(m.rate)) + "/s"
+		}
 		color = "#F59E0B"
 	} else if m.error != nil {
 		status = " ERROR: " + m.error.Error()
 		color = "#EF4444"
+	} else if m.notice != "" {
+		status = " NOTICE: " + m.notice
+		color = "#F59E0B"
+	} else if m.grpcStatus != "" {
+		status = "󰞉 STATUS: " + m.grpcStatus
+		color = "#EF4444"
+		if strings.HasPrefix(m.grpcStatus, "OK") {
+			color = "#34D399"
+		}
 	} else if m.statusCode > 0 {
 		status = "󰞉 STATUS: " + strconv.Itoa(m.statusCode)
 		if m.bytes > 0 {
@@ -124,10 +187,18 @@ func (m model) View() string {
 			lipgloss.PlaceHorizontal(
 				m.width-statusWidth-1,
 				lipgloss.Right,
-				nameStyle.Render("Restman")+
+				envStyle.Render(m.envView())+nameStyle.Render("Restman")+
 
 					versionStyle.Render(" v."+config.GetVersion()),
 			),
 		),
 	)
 }
+
+// envView shows the selected environment, if any
+func (m model) envView() string {
+	if m.env == "" {
+		return ""
+	}
+	return "ENV: " + m.env + "   "
+}
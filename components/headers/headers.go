@@ -3,66 +3,111 @@ package headers
 import (
 	"restman/app"
 	"restman/components/config"
-	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evertras/bubble-table/table"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 const (
-	columnKeyKey   = "key"
-	columnKeyValue = "value"
+	columnKeyEnabled = "enabled"
+	columnKeyKey     = "key"
+	columnKeyValue   = "value"
 )
 
-var styleBase = lipgloss.NewStyle().
-	Foreground(config.COLOR_FOREGROUND).
-	Bold(false).
-	BorderForeground(config.COLOR_SUBTLE)
+var (
+	styleBase = lipgloss.NewStyle().
+			Foreground(config.COLOR_FOREGROUND).
+			Bold(false).
+			BorderForeground(config.COLOR_SUBTLE)
+
+	disabledStyle = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	helpStyle     = lipgloss.NewStyle().Foreground(config.COLOR_GRAY).Padding(0, 1)
+)
 
 const (
 	view = iota
 	add
 	edit
+	bulk
 )
 
+// AddHeaderMsg starts adding a header, e.g. from the add link
+type AddHeaderMsg struct{}
+
 type Model struct {
 	mode        int
 	width       int
 	height      int
+	cursor      int
+	focused     int
+	inputs      []textinput.Model
+	textarea    textarea.Model
 	simpleTable table.Model
 	call        *app.Call
 }
 
-func GetRows(headers []string) []table.Row {
+func GetRows(headers app.Headers) []table.Row {
 	rows := make([]table.Row, 0, len(headers))
 	for _, header := range headers {
-		// split : to get key and value
-		parts := strings.Split(header, ":")
+		enabled := "󰄲"
+		style := styleBase
+		if header.Disabled {
+			enabled = "󰄱"
+			style = disabledStyle
+		}
 		row := table.NewRow(table.RowData{
-			columnKeyKey:   " " + parts[0],
-			columnKeyValue: " " + parts[1],
-		})
+			columnKeyEnabled: " " + enabled,
+			columnKeyKey:     " " + header.Key,
+			columnKeyValue:   " " + header.Value,
+		}).WithStyle(style)
 		rows = append(rows, row)
 	}
 	return rows
 }
 
 func New(call *app.Call, width int, height int) Model {
-
-	headers := []string{}
+	headers := app.Headers{}
 	if call != nil {
 		headers = call.Headers
 	}
 
+	inputs := make([]textinput.Model, 2)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ""
+		inputs[i].Width = (width - 30) / 2
+		inputs[i].ShowSuggestions = true
+		// tab moves between the panes, right accepts the suggestion
+		inputs[i].KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("right"))
+	}
+	inputs[0].Placeholder = "Content-Type"
+	inputs[0].SetSuggestions(app.StandardHeaders)
+	inputs[1].Placeholder = "value"
+
+	ta := textarea.New()
+	ta.CharLimit = 0
+	ta.Prompt = ""
+	ta.Placeholder = "Content-Type: application/json\n# Disabled-Header: value"
+	ta.ShowLineNumbers = false
+	ta.SetWidth(width - 4)
+	ta.SetHeight(max(height-12, 5))
+
 	return Model{
-		mode:   view,
-		call:   call,
-		width:  width,
-		height: height,
+		mode:     view,
+		call:     call,
+		width:    width,
+		height:   height,
+		inputs:   inputs,
+		textarea: ta,
 		simpleTable: table.New([]table.Column{
+			table.NewColumn(columnKeyEnabled, "", 3),
 			table.NewColumn(columnKeyKey, " Key", 20),
-			table.NewColumn(columnKeyValue, " Value", width-23),
+			table.NewColumn(columnKeyValue, " Value", width-27),
 		}).WithRows(GetRows(headers)).BorderRounded().
 			WithBaseStyle(styleBase).
 			Focused(true),
@@ -73,60 +118,195 @@ func (m Model) Init() tea.Cmd {
 	return nil
 }
 
+// save refreshes the table after the headers of the call changed
+func (m Model) save() (Model, tea.Cmd) {
+	m.cursor = max(min(m.cursor, len(m.call.Headers)-1), 0)
+	m.simpleTable = m.simpleTable.WithRows(GetRows(m.call.Headers)).WithHighlightedRow(m.cursor)
+	call := m.call
+	return m, func() tea.Msg {
+		return app.CallUpdatedMsg{Call: call}
+	}
+}
+
+func (m Model) startEditing(mode int) (Model, tea.Cmd) {
+	m.mode = mode
+	if mode == bulk {
+		m.textarea.SetValue(m.call.Headers.Text())
+		m.textarea.Focus()
+		return m, textarea.Blink
+	}
+
+	m.focused = 0
+	header := app.Header{}
+	if mode == edit {
+		header = m.call.Headers[m.cursor]
+	}
+	m.inputs[0].SetValue(header.Key)
+	m.inputs[1].SetValue(header.Value)
+	m.inputs[1].SetSuggestions(app.HeaderValueSuggestions(header.Key))
+	m.inputs[0].Focus()
+	m.inputs[1].Blur()
+	return m, textinput.Blink
+}
+
+func (m Model) updateEditing(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = view
+		return m, nil
+
+	case "enter":
+		if m.focused == 0 {
+			m.focused = 1
+			m.inputs[1].SetSuggestions(app.HeaderValueSuggestions(m.inputs[0].Value()))
+			m.inputs[0].Blur()
+			return m, m.inputs[1].Focus()
+		}
+		header := app.ParseHeader(m.inputs[0].Value() + ":" + m.inputs[1].Value())
+		adding := m.mode == add
+		m.mode = view
+		if header.Key == "" {
+			return m, nil
+		}
+		return m.saveHeader(header, adding)
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	return m, cmd
+}
+
+func (m Model) saveHeader(header app.Header, adding bool) (Model, tea.Cmd) {
+	headers := append(app.Headers{}, m.call.Headers...)
+	if adding {
+		headers = append(headers, header)
+		m.cursor = len(headers) - 1
+	} else {
+		header.Disabled = headers[m.cursor].Disabled
+		headers[m.cursor] = header
+	}
+	m.call.Headers = headers
+	return m.save()
+}
+
+func (m Model) updateBulk(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		m.mode = view
+		m.textarea.Blur()
+		return m.save()
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	m.call.Headers = app.ParseHeadersText(m.textarea.Value())
+	return m, cmd
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var (
-		cmd  tea.Cmd
-		cmds []tea.Cmd
-	)
+	if m.call == nil {
+		return m, nil
+	}
 
 	switch msg := msg.(type) {
+	case app.CallUpdatedMsg:
+		if msg.Call == m.call && m.mode == view {
+			m.simpleTable = m.simpleTable.WithRows(GetRows(m.call.Headers)).WithHighlightedRow(m.cursor)
+		}
+
+	case AddHeaderMsg:
+		if m.mode == view {
+			return m.startEditing(add)
+		}
+
 	case tea.KeyMsg:
-		{
-			switch msg.String() {
-			case "a":
-				if m.mode == view {
-					m.mode = add
-
-				}
-
-			case "x":
-				if m.call != nil && m.call.HeadersCount() > 0 {
-					key := strings.TrimSpace(m.simpleTable.HighlightedRow().Data[columnKeyKey].(string))
-					headers := []string{}
-					for _, header := range m.call.Headers {
-						if key != strings.Split(header, ":")[0] {
-							headers = append(headers, header)
-						}
-					}
-					m.call.Headers = headers
-
-					cmd := func() tea.Msg {
-						return app.CallUpdatedMsg{Call: m.call}
-					}
-					m.simpleTable = m.simpleTable.WithRows(GetRows(m.call.Headers))
-					cmds = append(cmds, cmd)
-				}
-			}
+		switch m.mode {
+		case add, edit:
+			return m.updateEditing(msg)
+		case bulk:
+			return m.updateBulk(msg)
 		}
-	case app.CallUpdatedMsg:
-		m.call = msg.Call
 
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+			m.simpleTable = m.simpleTable.WithHighlightedRow(m.cursor)
+
+		case "down", "j":
+			m.cursor = min(m.cursor+1, max(len(m.call.Headers)-1, 0))
+			m.simpleTable = m.simpleTable.WithHighlightedRow(m.cursor)
+
+		case "a":
+			return m.startEditing(add)
+
+		case "b":
+			return m.startEditing(bulk)
+
+		case "enter", "e":
+			if len(m.call.Headers) > 0 {
+				return m.startEditing(edit)
+			}
+
+		case "x", "delete":
+			if len(m.call.Headers) > 0 {
+				headers := append(app.Headers{}, m.call.Headers[:m.cursor]...)
+				m.call.Headers = append(headers, m.call.Headers[m.cursor+1:]...)
+				return m.save()
+			}
+
+		case " ":
+			if len(m.call.Headers) > 0 {
+				headers := append(app.Headers{}, m.call.Headers...)
+				headers[m.cursor].Disabled = !headers[m.cursor].Disabled
+				m.call.Headers = headers
+				return m.save()
+			}
+		}
 	}
 
-	m.simpleTable, cmd = m.simpleTable.Update(msg)
-	cmds = append(cmds, cmd)
+	return m, nil
+}
 
-	return m, tea.Batch(cmds...)
+func (m Model) editView() string {
+	title := "Add Header"
+	if m.mode == edit {
+		title = "Edit Header"
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		config.BoxHeader.Render(title),
+		lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			config.LabelStyle.Render("Key:"), config.InputStyle.Render(m.inputs[0].View()),
+		),
+		lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			config.LabelStyle.Render("Value:"), config.InputStyle.Render(m.inputs[1].View()),
+		),
+		helpStyle.Render("→ complete · ↑/↓ other suggestions · enter next/save · esc cancel"),
+	)
 }
 
 func (m Model) View() string {
-	if m.mode == add {
-		return config.BoxHeader.Render("Add Header")
+	if m.mode == bulk {
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			config.BoxHeader.Render("Bulk Edit Headers"),
+			m.textarea.View(),
+			helpStyle.Render("one `Key: Value` per line, # disables a header · esc done"),
+		)
 	}
 
 	content := config.EmptyMessageStyle.Padding(2, 2).Render("No headers defined.")
 	if m.call != nil && len(m.call.Headers) > 0 {
 		content = m.simpleTable.View()
 	}
-	return content + "\n" + config.LinkStyle.Padding(0, 1).Foreground(config.COLOR_LINK).Render("+ Add Header")
+	if m.call == nil {
+		return content
+	}
+	content += "\n" + zone.Mark("add_header", config.LinkStyle.Padding(0, 1).Foreground(config.COLOR_LINK).Render("+ Add Header")) +
+		helpStyle.Render("a add · e edit · x delete · space toggle · b bulk edit")
+	if m.mode != view {
+		content += "\n" + m.editView()
+	}
+	return content
 }
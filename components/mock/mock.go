@@ -0,0 +1,301 @@
+package mock
+
+import (
+	"fmt"
+	"net"
+	"restman/app"
+	"restman/components/config"
+	"restman/components/overlay"
+	"restman/components/popup"
+	"restman/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	PORT_IDX = iota
+	LATENCY_IDX
+	JITTER_IDX
+	ERROR_RATE_IDX
+	ERROR_STATUS_IDX
+	CANCEL_IDX
+	OK_IDX
+)
+
+const NUM_OF_INPUTS = 7
+
+// number of requests kept in the log
+const maxLogEntries = 200
+
+var (
+	general = lipgloss.NewStyle().
+		UnsetAlign().
+		Padding(0, 1, 0, 1).
+		Foreground(config.COLOR_FOREGROUND).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(config.COLOR_HIGHLIGHT)
+
+	grayStyle   = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	passedStyle = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	failedStyle = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+)
+
+// Mock is a popup serving a collection from a mock server, first showing
+// the options and then the live request log
+type Mock struct {
+	collection app.Collection
+	width      int
+	height     int
+	bgRaw      string
+	focused    int
+	inputs     []textinput.Model
+	errors     []string
+
+	running bool
+	stopped bool
+	addr    string
+	routes  []string
+	entries []app.MockLogEntry
+	err     error
+}
+
+func New(collection app.Collection, bgRaw string, width int) Mock {
+	inputs := make([]textinput.Model, ERROR_STATUS_IDX+1)
+	placeholders := []string{"8080", "0ms", "0ms, random extra latency", "0%", "500"}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = "󱞩 "
+		inputs[i].Placeholder = placeholders[i]
+	}
+	inputs[PORT_IDX].Focus()
+
+	return Mock{
+		collection: collection,
+		width:      width,
+		height:     len(strings.Split(bgRaw, "\n")) - 4,
+		bgRaw:      bgRaw,
+		inputs:     inputs,
+	}
+}
+
+// Init initializes the popup.
+func (c Mock) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// options reads the address and the mock options from the inputs
+func (c Mock) options() (string, app.MockOptions, []string) {
+	errors := []string{}
+	opts := app.MockOptions{}
+
+	port := 8080
+	if value := strings.TrimSpace(c.inputs[PORT_IDX].Value()); value != "" {
+		var err error
+		port, err = strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			errors = append(errors, "Port must be a number from 1 to 65535")
+		}
+	}
+	if value := strings.TrimSpace(c.inputs[LATENCY_IDX].Value()); value != "" {
+		latency, err := app.ParseMilliseconds(value)
+		if err != nil {
+			errors = append(errors, "Latency must be milliseconds or a duration like 1.5s")
+		}
+		opts.Latency = latency
+	}
+	if value := strings.TrimSpace(c.inputs[JITTER_IDX].Value()); value != "" {
+		jitter, err := app.ParseMilliseconds(value)
+		if err != nil {
+			errors = append(errors, "Jitter must be milliseconds or a duration like 1.5s")
+		}
+		opts.Jitter = jitter
+	}
+	if value := strings.TrimSuffix(strings.TrimSpace(c.inputs[ERROR_RATE_IDX].Value()), "%"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 100 {
+			errors = append(errors, "Error rate must be a percentage from 0 to 100")
+		}
+		opts.ErrorRate = rate / 100
+	}
+	if value := strings.TrimSpace(c.inputs[ERROR_STATUS_IDX].Value()); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil || status < 100 || status > 599 {
+			errors = append(errors, "Error status must be a status code")
+		}
+		opts.ErrorStatus = status
+	}
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), opts, errors
+}
+
+// Update handles messages.
+func (c Mock) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.OnMockStartedMsg:
+		c.addr = msg.Addr
+		c.routes = msg.Routes
+		return c, nil
+
+	case app.OnMockRequestMsg:
+		c.entries = append(c.entries, msg.Entry)
+		if len(c.entries) > maxLogEntries {
+			c.entries = c.entries[len(c.entries)-maxLogEntries:]
+		}
+		return c, nil
+
+	case app.OnMockStoppedMsg:
+		c.running = false
+		c.stopped = true
+		c.err = msg.Err
+		return c, nil
+
+	case tea.KeyMsg:
+		if c.running || c.stopped {
+			switch msg.String() {
+			case "esc", "q":
+				app.GetInstance().StopMock()
+				return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+			case "c":
+				c.entries = nil
+			}
+			return c, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyShiftTab, tea.KeyCtrlP, tea.KeyUp:
+			c.focused = (c.focused - 1 + NUM_OF_INPUTS) % NUM_OF_INPUTS
+
+		case tea.KeyTab, tea.KeyCtrlN, tea.KeyDown:
+			c.focused = (c.focused + 1) % NUM_OF_INPUTS
+
+		case tea.KeyEnter:
+			if c.focused == CANCEL_IDX {
+				return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+			}
+			var addr string
+			var opts app.MockOptions
+			addr, opts, c.errors = c.options()
+			if len(c.errors) > 0 {
+				return c, nil
+			}
+			c.running = true
+			return c, app.GetInstance().StartMock(c.collection, addr, opts)
+
+		case tea.KeyEsc:
+			return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i := range c.inputs {
+		if i == c.focused {
+			c.inputs[i].Focus()
+		} else {
+			c.inputs[i].Blur()
+		}
+		var cmd tea.Cmd
+		c.inputs[i], cmd = c.inputs[i].Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return c, tea.Batch(cmds...)
+}
+
+func (c Mock) optionsView() string {
+	labels := []string{"Port:", "Latency:", "Jitter:", "Error rate:", "Error status:"}
+	rows := []string{}
+	for i, input := range c.inputs {
+		rows = append(rows, config.LabelStyle.Render(labels[i]), config.InputStyle.Render(input.View()))
+	}
+
+	okButtonStyle := config.ButtonStyle
+	cancelButtonStyle := config.ButtonStyle
+	if c.focused == CANCEL_IDX {
+		cancelButtonStyle = config.ActiveButtonStyle
+	} else if c.focused == OK_IDX {
+		okButtonStyle = config.ActiveButtonStyle
+	}
+	footer := lipgloss.PlaceHorizontal(
+		c.width,
+		lipgloss.Right,
+		lipgloss.JoinHorizontal(lipgloss.Right, cancelButtonStyle.Render("Cancel"), " ", okButtonStyle.Render("Start")),
+	)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		append(rows, utils.RenderErrors(c.errors), footer)...,
+	)
+}
+
+// logView renders the routes and the latest requests which fit the popup
+func (c Mock) logView() string {
+	rows := []string{}
+	switch {
+	case c.err != nil:
+		rows = append(rows, failedStyle.Render(c.err.Error()))
+	case c.stopped:
+		rows = append(rows, "Stopped")
+	case c.addr == "":
+		rows = append(rows, "Starting…")
+	default:
+		rows = append(rows, "Listening on "+passedStyle.Render("http://"+c.addr))
+	}
+	rows = append(rows, "")
+
+	routes := c.routes
+	if len(routes) > 8 {
+		routes = append(routes[:7:7], fmt.Sprintf("… %d more", len(c.routes)-7))
+	}
+	for _, route := range routes {
+		rows = append(rows, grayStyle.MaxWidth(c.width).Render("  "+route))
+	}
+	rows = append(rows, "")
+
+	lines := []string{}
+	for _, entry := range c.entries {
+		status := passedStyle.Render(strconv.Itoa(entry.Status))
+		if entry.Status >= 400 {
+			status = failedStyle.Render(strconv.Itoa(entry.Status))
+		}
+		source := entry.Source
+		if entry.Call != "" {
+			source = entry.Call + " · " + source
+		}
+		line := fmt.Sprintf("%s %-7s %-36s %s %-8s %s",
+			entry.Time.Format("15:04:05"), entry.Method, entry.Path, status,
+			entry.Duration.Round(time.Millisecond), grayStyle.Render(source))
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(c.width).Render(line))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, grayStyle.Render("No requests yet"))
+	}
+	if visible := max(c.height-len(rows)-6, 1); len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		append(append(rows, lines...), "", grayStyle.Render("c clear · esc stop"))...,
+	)
+}
+
+func (c Mock) View() string {
+	content := c.optionsView()
+	if c.running || c.stopped {
+		content = c.logView()
+	}
+
+	formView := lipgloss.JoinVertical(
+		lipgloss.Left,
+		config.BoxHeader.Render("Mock "+c.collection.Name),
+		"",
+		content,
+	)
+
+	view := general.Width(c.width).Render(formView)
+	startCol, startRow := utils.GetStartColRow(view, c.bgRaw)
+	return overlay.PlaceOverlay(startCol, startRow, view, c.bgRaw)
+}
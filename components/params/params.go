@@ -1,93 +1,366 @@
 package params
 
 import (
-	"net/url"
 	"restman/app"
 	"restman/components/config"
-	"sort"
-	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evertras/bubble-table/table"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 const (
-	columnKeyKey   = "key"
-	columnKeyValue = "value"
+	columnKeyEnabled     = "enabled"
+	columnKeyKey         = "key"
+	columnKeyValue       = "value"
+	columnKeyDescription = "description"
 )
 
-var styleBase = lipgloss.NewStyle().
-	Foreground(config.COLOR_FOREGROUND).
-	Bold(false).
-	BorderForeground(config.COLOR_SUBTLE)
+var (
+	styleBase = lipgloss.NewStyle().
+			Foreground(config.COLOR_FOREGROUND).
+			Bold(false).
+			BorderForeground(config.COLOR_SUBTLE)
 
+	disabledStyle = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	missingStyle  = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+	helpStyle     = lipgloss.NewStyle().Foreground(config.COLOR_GRAY).Padding(0, 1)
+	sectionStyle  = lipgloss.NewStyle().Bold(true).Padding(0, 1)
+)
+
+const (
+	view = iota
+	add
+	edit
+	editPath
+)
+
+// AddParamMsg starts adding a param, e.g. from the add link
+type AddParamMsg struct{}
+
+// Model edits the path parameters and the query params of a call. The
+// cursor moves over the rows of both tables, the path rows come first.
 type Model struct {
+	mode        int
 	width       int
 	height      int
 	call        *app.Call
+	path        []app.PathParam
+	params      []app.Param
+	cursor      int
+	inputs      []textinput.Model
+	focused     int
+	pathTable   table.Model
 	simpleTable table.Model
-	items       url.Values
 }
 
 func New(call *app.Call, width int, height int) Model {
-	items := make(map[string][]string)
-	if call != nil {
-		u, err := url.Parse(call.Url)
-		if err == nil && call.Url != "" {
-			items, _ = url.ParseQuery(u.RawQuery)
-		}
+	inputs := make([]textinput.Model, 2)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ""
+		inputs[i].Width = (width - 30) / 2
 	}
+	inputs[0].Placeholder = "key"
+	inputs[1].Placeholder = "value"
 
-	// sort items
-	keys := make([]string, 0, len(items))
-	for k := range items {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	rows := make([]table.Row, 0, len(items))
-	for _, key := range keys {
-		row := table.NewRow(table.RowData{
-			columnKeyKey:   " " + key,
-			columnKeyValue: " " + strings.Join(items[key], ", "),
-		})
-		rows = append(rows, row)
-	}
-
-	return Model{
+	m := Model{
+		mode:   view,
 		call:   call,
-		items:  items,
 		width:  width,
 		height: height,
+		inputs: inputs,
+		pathTable: table.New([]table.Column{
+			table.NewColumn(columnKeyKey, " Name", 20),
+			table.NewColumn(columnKeyValue, " Value", (width-26)/2),
+			table.NewColumn(columnKeyDescription, " Description", (width-26)-(width-26)/2),
+		}).BorderRounded().
+			WithBaseStyle(styleBase),
 		simpleTable: table.New([]table.Column{
+			table.NewColumn(columnKeyEnabled, "", 3),
 			table.NewColumn(columnKeyKey, " Key", 20),
-			table.NewColumn(columnKeyValue, " Value", width-25),
-		}).WithRows(rows).BorderRounded().
-			WithBaseStyle(styleBase).
-			Focused(true),
+			table.NewColumn(columnKeyValue, " Value", width-29),
+		}).BorderRounded().
+			WithBaseStyle(styleBase),
 	}
+	return m.sync()
+}
+
+// sync reads the params from the call and refreshes the tables
+func (m Model) sync() Model {
+	m.path = []app.PathParam{}
+	m.params = []app.Param{}
+	if m.call != nil {
+		m.path = m.call.PathParamsList()
+		m.params = m.call.QueryParams()
+	}
+	return m.refresh()
+}
+
+func (m Model) refresh() Model {
+	pathRows := make([]table.Row, 0, len(m.path))
+	for _, param := range m.path {
+		value := param.Value
+		if param.Value == "" && param.Default != "" {
+			value = disabledStyle.Render(param.Default + " (default)")
+		} else if param.Value == "" {
+			value = missingStyle.Render("required")
+		}
+		pathRows = append(pathRows, table.NewRow(table.RowData{
+			columnKeyKey:         " " + param.Name,
+			columnKeyValue:       " " + value,
+			columnKeyDescription: " " + param.Description,
+		}))
+	}
+
+	rows := make([]table.Row, 0, len(m.params))
+	for _, param := range m.params {
+		enabled := "󰄲"
+		style := styleBase
+		if param.Disabled {
+			enabled = "󰄱"
+			style = disabledStyle
+		}
+		rows = append(rows, table.NewRow(table.RowData{
+			columnKeyEnabled: " " + enabled,
+			columnKeyKey:     " " + param.Key,
+			columnKeyValue:   " " + param.Value,
+		}).WithStyle(style))
+	}
+
+	m.cursor = max(min(m.cursor, len(m.path)+len(m.params)-1), 0)
+	m.pathTable = m.pathTable.WithRows(pathRows)
+	m.simpleTable = m.simpleTable.WithRows(rows)
+	return m.highlight()
+}
+
+// highlight shows the cursor in the table it is in
+func (m Model) highlight() Model {
+	inPath := m.cursor < len(m.path)
+	m.pathTable = m.pathTable.Focused(inPath)
+	m.simpleTable = m.simpleTable.Focused(!inPath)
+	if inPath {
+		m.pathTable = m.pathTable.WithHighlightedRow(m.cursor)
+	} else {
+		m.simpleTable = m.simpleTable.WithHighlightedRow(m.cursor - len(m.path))
+	}
+	return m
+}
+
+// save writes the params to the call, which re-encodes its URL
+func (m Model) save() (Model, tea.Cmd) {
+	m.call.SetQueryParams(m.params)
+	m = m.refresh()
+	call := m.call
+	return m, func() tea.Msg {
+		return app.CallUpdatedMsg{Call: call}
+	}
+}
+
+func (m Model) startEditing(mode int) (Model, tea.Cmd) {
+	m.mode = mode
+	m.focused = 0
+	key, value := "", ""
+	switch mode {
+	case edit:
+		param := m.params[m.cursor-len(m.path)]
+		key, value = param.Key, param.Value
+	case editPath:
+		key, value = m.path[m.cursor].Name, m.path[m.cursor].Value
+		m.inputs[1].Placeholder = m.path[m.cursor].Default
+		m.focused = 1
+	}
+	m.inputs[0].SetValue(key)
+	m.inputs[1].SetValue(value)
+	m.inputs[0].Blur()
+	m.inputs[1].Blur()
+	return m, tea.Batch(m.inputs[m.focused].Focus(), textinput.Blink)
 }
 
 func (m Model) Init() tea.Cmd {
 	return nil
 }
 
-func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var (
-		cmd  tea.Cmd
-		cmds []tea.Cmd
-	)
+func (m Model) updateEditing(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = view
+		m.inputs[1].Placeholder = "value"
+		return m, nil
 
-	m.simpleTable, cmd = m.simpleTable.Update(msg)
-	cmds = append(cmds, cmd)
+	case "up", "down":
+		if m.mode != editPath {
+			m.focused = 1 - m.focused
+		}
+
+	case "enter":
+		if m.focused == 0 {
+			m.focused = 1
+			break
+		}
+		if m.mode == editPath {
+			m.mode = view
+			m.inputs[1].Placeholder = "value"
+			m.call.SetPathParamValue(m.path[m.cursor].Name, m.inputs[1].Value())
+			m = m.sync()
+			call := m.call
+			return m, func() tea.Msg {
+				return app.CallUpdatedMsg{Call: call}
+			}
+		}
 
+		param := app.Param{Key: m.inputs[0].Value(), Value: m.inputs[1].Value()}
+		if param.Key == "" && param.Value == "" {
+			m.mode = view
+			return m, nil
+		}
+		if m.mode == add {
+			m.params = append(m.params, param)
+			m.cursor = len(m.path) + len(m.params) - 1
+		} else {
+			index := m.cursor - len(m.path)
+			m.params[index] = m.params[index].Edited(param.Key, param.Value)
+		}
+		m.mode = view
+		return m.save()
+	}
+
+	var cmds []tea.Cmd
+	for i := range m.inputs {
+		if i == m.focused {
+			m.inputs[i].Focus()
+		} else {
+			m.inputs[i].Blur()
+		}
+		var cmd tea.Cmd
+		m.inputs[i], cmd = m.inputs[i].Update(msg)
+		cmds = append(cmds, cmd)
+	}
 	return m, tea.Batch(cmds...)
 }
 
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.call == nil {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case app.CallUpdatedMsg:
+		// typing in the URL changes the params
+		if m.mode == view && msg.Call == m.call {
+			return m.sync(), nil
+		}
+
+	case AddParamMsg:
+		if m.mode == view {
+			return m.startEditing(add)
+		}
+
+	case tea.KeyMsg:
+		if m.mode != view {
+			return m.updateEditing(msg)
+		}
+
+		// index of the query param under the cursor, negative in the path
+		// table
+		index := m.cursor - len(m.path)
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+			return m.highlight(), nil
+
+		case "down", "j":
+			m.cursor = min(m.cursor+1, max(len(m.path)+len(m.params)-1, 0))
+			return m.highlight(), nil
+
+		case "a":
+			return m.startEditing(add)
+
+		case "enter", "e":
+			if index < 0 {
+				return m.startEditing(editPath)
+			}
+			if index < len(m.params) {
+				return m.startEditing(edit)
+			}
+
+		case "x", "delete":
+			if index >= 0 && index < len(m.params) {
+				m.params = append(m.params[:index:index], m.params[index+1:]...)
+				return m.save()
+			}
+
+		case " ":
+			if index >= 0 && index < len(m.params) {
+				m.params[index].Disabled = !m.params[index].Disabled
+				return m.save()
+			}
+
+		case "shift+up", "K":
+			if index > 0 {
+				m.params[index-1], m.params[index] = m.params[index], m.params[index-1]
+				m.cursor--
+				return m.save()
+			}
+
+		case "shift+down", "J":
+			if index >= 0 && index < len(m.params)-1 {
+				m.params[index+1], m.params[index] = m.params[index], m.params[index+1]
+				m.cursor++
+				return m.save()
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) editView() string {
+	title := "Add Param"
+	key := config.InputStyle.Render(m.inputs[0].View())
+	switch m.mode {
+	case edit:
+		title = "Edit Param"
+	case editPath:
+		title = "Edit Path Parameter"
+		key = config.InputStyle.Render(m.inputs[0].Value())
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		config.BoxHeader.Render(title),
+		lipgloss.JoinHorizontal(lipgloss.Left, config.LabelStyle.Render("Key:"), key),
+		lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			config.LabelStyle.Render("Value:"), config.InputStyle.Render(m.inputs[1].View()),
+		),
+		helpStyle.Render("enter next/save · esc cancel"),
+	)
+}
+
 func (m Model) View() string {
-	if len(m.items) == 0 {
-		return config.EmptyMessageStyle.Padding(2, 2).Render("No query params defined. You can " + config.LinkStyle.Underline(true).Render("add param"))
+	if m.call == nil {
+		return config.EmptyMessageStyle.Padding(2, 2).Render("No query params defined.")
+	}
+
+	content := ""
+	if len(m.path) > 0 {
+		content = sectionStyle.Render("Path") + "\n" + m.pathTable.View() + "\n" + sectionStyle.Render("Query") + "\n"
+	}
+	if len(m.params) > 0 {
+		content += m.simpleTable.View() + "\n" +
+			zone.Mark("add_param", config.LinkStyle.Padding(0, 1).Render("+ Add Param")) +
+			helpStyle.Render("a add · e edit · x delete · space toggle · J/K move")
+	} else {
+		padding := 2
+		if len(m.path) > 0 {
+			padding = 1
+		}
+		content += config.EmptyMessageStyle.Padding(padding, 2).Render("No query params defined. You can " + zone.Mark("add_param", config.LinkStyle.Underline(true).Render("add param")))
+	}
+	if m.mode != view {
+		content += "\n" + m.editView()
 	}
-	return m.simpleTable.View() + "\n" + config.LinkStyle.Padding(0, 1).Render("+ Add Param")
+	return content
 }
@@ -0,0 +1,91 @@
+package popup
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var conflictOptions = []string{"Keep mine", "Take theirs"}
+
+// ConflictResultMsg is the message sent when the user decides which changes
+// win over the ones made to the collections outside restman.
+type ConflictResultMsg struct {
+	TakeTheirs bool
+}
+
+// Conflict is a popup that asks whether the changes made in restman or the
+// ones made on disk are kept.
+type Conflict struct {
+	style    style
+	question string
+	overlay  Overlay
+	selected int
+}
+
+// NewConflict creates a new Conflict popup.
+func NewConflict(bgRaw string, width int, question string) Conflict {
+	optWidth := max(len(question)+16, 50)
+	if optWidth > width {
+		optWidth = width
+	}
+
+	height := 7
+
+	return Conflict{
+		style:    newStyle(optWidth, height),
+		overlay:  NewOverlay(bgRaw, optWidth, height),
+		question: question,
+	}
+}
+
+// Init initializes the popup.
+func (c Conflict) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages.
+func (c Conflict) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			return c, c.makeChoice()
+
+		case "left", "right", "tab", "shift+tab":
+			c.selected = 1 - c.selected
+			return c, nil
+
+		case "m", "M", "esc":
+			c.selected = 0
+			return c, c.makeChoice()
+
+		case "t", "T":
+			c.selected = 1
+			return c, c.makeChoice()
+		}
+	}
+
+	return c, nil
+}
+
+// View renders the popup.
+func (c Conflict) View() string {
+	buttons := []string{}
+	for i, option := range conflictOptions {
+		if i == c.selected {
+			buttons = append(buttons, c.style.activeButton.Render(option))
+		} else {
+			buttons = append(buttons, c.style.button.Render(option))
+		}
+	}
+
+	question := c.style.question.Render(c.question)
+	ui := lipgloss.JoinVertical(lipgloss.Center, question, lipgloss.JoinHorizontal(lipgloss.Top, buttons...))
+	dialog := lipgloss.Place(c.overlay.width-2, c.overlay.height-2, lipgloss.Center, lipgloss.Center, ui)
+
+	return c.overlay.WrapView(c.style.general.Render(dialog))
+}
+
+// makeChoice returns a tea.Cmd that tells the parent model about the choice.
+func (c Conflict) makeChoice() tea.Cmd {
+	return func() tea.Msg { return ConflictResultMsg{TakeTheirs: c.selected == 1} }
+}
@@ -0,0 +1,86 @@
+package popup
+
+import (
+	"restman/components/config"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PassphraseMsg is the message sent when the user entered the passphrase of
+// the secrets store.
+type PassphraseMsg struct {
+	Passphrase string
+}
+
+// Passphrase is a popup that asks for the passphrase of the secrets store,
+// esc closes it leaving the secrets locked.
+type Passphrase struct {
+	style    style
+	question string
+	err      string
+	overlay  Overlay
+	input    textinput.Model
+}
+
+// NewPassphrase creates a new Passphrase popup, err is shown below the input
+// when the previous passphrase was wrong.
+func NewPassphrase(bgRaw string, width int, question string, err string) Passphrase {
+	optWidth := max(len(question)+16, 50)
+	if optWidth > width {
+		optWidth = width
+	}
+
+	height := 8
+
+	input := textinput.New()
+	input.Prompt = "󰌆  "
+	input.Placeholder = "passphrase"
+	input.EchoMode = textinput.EchoPassword
+	input.Width = optWidth - 12
+	input.Focus()
+
+	return Passphrase{
+		style:    newStyle(optWidth, height),
+		overlay:  NewOverlay(bgRaw, optWidth, height),
+		question: question,
+		err:      err,
+		input:    input,
+	}
+}
+
+// Init initializes the popup.
+func (p Passphrase) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles messages.
+func (p Passphrase) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			passphrase := p.input.Value()
+			return p, func() tea.Msg { return PassphraseMsg{passphrase} }
+
+		case "esc":
+			return p, func() tea.Msg { return ClosePopupMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+// View renders the popup.
+func (p Passphrase) View() string {
+	lines := []string{p.style.question.Render(p.question), config.InputStyle.Render(p.input.View())}
+	if p.err != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(config.COLOR_ERROR).Render(p.err))
+	}
+	ui := lipgloss.JoinVertical(lipgloss.Center, lines...)
+	dialog := lipgloss.Place(p.overlay.width-2, p.overlay.height-2, lipgloss.Center, lipgloss.Center, ui)
+
+	return p.overlay.WrapView(p.style.general.Render(dialog))
+}
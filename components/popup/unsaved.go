@@ -0,0 +1,107 @@
+package popup
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Results of the Unsaved popup.
+const (
+	UnsavedSave = iota
+	UnsavedDiscard
+	UnsavedCancel
+)
+
+var unsavedOptions = []string{"Save", "Discard", "Cancel"}
+
+// UnsavedResultMsg is the message sent when the user decides what happens
+// with unsaved changes.
+type UnsavedResultMsg struct {
+	Result int
+}
+
+// Unsaved is a popup that asks whether unsaved changes are saved or
+// discarded, or the action which would drop them is cancelled.
+type Unsaved struct {
+	style    style
+	question string
+	overlay  Overlay
+	selected int
+}
+
+// NewUnsaved creates a new Unsaved popup.
+func NewUnsaved(bgRaw string, width int, question string) Unsaved {
+	optWidth := max(len(question)+16, 50)
+	if optWidth > width {
+		optWidth = width
+	}
+
+	height := 7
+
+	return Unsaved{
+		style:    newStyle(optWidth, height),
+		overlay:  NewOverlay(bgRaw, optWidth, height),
+		question: question,
+		selected: UnsavedSave,
+	}
+}
+
+// Init initializes the popup.
+func (u Unsaved) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages.
+func (u Unsaved) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			return u, u.makeChoice()
+
+		case "right", "tab":
+			u.selected = (u.selected + 1) % len(unsavedOptions)
+			return u, nil
+
+		case "left", "shift+tab":
+			u.selected = (u.selected + len(unsavedOptions) - 1) % len(unsavedOptions)
+			return u, nil
+
+		case "s", "S":
+			u.selected = UnsavedSave
+			return u, u.makeChoice()
+
+		case "d", "D":
+			u.selected = UnsavedDiscard
+			return u, u.makeChoice()
+
+		case "c", "C", "esc":
+			u.selected = UnsavedCancel
+			return u, u.makeChoice()
+		}
+	}
+
+	return u, nil
+}
+
+// View renders the popup.
+func (u Unsaved) View() string {
+	buttons := []string{}
+	for i, option := range unsavedOptions {
+		if i == u.selected {
+			buttons = append(buttons, u.style.activeButton.Render(option))
+		} else {
+			buttons = append(buttons, u.style.button.Render(option))
+		}
+	}
+
+	question := u.style.question.Render(u.question)
+	ui := lipgloss.JoinVertical(lipgloss.Center, question, lipgloss.JoinHorizontal(lipgloss.Top, buttons...))
+	dialog := lipgloss.Place(u.overlay.width-2, u.overlay.height-2, lipgloss.Center, lipgloss.Center, ui)
+
+	return u.overlay.WrapView(u.style.general.Render(dialog))
+}
+
+// makeChoice returns a tea.Cmd that tells the parent model about the choice.
+func (u Unsaved) makeChoice() tea.Cmd {
+	return func() tea.Msg { return UnsavedResultMsg{u.selected} }
+}
@@ -13,9 +13,10 @@ import (
 )
 
 const (
-	NONE = "None"
-	TEXT = "Text"
-	JSON = "JSON"
+	NONE    = "None"
+	TEXT    = "Text"
+	JSON    = "JSON"
+	GRAPHQL = "GraphQL"
 )
 
 type editorFinishedMsg struct {
@@ -23,7 +24,7 @@ type editorFinishedMsg struct {
 	err  error
 }
 
-var OPTIONS = []string{NONE, TEXT, JSON}
+var OPTIONS = []string{NONE, TEXT, JSON, GRAPHQL}
 
 type BodyModel struct {
 	call     *app.Call
@@ -31,6 +32,8 @@ type BodyModel struct {
 	height   int
 	textarea textarea.Model
 	toggle   components.ToggleModel
+	graphql  *graphqlModel
+	grpc     *grpcModel
 }
 
 func NewBody(call *app.Call, width int, height int) BodyModel {
@@ -68,13 +71,23 @@ func NewBody(call *app.Call, width int, height int) BodyModel {
 	}
 	toggle := components.NewToggle("Body type", OPTIONS, defaultValue)
 
-	return BodyModel{
+	m := BodyModel{
 		width:    width,
 		height:   height,
 		call:     call,
 		textarea: ti,
 		toggle:   toggle,
 	}
+	if call != nil && call.IsGRPC() {
+		// request messages are always JSON
+		grpc := newGRPC(call)
+		m.grpc = &grpc
+		m.textarea.SetHeight(height - 6)
+	} else if defaultValue == GRAPHQL {
+		graphql := newGraphQL(call, width-4, height-4)
+		m.graphql = &graphql
+	}
+	return m
 }
 
 func (m BodyModel) Init() tea.Cmd {
@@ -85,13 +98,46 @@ func (m BodyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
+	if m.grpc != nil {
+		if key, ok := msg.(tea.KeyMsg); ok && m.grpc.picking {
+			*m.grpc, cmd = m.grpc.Update(key)
+			return m, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 
+	case app.GRPCServicesMsg:
+		if m.grpc != nil {
+			*m.grpc, cmd = m.grpc.Update(msg)
+		}
+		return m, cmd
+
+	case grpcMethodSelectedMsg:
+		template := ""
+		for _, method := range m.grpc.methods {
+			if method.Service == m.call.GRPC.Service && method.Name == m.call.GRPC.Method {
+				template = method.Template
+			}
+		}
+		m.call.GRPC.Service = msg.method.Service
+		m.call.GRPC.Method = msg.method.Name
+		// keep what was typed unless it is the template of the previous method
+		if m.textarea.Value() == "" || m.textarea.Value() == template {
+			m.textarea.SetValue(msg.method.Template)
+			m.call.Data = msg.method.Template
+		}
+		return m, nil
+
 	case editorFinishedMsg:
 		// TODO: handle error
 		content, _ := os.ReadFile(msg.file.Name())
-		m.textarea.SetValue(string(content))
 		utils.RemoveTempFile(msg.file)
+		if m.graphql != nil {
+			m.graphql.editor().SetValue(string(content))
+			break
+		}
+		m.textarea.SetValue(string(content))
 
 	case components.OptionSelectedMsg:
 		if msg.Id == m.toggle.Id {
@@ -99,24 +145,48 @@ func (m BodyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.call.DataType = msg.Selected
 				m.call.Data = ""
 				m.textarea.SetValue("")
+				m.graphql = nil
+				if msg.Selected == GRAPHQL {
+					graphql := newGraphQL(m.call, m.width-4, m.height-4)
+					m.graphql = &graphql
+					if graphql.schema == nil {
+						return m, m.graphql.fetchSchema()
+					}
+				}
 			}
 		}
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlT:
+			if m.grpc != nil {
+				return m, nil
+			}
 			return m, m.toggle.Next()
 
+		case tea.KeyCtrlB:
+			if m.grpc != nil {
+				return m, m.grpc.Open()
+			}
+
 		case tea.KeyEsc:
 			if m.textarea.Focused() {
 				m.textarea.Blur()
 			}
 		case tea.KeyCtrlE:
 			extension := "txt"
-			if m.call != nil && m.call.DataType == JSON {
+			value := m.textarea.Value()
+			if m.call != nil && (m.call.DataType == JSON || m.grpc != nil) {
 				extension = "json"
 			}
+			if m.graphql != nil {
+				extension = "graphql"
+				if m.graphql.editing == 1 {
+					extension = "json"
+				}
+				value = m.graphql.editor().Value()
+			}
 			// TODO: handle error
-			tmpFile, _ := utils.CreateTempFile(m.textarea.Value(), extension)
+			tmpFile, _ := utils.CreateTempFile(value, extension)
 			return m, tea.ExecProcess(utils.OpenInEditorCommand(tmpFile), func(err error) tea.Msg {
 				return editorFinishedMsg{tmpFile, err}
 			})
@@ -132,15 +202,34 @@ func (m BodyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.toggle, cmd = m.toggle.Update(msg)
 	cmds = append(cmds, cmd)
 
+	if m.graphql != nil {
+		*m.graphql, cmd = m.graphql.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	}
+
 	m.textarea, cmd = m.textarea.Update(msg)
 	cmds = append(cmds, cmd)
+	if m.call != nil && (m.call.DataType != NONE || m.grpc != nil) {
+		m.call.Data = m.textarea.Value()
+	}
 	return m, tea.Batch(cmds...)
 }
 
 func (m BodyModel) View() string {
+	if m.grpc != nil {
+		content := lipgloss.JoinVertical(lipgloss.Left, m.grpc.Header(), m.textarea.View())
+		if m.grpc.picking {
+			content = m.grpc.View(m.width-4, m.height-4)
+		}
+		return lipgloss.NewStyle().Padding(1, 2).Render(content)
+	}
+
 	content := m.textarea.View()
 	if m.call == nil || m.call.DataType == NONE {
 		content = config.EmptyMessageStyle.Render("No body")
+	} else if m.graphql != nil {
+		content = lipgloss.NewStyle().MarginTop(1).Render(m.graphql.View())
 	}
 
 	return lipgloss.
@@ -6,6 +6,9 @@ import (
 	"restman/components/config"
 	"restman/components/headers"
 	"restman/components/params"
+	"restman/components/scripts"
+	"restman/components/tests"
+	"restman/utils"
 	"strconv"
 	"strings"
 
@@ -59,7 +62,7 @@ type Request struct {
 func New() Request {
 	return Request{
 		title: "Params",
-		Tabs:  []string{"Params", "Headers", "Auth", "Body"},
+		Tabs:  []string{"Params", "Headers", "Auth", "Body", "Tests", "Scripts"},
 	}
 }
 
@@ -77,6 +80,10 @@ func (b Request) GetContent() tea.Model {
 		return auth.New(b.width, b.call)
 	} else if b.activeTab == 3 {
 		return NewBody(b.call, b.width-2, b.height-4)
+	} else if b.activeTab == 4 {
+		return tests.New(b.call, b.width-2, b.height-4)
+	} else if b.activeTab == 5 {
+		return scripts.New(b.call, b.width-2, b.height-4)
 	}
 	return nil
 }
@@ -128,8 +135,6 @@ func (b *Request) SetActiveTab(tab int) {
 func (b Request) View() string {
 	doc := strings.Builder{}
 
-	var renderedTabs []string
-
 	if b.focused {
 		inactiveTabStyle = inactiveTabStyle.BorderForeground(config.COLOR_HIGHLIGHT)
 		activeTabStyle = activeTabStyle.BorderForeground(config.COLOR_HIGHLIGHT)
@@ -142,7 +147,56 @@ func (b Request) View() string {
 		tabGap = tabGap.BorderForeground(config.COLOR_SUBTLE)
 	}
 
-	tabSize := 22
+	// narrow panes drop the tab padding so that all tabs fit
+	renderedTabs, tabSize := b.renderTabs(false)
+	if tabSize > b.width {
+		renderedTabs, tabSize = b.renderTabs(true)
+	}
+
+	// narrow panes cannot fit the gap after all tabs
+	renderedTabs = append(renderedTabs, tabGap.Render(strings.Repeat(" ", utils.MaxInt(0, b.width-tabSize))))
+
+	windowStyle = windowStyle.Height(b.height - 4)
+
+	style := inactiveTabStyle
+	border, _, _, _, _ := style.GetBorder()
+	border.Right = " "
+	border.BottomRight = "┐"
+	style = style.Border(border).BorderTop(false).BorderLeft(false)
+	renderedTabs = append(renderedTabs, style.Render(" "))
+	row := lipgloss.JoinHorizontal(lipgloss.Bottom, renderedTabs...)
+	doc.WriteString(row)
+	doc.WriteString("\n")
+
+	var content string
+	if b.activeTab == 0 {
+		content = b.content.View()
+	} else if b.activeTab == 1 {
+		content = b.content.View()
+	} else if b.activeTab == 2 {
+		content = b.content.View()
+	} else if b.activeTab == 3 {
+		content = b.content.View()
+	} else if b.activeTab == 4 {
+		content = b.content.View()
+	} else if b.activeTab == 5 {
+		content = b.content.View()
+	} else {
+		content = emptyMessage.Render("Not implemented yet")
+	}
+
+	doc.WriteString(windowStyle.Width((lipgloss.Width(row) - windowStyle.GetHorizontalFrameSize())).Render(content))
+	return docStyle.Render(doc.String())
+}
+
+// renderTabs renders the tab row and returns it with its width; compact
+// tabs have no padding.
+func (b Request) renderTabs(compact bool) ([]string, int) {
+	var renderedTabs []string
+	tabSize := 30
+	if compact {
+		tabSize -= 2 * len(b.Tabs)
+	}
 	for i, t := range b.Tabs {
 		var style lipgloss.Style
 		isFirst, isActive := i == 0, i == b.activeTab
@@ -191,6 +245,9 @@ func (b Request) View() string {
 		}
 
 		style = style.Border(border)
+		if compact {
+			style = style.Padding(0)
+		}
 		toRender := t
 		if counterSign != "" {
 			toRender += " " + counter
@@ -201,33 +258,5 @@ func (b Request) View() string {
 		tabSize += len(t)
 	}
 
-	renderedTabs = append(renderedTabs, tabGap.Render(strings.Repeat(" ", b.width-tabSize)))
-
-	windowStyle = windowStyle.Height(b.height - 4)
-
-	style := inactiveTabStyle
-	border, _, _, _, _ := style.GetBorder()
-	border.Right = " "
-	border.BottomRight = "┐"
-	style = style.Border(border).BorderTop(false).BorderLeft(false)
-	renderedTabs = append(renderedTabs, style.Render(" "))
-	row := lipgloss.JoinHorizontal(lipgloss.Bottom, renderedTabs...)
-	doc.WriteString(row)
-	doc.WriteString("\n")
-
-	var content string
-	if b.activeTab == 0 {
-		content = b.content.View()
-	} else if b.activeTab == 1 {
-		content = b.content.View()
-	} else if b.activeTab == 2 {
-		content = b.content.View()
-	} else if b.activeTab == 3 {
-		content = b.content.View()
-	} else {
-		content = emptyMessage.Render("Not implemented yet")
-	}
-
-	doc.WriteString(windowStyle.Width((lipgloss.Width(row) - windowStyle.GetHorizontalFrameSize())).Render(content))
-	return docStyle.Render(doc.String())
+	return renderedTabs, tabSize
 }
@@ -0,0 +1,407 @@
+package request
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	sectionStyle    = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	activeSection   = lipgloss.NewStyle().Bold(true).Foreground(config.COLOR_HIGHLIGHT)
+	suggestionStyle = lipgloss.NewStyle().Foreground(config.COLOR_LIGHTER).Padding(0, 1)
+	selectedStyle   = lipgloss.NewStyle().Foreground(config.COLOR_WHITE).Background(config.COLOR_HIGHLIGHT).Padding(0, 1)
+	graphqlHelp     = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	typeStyle       = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+)
+
+// graphqlModel edits the query, variables and operation name of a GraphQL
+// call. The schema of the collection drives completion, validation and the
+// schema explorer.
+type graphqlModel struct {
+	call      *app.Call
+	width     int
+	height    int
+	query     textarea.Model
+	variables textarea.Model
+	editing   int // 0 query, 1 variables
+
+	schema   *app.Schema
+	fetching bool
+	status   string
+	errors   []string
+
+	suggestions []string
+	prefix      string
+	selected    int
+
+	explorer *schemaExplorer
+}
+
+func newEditor(value string, width int, height int) textarea.Model {
+	ti := textarea.New()
+	ti.CharLimit = 0
+	ti.SetValue(value)
+	ti.SetWidth(width)
+	ti.SetHeight(height)
+	ti.Prompt = ""
+	ti.ShowLineNumbers = false
+	return ti
+}
+
+func newGraphQL(call *app.Call, width int, height int) graphqlModel {
+	if call.GraphQL == nil {
+		call.GraphQL = &app.GraphQL{}
+	}
+
+	// two thirds for the query, the rest for the variables
+	editorsHeight := max(height-8, 4)
+	queryHeight := editorsHeight * 2 / 3
+
+	m := graphqlModel{
+		call:      call,
+		width:     width,
+		height:    height,
+		query:     newEditor(call.GraphQL.Query, width, queryHeight),
+		variables: newEditor(call.GraphQL.Variables, width, editorsHeight-queryHeight),
+		schema:    app.GetInstance().GetSchema(call),
+	}
+	m.query.Placeholder = "query { ... }"
+	m.variables.Placeholder = "{}"
+	m.query.Focus()
+	m.validate()
+	return m
+}
+
+func (m *graphqlModel) editor() *textarea.Model {
+	if m.editing == 1 {
+		return &m.variables
+	}
+	return &m.query
+}
+
+// fetchSchema starts an introspection query unless one is running already
+func (m *graphqlModel) fetchSchema() tea.Cmd {
+	if m.fetching || m.call.GetUrl() == "" {
+		return nil
+	}
+	m.fetching = true
+	m.status = "Fetching schema..."
+	return app.GetInstance().FetchSchema(m.call)
+}
+
+func (m *graphqlModel) validate() {
+	m.errors = nil
+	if m.schema != nil {
+		m.errors = m.schema.Validate(m.query.Value())
+	}
+	if _, err := m.call.GraphQL.Body(); err != nil {
+		m.errors = append(m.errors, err.Error())
+	}
+}
+
+// cursorOffset returns the byte offset of the cursor in the query
+func (m graphqlModel) cursorOffset() int {
+	lines := strings.Split(m.query.Value(), "\n")
+	row := min(m.query.Line(), len(lines)-1)
+	offset := 0
+	for _, line := range lines[:row] {
+		offset += len(line) + 1
+	}
+	info := m.query.LineInfo()
+	runes := []rune(lines[row])
+	column := min(info.StartColumn+info.CharOffset, len(runes))
+	return offset + len(string(runes[:column]))
+}
+
+func (m *graphqlModel) complete() tea.Cmd {
+	if m.schema == nil {
+		return m.fetchSchema()
+	}
+	m.suggestions, m.prefix = m.schema.Complete(m.query.Value(), m.cursorOffset())
+	m.selected = 0
+	return nil
+}
+
+func (m *graphqlModel) accept() {
+	suggestion := m.suggestions[m.selected]
+	m.query.InsertString(strings.TrimPrefix(suggestion, m.prefix))
+	m.suggestions = nil
+}
+
+// nextOperation selects the following operation defined in the query, the
+// name is sent along so servers know which one to execute
+func (m *graphqlModel) nextOperation() {
+	names := m.call.GraphQL.OperationNames()
+	next := ""
+	for i, name := range names {
+		if name == m.call.GraphQL.OperationName && i+1 < len(names) {
+			next = names[i+1]
+		}
+	}
+	if m.call.GraphQL.OperationName == "" && len(names) > 0 {
+		next = names[0]
+	}
+	m.call.GraphQL.OperationName = next
+}
+
+func (m graphqlModel) Update(msg tea.Msg) (graphqlModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.SchemaFetchedMsg:
+		if msg.Call != m.call {
+			return m, nil
+		}
+		m.fetching = false
+		if msg.Err != nil {
+			m.status = "Schema: " + msg.Err.Error()
+			return m, nil
+		}
+		m.status = ""
+		m.schema = msg.Schema
+		m.validate()
+		if m.explorer != nil {
+			m.explorer = newSchemaExplorer(m.schema)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.explorer != nil {
+			switch msg.String() {
+			case "esc", "ctrl+b":
+				m.explorer = nil
+			case "ctrl+r":
+				return m, m.fetchSchema()
+			default:
+				m.explorer.Update(msg)
+			}
+			return m, nil
+		}
+
+		if len(m.suggestions) > 0 {
+			switch msg.String() {
+			case "up":
+				m.selected = max(m.selected-1, 0)
+				return m, nil
+			case "down":
+				m.selected = min(m.selected+1, len(m.suggestions)-1)
+				return m, nil
+			case "enter":
+				m.accept()
+				m.validate()
+				return m, nil
+			case "esc":
+				m.suggestions = nil
+				return m, nil
+			}
+			m.suggestions = nil
+		}
+
+		switch msg.String() {
+		case "ctrl+y":
+			m.editor().Blur()
+			m.editing = 1 - m.editing
+			return m, m.editor().Focus()
+		case "ctrl+g":
+			m.nextOperation()
+			return m, nil
+		case "ctrl+@":
+			if m.editing == 0 {
+				return m, m.complete()
+			}
+			return m, nil
+		case "ctrl+b":
+			if m.schema == nil {
+				return m, m.fetchSchema()
+			}
+			m.explorer = newSchemaExplorer(m.schema)
+			return m, nil
+		case "ctrl+r":
+			return m, m.fetchSchema()
+		}
+	}
+
+	var cmd tea.Cmd
+	*m.editor(), cmd = m.editor().Update(msg)
+
+	if m.query.Value() != m.call.GraphQL.Query || m.variables.Value() != m.call.GraphQL.Variables {
+		m.call.GraphQL.Query = m.query.Value()
+		m.call.GraphQL.Variables = m.variables.Value()
+		m.validate()
+	}
+	return m, cmd
+}
+
+func (m graphqlModel) section(title string, index int) string {
+	if index == m.editing {
+		return activeSection.Render(title)
+	}
+	return sectionStyle.Render(title)
+}
+
+func (m graphqlModel) View() string {
+	if m.explorer != nil {
+		return m.explorer.View(m.width, m.height-2)
+	}
+
+	operation := m.call.GraphQL.OperationName
+	if operation == "" {
+		operation = "(default)"
+	}
+
+	query := m.query.View()
+	if len(m.suggestions) > 0 {
+		items := []string{}
+		for i, suggestion := range m.suggestions {
+			if i == m.selected {
+				items = append(items, selectedStyle.Render(suggestion))
+			} else {
+				items = append(items, suggestionStyle.Render(suggestion))
+			}
+		}
+		query = lipgloss.JoinVertical(lipgloss.Left, query, lipgloss.NewStyle().MaxWidth(m.width).Render(strings.Join(items, "")))
+	}
+
+	status := graphqlHelp.Render("ctrl+y query/variables · ctrl+g operation · ctrl+space complete · ctrl+b schema")
+	switch {
+	case len(m.errors) > 0:
+		status = config.ErrorStyle.Render(m.errors[0])
+		if len(m.errors) > 1 {
+			status += graphqlHelp.Render(fmt.Sprintf(" (+%d more)", len(m.errors)-1))
+		}
+	case m.status != "":
+		status = graphqlHelp.Render(m.status)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.section("Query", 0)+graphqlHelp.Render("  operation: ")+operation,
+		query,
+		m.section("Variables", 1),
+		m.variables.View(),
+		lipgloss.NewStyle().MaxWidth(m.width).Render(status),
+	)
+}
+
+// schemaExplorer browses the types of a schema, starting at the root
+// operation types and following the type of the selected field
+type schemaExplorer struct {
+	schema *app.Schema
+	path   []string
+	cursor int
+	offset int
+}
+
+func newSchemaExplorer(schema *app.Schema) *schemaExplorer {
+	return &schemaExplorer{schema: schema}
+}
+
+type explorerEntry struct {
+	name        string
+	detail      string
+	typeName    string
+	description string
+}
+
+func (e schemaExplorer) entries() []explorerEntry {
+	entries := []explorerEntry{}
+
+	if len(e.path) == 0 {
+		for _, t := range e.schema.UserTypes() {
+			entries = append(entries, explorerEntry{t.Name, strings.ToLower(t.Kind), t.Name, t.Description})
+		}
+		return entries
+	}
+
+	t := e.schema.Type(e.path[len(e.path)-1])
+	if t == nil {
+		return entries
+	}
+	for _, field := range t.Fields {
+		args := []string{}
+		for _, arg := range field.Args {
+			args = append(args, arg.Name+": "+arg.Type.String())
+		}
+		name := field.Name
+		if len(args) > 0 {
+			name += "(" + strings.Join(args, ", ") + ")"
+		}
+		entries = append(entries, explorerEntry{name, field.Type.String(), field.Type.NamedType(), field.Description})
+	}
+	for _, field := range t.InputFields {
+		entries = append(entries, explorerEntry{field.Name, field.Type.String(), field.Type.NamedType(), field.Description})
+	}
+	for _, value := range t.EnumValues {
+		entries = append(entries, explorerEntry{value.Name, "", "", value.Description})
+	}
+	return entries
+}
+
+func (e *schemaExplorer) Update(msg tea.KeyMsg) {
+	entries := e.entries()
+
+	switch msg.String() {
+	case "up":
+		e.cursor = max(e.cursor-1, 0)
+	case "down":
+		e.cursor = min(e.cursor+1, len(entries)-1)
+	case "enter", "right":
+		if e.cursor < len(entries) && e.schema.Type(entries[e.cursor].typeName) != nil {
+			e.path = append(e.path, entries[e.cursor].typeName)
+			e.cursor, e.offset = 0, 0
+		}
+	case "backspace", "left":
+		if len(e.path) > 0 {
+			e.path = e.path[:len(e.path)-1]
+			e.cursor, e.offset = 0, 0
+		}
+	}
+}
+
+func (e *schemaExplorer) View(width int, height int) string {
+	entries := e.entries()
+	// title, description and help
+	rows := max(height-4, 1)
+
+	if e.cursor < e.offset {
+		e.offset = e.cursor
+	} else if e.cursor >= e.offset+rows {
+		e.offset = e.cursor - rows + 1
+	}
+
+	title := "Schema"
+	if len(e.path) > 0 {
+		title += " › " + strings.Join(e.path, " › ")
+	}
+
+	lines := []string{activeSection.Render(title)}
+	for i := e.offset; i < len(entries) && i < e.offset+rows; i++ {
+		line := entries[i].name
+		if entries[i].detail != "" {
+			line += ": " + typeStyle.Render(entries[i].detail)
+		}
+		if i == e.cursor {
+			line = "› " + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(line))
+	}
+	if len(entries) == 0 {
+		lines = append(lines, sectionStyle.Render("  no fields"))
+	}
+
+	description := ""
+	if e.cursor < len(entries) {
+		description = entries[e.cursor].description
+	}
+	lines = append(lines,
+		lipgloss.NewStyle().MaxWidth(width).Render(sectionStyle.Render(description)),
+		graphqlHelp.Render("enter open · backspace back · ctrl+r refresh · esc close"),
+	)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,168 @@
+package request
+
+import (
+	"restman/app"
+	"restman/components/config"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type grpcMethodSelectedMsg struct{ method app.GRPCMethod }
+
+// grpcModel picks the method of a gRPC call among the ones discovered with
+// server reflection or loaded from proto files
+type grpcModel struct {
+	call       *app.Call
+	methods    []app.GRPCMethod
+	cursor     int
+	offset     int
+	picking    bool
+	loading    bool
+	err        error
+	protoFiles textinput.Model
+}
+
+func newGRPC(call *app.Call) grpcModel {
+	if call.GRPC == nil {
+		call.GRPC = &app.GRPC{}
+	}
+
+	input := textinput.New()
+	input.Prompt = "Proto files: "
+	input.Placeholder = "none, using server reflection"
+	input.SetValue(strings.Join(call.GRPC.ProtoFiles, " "))
+
+	return grpcModel{call: call, protoFiles: input}
+}
+
+func (m *grpcModel) discover(refresh bool) tea.Cmd {
+	m.loading = true
+	m.err = nil
+	return app.GetInstance().DiscoverGRPC(m.call, refresh)
+}
+
+func (m grpcModel) Update(msg tea.Msg) (grpcModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.GRPCServicesMsg:
+		if msg.Call != m.call {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.Err
+		m.methods = msg.Methods
+		m.cursor = 0
+		for i, method := range m.methods {
+			if method.Service == m.call.GRPC.Service && method.Name == m.call.GRPC.Method {
+				m.cursor = i
+			}
+		}
+
+	case tea.KeyMsg:
+		if m.protoFiles.Focused() {
+			switch msg.String() {
+			case "enter":
+				m.protoFiles.Blur()
+				m.call.GRPC.ProtoFiles = strings.Fields(m.protoFiles.Value())
+				return m, m.discover(true)
+			case "esc", "ctrl+y":
+				m.protoFiles.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.protoFiles, cmd = m.protoFiles.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up":
+			m.cursor = max(m.cursor-1, 0)
+		case "down":
+			m.cursor = max(min(m.cursor+1, len(m.methods)-1), 0)
+		case "enter":
+			m.picking = false
+			if m.cursor < len(m.methods) {
+				method := m.methods[m.cursor]
+				return m, func() tea.Msg { return grpcMethodSelectedMsg{method} }
+			}
+		case "ctrl+r":
+			return m, m.discover(true)
+		case "ctrl+y":
+			return m, m.protoFiles.Focus()
+		case "esc", "ctrl+b":
+			m.picking = false
+		}
+	}
+	return m, nil
+}
+
+// Open shows the method picker, discovering the methods the first time
+func (m *grpcModel) Open() tea.Cmd {
+	m.picking = true
+	if m.methods == nil && !m.loading {
+		return m.discover(false)
+	}
+	return nil
+}
+
+// Header describes the selected method above the request message editor
+func (m grpcModel) Header() string {
+	if m.call.GRPC.Method == "" {
+		return graphqlHelp.Render("No method selected · ctrl+b pick a method")
+	}
+	kind := "unary"
+	for _, method := range m.methods {
+		if method.Service == m.call.GRPC.Service && method.Name == m.call.GRPC.Method {
+			kind = methodKind(method)
+		}
+	}
+	return activeSection.Render(m.call.GRPC.Service+"/"+m.call.GRPC.Method) +
+		graphqlHelp.Render("  "+kind+" · ctrl+b change")
+}
+
+func methodKind(method app.GRPCMethod) string {
+	switch {
+	case method.ClientStreaming && method.ServerStreaming:
+		return "bidi streaming"
+	case method.ClientStreaming:
+		return "client streaming"
+	case method.ServerStreaming:
+		return "server streaming"
+	}
+	return "unary"
+}
+
+func (m *grpcModel) View(width int, height int) string {
+	lines := []string{activeSection.Render("Methods"), config.InputStyle.Render(m.protoFiles.View())}
+
+	rows := max(height-4, 1)
+	switch {
+	case m.loading:
+		lines = append(lines, graphqlHelp.Render("Discovering services..."))
+	case m.err != nil:
+		lines = append(lines, config.ErrorStyle.Render(m.err.Error()))
+	case len(m.methods) == 0:
+		lines = append(lines, graphqlHelp.Render("No services found"))
+	default:
+		if m.cursor < m.offset {
+			m.offset = m.cursor
+		} else if m.cursor >= m.offset+rows {
+			m.offset = m.cursor - rows + 1
+		}
+		for i := m.offset; i < len(m.methods) && i < m.offset+rows; i++ {
+			method := m.methods[i]
+			line := method.FullName() + typeStyle.Render(" "+methodKind(method))
+			if i == m.cursor {
+				line = "› " + line
+			} else {
+				line = "  " + line
+			}
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(line))
+		}
+	}
+
+	lines = append(lines, graphqlHelp.Render("enter select · ctrl+y proto files · ctrl+r refresh · esc close"))
+	return strings.Join(lines, "\n")
+}
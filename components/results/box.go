@@ -2,6 +2,7 @@ package results
 
 import (
 	"encoding/json"
+	"fmt"
 	"restman/app"
 	"restman/components/config"
 	"restman/utils"
@@ -36,6 +37,13 @@ type Results struct {
 	status    int
 	isLoading bool
 	spinner   spinner.Model
+	pager     *pager
+	progress  app.OnProgressMsg
+	diff      *diffView
+	events    *eventsView
+	socket    *socketView
+	grpc      *grpcView
+	results   []app.AssertionResult
 }
 
 func New() Results {
@@ -43,7 +51,7 @@ func New() Results {
 	s.Spinner = spinner.Points
 	return Results{
 		title:   "Results",
-		Tabs:    []string{"Response", "Headers", "Cookies", "Statistics"},
+		Tabs:    []string{"Response", "Headers", "Cookies", "Statistics", "Tests", "Console"},
 		spinner: s,
 	}
 }
@@ -68,12 +76,87 @@ func (b Results) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		b.body = ""
 		b.status = 0
 		b.call = nil
+		b.pager = nil
+		b.diff = nil
+		b.events = nil
+		b.socket = nil
+		b.grpc = nil
+		b.results = nil
+		b.progress = app.OnProgressMsg{}
 		b.isLoading = true
 		cmd := b.spinner.Tick
 		cmds = append(cmds, cmd)
 
+	case app.OnProgressMsg:
+		b.progress = msg
+
+	case app.OnEventStreamMsg:
+		b.isLoading = false
+		b.call = msg.Call
+		b.status = msg.Response.StatusCode
+		if b.events == nil {
+			b.events = newEventsView(msg.Call, b.width-2, b.height-4)
+		} else {
+			b.events.SetState("streaming", nil)
+		}
+
+	case app.OnWebSocketOpenMsg:
+		b.isLoading = false
+		b.call = msg.Call
+		b.status = msg.Response.StatusCode
+		b.socket = newSocketView(msg.Call, b.width-2, b.height-4)
+
+	case app.OnFrameMsg:
+		if b.socket != nil {
+			b.socket.Add(msg.Frame)
+		}
+
+	case app.OnWebSocketClosedMsg:
+		if b.socket != nil {
+			b.socket.Closed(msg)
+		}
+
+	case app.OnGRPCMessageMsg:
+		b.isLoading = false
+		b.call = msg.Call
+		if b.grpc == nil {
+			b.grpc = newGRPCView(b.width-2, b.height-4)
+		}
+		b.grpc.Add(msg.Message)
+
+	case app.OnGRPCStatusMsg:
+		b.isLoading = false
+		b.call = msg.Call
+		if b.grpc == nil {
+			b.grpc = newGRPCView(b.width-2, b.height-4)
+		}
+		b.grpc.Done(msg)
+
+	case app.OnEventMsg:
+		if b.events != nil {
+			b.events.Add(msg.Event)
+		}
+
+	case app.OnEventStreamClosedMsg:
+		if b.events != nil {
+			if msg.Reconnecting {
+				b.events.SetState("reconnecting", msg.Err)
+			} else {
+				b.events.SetState("closed", msg.Err)
+			}
+		}
+
 	case app.OnResponseMsg:
 		b.isLoading = false
+		b.call = msg.Call
+		b.results = msg.Assertions
+		if msg.BodyFile != "" {
+			// too big to keep in memory, page through the file instead
+			p := newPager(msg.BodyFile)
+			b.pager = &p
+			b.status = msg.Response.StatusCode
+			return b, indexFile(msg.BodyFile)
+		}
 		if msg.Body != "" {
 			f := colorjson.NewFormatter()
 			f.Indent = 2
@@ -105,7 +188,56 @@ func (b Results) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		b.width = msg.Width
 		b.height = msg.Height
 
+	case pagerIndexedMsg:
+		if b.pager != nil {
+			p := b.pager.Update(msg, b.height-4)
+			b.pager = &p
+		}
+
 	case tea.KeyMsg:
+		// the message box of a WebSocket takes the keys while it is
+		// focused, ctrl+d and ctrl+k edit the message there
+		if b.socket != nil && b.diff == nil && b.socket.input.Focused() {
+			return b, b.socket.Update(msg)
+		}
+
+		switch msg.String() {
+		case "ctrl+d":
+			if b.diff != nil {
+				b.diff = nil
+			} else if b.call != nil && b.status != 0 {
+				b.diff = newDiffView(b.call, b.width-2, b.height-4)
+			}
+			return b, nil
+
+		case "ctrl+k":
+			if b.call != nil && b.status != 0 {
+				name := fmt.Sprintf("Example %d", len(b.call.Examples)+1)
+				return b, app.GetInstance().SaveExample(b.call, name)
+			}
+		}
+
+		if b.diff != nil {
+			return b, b.diff.Update(msg)
+		}
+
+		if b.events != nil {
+			return b, b.events.Update(msg)
+		}
+
+		if b.socket != nil {
+			return b, b.socket.Update(msg)
+		}
+
+		if b.grpc != nil {
+			return b, b.grpc.Update(msg)
+		}
+
+		if b.pager != nil {
+			p := b.pager.Update(msg, b.height-4)
+			b.pager = &p
+		}
+
 		switch msg.String() {
 		case "ctrl+l":
 			b.activeTab = min(b.activeTab+1, len(b.Tabs)-1)
@@ -113,6 +245,9 @@ func (b Results) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+h":
 			b.activeTab = max(b.activeTab-1, 0)
 		case "ctrl+e":
+			if b.pager != nil {
+				return b, tea.ExecProcess(utils.OpenPathInEditorCommand(b.pager.path), nil)
+			}
 			if b.body != "" {
 				extension := "json"
 				tmpFile, _ := utils.CreateTempFile(string(b.body), extension)
@@ -120,6 +255,12 @@ func (b Results) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		}
+	case tea.MouseMsg:
+		if b.pager != nil {
+			p := b.pager.Update(msg, b.height-4)
+			b.pager = &p
+		}
+
 	case config.WindowFocusedMsg:
 		b.focused = msg.State
 
@@ -157,7 +298,25 @@ func (b Results) View() string {
 	b.viewport.Height = b.height - 4
 
 	var content string
-	if b.body != "" {
+	if b.Tabs[b.activeTab] == "Tests" {
+		content = renderAssertions(b.results, b.call, b.viewport.Width)
+	} else if b.Tabs[b.activeTab] == "Console" {
+		content = renderConsole(app.ScriptLogs(), b.viewport.Width, b.viewport.Height)
+	} else if b.socket != nil && b.diff == nil {
+		b.socket.SetSize(b.viewport.Width, b.viewport.Height)
+		content = b.socket.View()
+	} else if b.events != nil && b.diff == nil {
+		b.events.SetSize(b.viewport.Width, b.viewport.Height)
+		content = b.events.View()
+	} else if b.grpc != nil && b.diff == nil {
+		b.grpc.SetSize(b.viewport.Width, b.viewport.Height)
+		content = b.grpc.View()
+	} else if b.diff != nil {
+		b.diff.SetSize(b.viewport.Width, b.viewport.Height)
+		content = b.diff.viewport.View()
+	} else if b.pager != nil {
+		content = b.pager.View(b.viewport.Width, b.viewport.Height)
+	} else if b.body != "" {
 		content = b.viewport.View()
 	} else {
 		icon := `
@@ -171,6 +330,17 @@ func (b Results) View() string {
 		text := "Not sent yet"
 		if b.isLoading {
 			text = lipgloss.NewStyle().Foreground(config.COLOR_WHITE).Render(b.spinner.View() + " Loading please wait...")
+			if b.progress.Bytes > 0 {
+				received := utils.ByteCountIEC(b.progress.Bytes)
+				if b.progress.Total > 0 {
+					received += " / " + utils.ByteCountIEC(b.progress.Total)
+				}
+				text = lipgloss.JoinVertical(
+					lipgloss.Center,
+					text,
+					received+" ("+utils.ByteCountIEC(int64(b.progress.Rate))+"/s)",
+				)
+			}
 		}
 		message := lipgloss.JoinVertical(
 			lipgloss.Center,
@@ -185,8 +355,26 @@ func (b Results) View() string {
 	}
 
 	header := "Response"
+	if b.Tabs[b.activeTab] == "Tests" {
+		header = "Tests"
+	} else if b.Tabs[b.activeTab] == "Console" {
+		header = "Console"
+	} else if b.diff != nil {
+		header = b.diff.Title()
+	} else if b.events != nil {
+		header = b.events.Title()
+	} else if b.socket != nil {
+		header = b.socket.Title()
+	} else if b.grpc != nil {
+		header = b.grpc.Title()
+	}
 	if b.status != 0 {
 		header += " " + statusStyle.Render(strconv.Itoa(b.status))
+	} else if b.grpc != nil && b.grpc.status != nil {
+		header += " " + b.grpc.Status()
+	}
+	if len(b.results) > 0 {
+		header += " " + assertionsSummary(b.results)
 	}
 	return style.Render(" " + header + "\n\n" + content)
 }
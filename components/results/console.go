@@ -0,0 +1,38 @@
+package results
+
+import (
+	"restman/app"
+	"restman/components/config"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	logWarnStyle  = lipgloss.NewStyle().Foreground(config.COLOR_WARNING)
+	logErrorStyle = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+)
+
+// renderConsole shows the latest output of the scripts that fits the height
+func renderConsole(logs []app.ScriptLog, width int, height int) string {
+	if len(logs) == 0 {
+		return emptyMessage.Render("No script output, use console.log in the Scripts tab of the request")
+	}
+
+	lines := []string{}
+	for _, log := range logs {
+		message := log.Message
+		switch log.Level {
+		case app.LOG_WARN:
+			message = logWarnStyle.Render(message)
+		case app.LOG_ERROR:
+			message = logErrorStyle.Render(message)
+		}
+		prefix := eventTimeStyle.Render(log.Time.Format("15:04:05.000") + " " + log.Source + " ")
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(prefix+message))
+	}
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,207 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"restman/utils"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	removedStyle  = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+	addedStyle    = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	modifiedStyle = lipgloss.NewStyle().Foreground(config.COLOR_WARNING)
+	equalStyle    = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	sectionStyle  = lipgloss.NewStyle().Bold(true).Foreground(config.COLOR_HIGHLIGHT)
+)
+
+// diffView compares the current response against an earlier run or a saved
+// example of the same call.
+type diffView struct {
+	current  app.Response
+	targets  []app.Response
+	names    []string
+	target   int
+	plain    bool
+	width    int
+	viewport viewport.Model
+}
+
+func newDiffView(call *app.Call, width int, height int) *diffView {
+	d := &diffView{viewport: viewport.New(width, height), width: width}
+
+	responses := app.GetInstance().History(call)
+	if len(responses) == 0 {
+		return d
+	}
+	d.current = responses[len(responses)-1]
+
+	// newest runs first, then saved examples
+	for i := len(responses) - 2; i >= 0; i-- {
+		d.targets = append(d.targets, responses[i])
+		d.names = append(d.names, fmt.Sprintf("Run %s", responses[i].ReceivedAt.Format("15:04:05")))
+	}
+	for i, example := range call.Examples {
+		d.targets = append(d.targets, example)
+		name := example.Name
+		if name == "" {
+			name = fmt.Sprintf("Example %d", i+1)
+		}
+		d.names = append(d.names, name)
+	}
+
+	d.render()
+	return d
+}
+
+func (d *diffView) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "]":
+			if len(d.targets) > 0 {
+				d.target = (d.target + 1) % len(d.targets)
+				d.render()
+			}
+		case "[":
+			if len(d.targets) > 0 {
+				d.target = (d.target - 1 + len(d.targets)) % len(d.targets)
+				d.render()
+			}
+		case "m":
+			d.plain = !d.plain
+			d.render()
+		}
+	}
+
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return cmd
+}
+
+func (d *diffView) SetSize(width int, height int) {
+	if d.width != width {
+		d.width = width
+		d.render()
+	}
+	d.viewport.Width = width
+	d.viewport.Height = height
+}
+
+func (d diffView) Title() string {
+	if len(d.targets) == 0 {
+		return "Diff"
+	}
+	mode := "structural"
+	if d.plain {
+		mode = "text"
+	}
+	return fmt.Sprintf("Diff %s ↔ current (%s)", d.names[d.target], mode)
+}
+
+func (d *diffView) render() {
+	if len(d.targets) == 0 {
+		d.viewport.SetContent(emptyMessage.Render("Nothing to compare yet, send the request again or save an example."))
+		return
+	}
+	base := d.targets[d.target]
+	colWidth := (d.width - 3) / 2
+
+	rows := []string{sectionStyle.Render("Status")}
+	if base.Status != d.current.Status {
+		rows = append(rows, d.row(modifiedStyle.Render(fmt.Sprint(base.Status)), modifiedStyle.Render(fmt.Sprint(d.current.Status)), colWidth))
+	} else {
+		rows = append(rows, d.row(equalStyle.Render(fmt.Sprint(base.Status)), equalStyle.Render(fmt.Sprint(d.current.Status)), colWidth))
+	}
+
+	rows = append(rows, "", sectionStyle.Render("Headers"))
+	headerChanges := utils.DiffHeaders(base.Headers, d.current.Headers)
+	if len(headerChanges) == 0 {
+		rows = append(rows, equalStyle.Render("No differences"))
+	}
+	for _, change := range headerChanges {
+		rows = append(rows, d.changeRow(change, fmt.Sprint(change.Old), fmt.Sprint(change.New), colWidth))
+	}
+
+	rows = append(rows, "", sectionStyle.Render("Body"))
+	before, after := base.Content(), d.current.Content()
+
+	var a, b interface{}
+	isJSON := json.Unmarshal([]byte(before), &a) == nil && json.Unmarshal([]byte(after), &b) == nil
+	if isJSON && !d.plain {
+		changes := utils.DiffJSON(a, b)
+		if len(changes) == 0 {
+			rows = append(rows, equalStyle.Render("No differences"))
+		}
+		for _, change := range changes {
+			rows = append(rows, d.changeRow(change, utils.FormatValue(change.Old), utils.FormatValue(change.New), colWidth))
+		}
+	} else {
+		if isJSON {
+			before, after = utils.FormatJSON(before), utils.FormatJSON(after)
+		}
+		rows = append(rows, d.textRows(utils.DiffLines(utils.SplitLines(before), utils.SplitLines(after)), colWidth)...)
+	}
+
+	d.viewport.SetContent(strings.Join(rows, "\n"))
+}
+
+func (d diffView) row(left string, right string, colWidth int) string {
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		lipgloss.NewStyle().Width(colWidth).MaxWidth(colWidth).Render(left),
+		equalStyle.Render(" │ "),
+		lipgloss.NewStyle().Width(colWidth).MaxWidth(colWidth).Render(right),
+	)
+}
+
+func (d diffView) changeRow(change utils.Change, old string, new string, colWidth int) string {
+	switch change.Kind {
+	case utils.ChangeAdded:
+		return d.row("", addedStyle.Render("+ "+change.Path+": "+new), colWidth)
+	case utils.ChangeRemoved:
+		return d.row(removedStyle.Render("- "+change.Path+": "+old), "", colWidth)
+	}
+	return d.row(modifiedStyle.Render("~ "+change.Path+": "+old), modifiedStyle.Render("~ "+change.Path+": "+new), colWidth)
+}
+
+// textRows lays out a line diff side by side, pairing removed lines with the
+// lines which replaced them
+func (d diffView) textRows(lines []utils.DiffLine, colWidth int) []string {
+	rows := []string{}
+	var removed, added []string
+
+	flush := func() {
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			var left, right string
+			if i < len(removed) {
+				left = removedStyle.Render("- " + removed[i])
+			}
+			if i < len(added) {
+				right = addedStyle.Render("+ " + added[i])
+			}
+			rows = append(rows, d.row(left, right, colWidth))
+		}
+		removed, added = nil, nil
+	}
+
+	for _, line := range lines {
+		switch line.Op {
+		case utils.DiffDelete:
+			removed = append(removed, line.Text)
+		case utils.DiffInsert:
+			added = append(added, line.Text)
+		default:
+			flush()
+			rows = append(rows, d.row(equalStyle.Render("  "+line.Text), equalStyle.Render("  "+line.Text), colWidth))
+		}
+	}
+	flush()
+	return rows
+}
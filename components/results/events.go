@@ -0,0 +1,199 @@
+package results
+
+import (
+	"fmt"
+	"os"
+	"restman/app"
+	"restman/components/config"
+	"restman/utils"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// oldest events are dropped once the stream grows past this
+const maxEvents = 5000
+
+var (
+	eventNameStyle = lipgloss.NewStyle().Bold(true).Foreground(config.COLOR_SPECIAL)
+	eventTimeStyle = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	noticeStyle    = lipgloss.NewStyle().Foreground(config.COLOR_WARNING)
+)
+
+// eventsView renders a live Server-Sent Events stream
+type eventsView struct {
+	call     *app.Call
+	events   []app.Event
+	types    []string
+	filter   string
+	paused   bool
+	state    string
+	notice   string
+	viewport viewport.Model
+}
+
+func newEventsView(call *app.Call, width int, height int) *eventsView {
+	return &eventsView{
+		call:     call,
+		state:    "streaming",
+		viewport: viewport.New(width, height),
+	}
+}
+
+func (e *eventsView) Add(event app.Event) {
+	e.events = append(e.events, event)
+	if len(e.events) > maxEvents {
+		e.events = e.events[len(e.events)-maxEvents:]
+	}
+
+	known := false
+	for _, t := range e.types {
+		if t == event.Name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		e.types = append(e.types, event.Name)
+	}
+
+	if !e.paused {
+		e.render()
+	}
+}
+
+func (e *eventsView) SetState(state string, err error) {
+	e.state = state
+	e.notice = ""
+	if err != nil && err.Error() != "EOF" {
+		e.notice = err.Error()
+	}
+}
+
+func (e *eventsView) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "p":
+			e.paused = !e.paused
+			if !e.paused {
+				e.render()
+			}
+			return nil
+
+		case "t":
+			// cycle over all events and every event type seen so far
+			next := ""
+			for i, t := range e.types {
+				if t == e.filter && i+1 < len(e.types) {
+					next = e.types[i+1]
+				}
+			}
+			if e.filter == "" && len(e.types) > 0 {
+				next = e.types[0]
+			}
+			e.filter = next
+			e.render()
+			return nil
+
+		case "s":
+			return app.GetInstance().StopStream(e.call)
+
+		case "w":
+			name := fmt.Sprintf("restman-events-%s.txt", time.Now().Format("20060102-150405"))
+			if err := os.WriteFile(name, []byte(e.Transcript()), 0644); err != nil {
+				e.notice = err.Error()
+			} else {
+				e.notice = "Transcript saved to " + name
+			}
+			return nil
+
+		case "ctrl+e":
+			tmpFile, _ := utils.CreateTempFile(e.Transcript(), "txt")
+			return tea.ExecProcess(utils.OpenInEditorCommand(tmpFile), nil)
+		}
+	}
+
+	var cmd tea.Cmd
+	e.viewport, cmd = e.viewport.Update(msg)
+	return cmd
+}
+
+func (e *eventsView) SetSize(width int, height int) {
+	resized := e.viewport.Width != width
+	e.viewport.Width = width
+	e.viewport.Height = height
+	if resized {
+		e.render()
+	}
+}
+
+func (e eventsView) visible() []app.Event {
+	if e.filter == "" {
+		return e.events
+	}
+	events := []app.Event{}
+	for _, event := range e.events {
+		if event.Name == e.filter {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Transcript returns the visible events in the text/event-stream format
+func (e eventsView) Transcript() string {
+	var b strings.Builder
+	for _, event := range e.visible() {
+		b.WriteString(event.Transcript())
+	}
+	return b.String()
+}
+
+func (e *eventsView) render() {
+	follow := e.viewport.AtBottom()
+
+	lines := []string{}
+	for _, event := range e.visible() {
+		header := eventTimeStyle.Render(event.ReceivedAt.Format("15:04:05.000")) + " " + eventNameStyle.Render(event.Name)
+		if event.ID != "" {
+			header += eventTimeStyle.Render(" #" + event.ID)
+		}
+		lines = append(lines, header)
+		for _, line := range utils.SplitLines(event.Data) {
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(e.viewport.Width).Render("  "+line))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, emptyMessage.Render("Waiting for events..."))
+	}
+	e.viewport.SetContent(strings.Join(lines, "\n"))
+
+	if follow {
+		e.viewport.GotoBottom()
+	}
+}
+
+func (e eventsView) Title() string {
+	state := e.state
+	if e.paused {
+		state = "paused"
+	}
+	filter := "all"
+	if e.filter != "" {
+		filter = e.filter
+	}
+	return fmt.Sprintf("Events (%d) · %s · type: %s", len(e.visible()), state, filter)
+}
+
+func (e eventsView) View() string {
+	help := eventTimeStyle.Render("p pause · t type · s stop · w save")
+	if e.notice != "" {
+		help = noticeStyle.Render(e.notice)
+	}
+	e.viewport.Height--
+	return lipgloss.JoinVertical(lipgloss.Left, e.viewport.View(), help)
+}
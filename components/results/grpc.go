@@ -0,0 +1,154 @@
+package results
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"restman/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	grpcOkStyle    = statusStyle.Background(config.COLOR_SPECIAL).Foreground(lipgloss.Color("#000000"))
+	grpcErrorStyle = statusStyle.Background(config.COLOR_ERROR)
+	metadataStyle  = lipgloss.NewStyle().Foreground(config.COLOR_LIGHTER)
+)
+
+// grpcView shows the response messages of a gRPC call followed by its status
+// and trailers
+type grpcView struct {
+	messages []string
+	status   *app.OnGRPCStatusMsg
+	viewport viewport.Model
+}
+
+func newGRPCView(width int, height int) *grpcView {
+	return &grpcView{viewport: viewport.New(width, height)}
+}
+
+func (g *grpcView) Add(message string) {
+	g.messages = append(g.messages, message)
+	if len(g.messages) > maxEvents {
+		g.messages = g.messages[len(g.messages)-maxEvents:]
+	}
+	g.render()
+}
+
+func (g *grpcView) Done(msg app.OnGRPCStatusMsg) {
+	g.status = &msg
+	g.render()
+}
+
+func (g *grpcView) Update(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "ctrl+e" {
+		tmpFile, _ := utils.CreateTempFile(strings.Join(g.messages, "\n"), "json")
+		return tea.ExecProcess(utils.OpenInEditorCommand(tmpFile), nil)
+	}
+	var cmd tea.Cmd
+	g.viewport, cmd = g.viewport.Update(msg)
+	return cmd
+}
+
+func (g *grpcView) SetSize(width int, height int) {
+	resized := g.viewport.Width != width
+	g.viewport.Width = width
+	g.viewport.Height = height
+	if resized {
+		g.render()
+	}
+}
+
+func renderMetadata(title string, md metadata.MD) []string {
+	if len(md) == 0 {
+		return nil
+	}
+	keys := []string{}
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := []string{eventNameStyle.Render(title)}
+	for _, k := range keys {
+		lines = append(lines, metadataStyle.Render("  "+k+": ")+strings.Join(md[k], ", "))
+	}
+	return lines
+}
+
+func (g *grpcView) render() {
+	follow := g.viewport.AtBottom()
+
+	lines := []string{}
+	for i, message := range g.messages {
+		if len(g.messages) > 1 {
+			lines = append(lines, eventTimeStyle.Render(fmt.Sprintf("#%d", i+1)))
+		}
+		for _, line := range utils.SplitLines(message) {
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(g.viewport.Width).Render(line))
+		}
+	}
+	if len(lines) == 0 && g.status == nil {
+		lines = append(lines, emptyMessage.Render("Waiting for messages..."))
+	}
+
+	if g.status != nil {
+		if g.status.Message != "" {
+			lines = append(lines, "", noticeStyle.Render(g.status.Message))
+		}
+		lines = append(lines, "")
+		lines = append(lines, renderMetadata("Headers", g.status.Header)...)
+		lines = append(lines, renderMetadata("Trailers", g.status.Trailer)...)
+	}
+	g.viewport.SetContent(strings.Join(lines, "\n"))
+
+	if follow {
+		g.viewport.GotoBottom()
+	}
+}
+
+func (g grpcView) Title() string {
+	title := fmt.Sprintf("gRPC (%d messages)", len(g.messages))
+	if g.status != nil {
+		title += " · " + g.status.Duration.Round(time.Millisecond).String()
+	}
+	return title
+}
+
+// Status renders the gRPC status code where the HTTP status is shown for
+// other calls
+func (g grpcView) Status() string {
+	if g.status == nil {
+		return ""
+	}
+	label := fmt.Sprintf("%d %s", g.status.Code, strings.ToUpper(codeName(g.status.Code)))
+	if g.status.Code == codes.OK {
+		return grpcOkStyle.Render(label)
+	}
+	return grpcErrorStyle.Render(label)
+}
+
+// codeName returns the canonical name of the code, e.g. NOT_FOUND
+func codeName(code codes.Code) string {
+	var b strings.Builder
+	previous := 'A'
+	for _, r := range code.String() {
+		if r >= 'A' && r <= 'Z' && previous >= 'a' && previous <= 'z' {
+			b.WriteRune('_')
+		}
+		b.WriteRune(r)
+		previous = r
+	}
+	return b.String()
+}
+
+func (g grpcView) View() string {
+	return g.viewport.View()
+}
@@ -0,0 +1,169 @@
+package results
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"restman/components/config"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// every pagerIndexStep-th line offset is kept, so the index stays small even
+// for huge files
+const pagerIndexStep = 256
+
+// pager pages through a response body spilled to disk without reading the
+// whole file into memory.
+type pager struct {
+	path    string
+	index   []int64
+	lines   int
+	top     int
+	indexed bool
+}
+
+type pagerIndexedMsg struct {
+	path  string
+	index []int64
+	lines int
+}
+
+func newPager(path string) pager {
+	return pager{path: path}
+}
+
+// indexFile scans the file once and records line offsets
+func indexFile(path string) tea.Cmd {
+	return func() tea.Msg {
+		index := []int64{0}
+		lines := 0
+
+		f, err := os.Open(path)
+		if err != nil {
+			return pagerIndexedMsg{path: path, index: index}
+		}
+		defer f.Close()
+
+		var offset int64
+		var last byte
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := f.Read(buf)
+			for i := 0; i < n; i++ {
+				if buf[i] == '\n' {
+					lines++
+					if lines%pagerIndexStep == 0 {
+						index = append(index, offset+int64(i)+1)
+					}
+				}
+			}
+			if n > 0 {
+				last = buf[n-1]
+			}
+			offset += int64(n)
+			if err != nil {
+				break
+			}
+		}
+		// count the last line when the file does not end with a newline
+		if offset > 0 && last != '\n' {
+			lines++
+		}
+
+		return pagerIndexedMsg{path: path, index: index, lines: lines}
+	}
+}
+
+func (p pager) Update(msg tea.Msg, height int) pager {
+	switch msg := msg.(type) {
+	case pagerIndexedMsg:
+		if msg.path == p.path {
+			p.index = msg.index
+			p.lines = msg.lines
+			p.indexed = true
+		}
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			p.top--
+		case "down", "j":
+			p.top++
+		case "pgup", "b":
+			p.top -= height
+		case "pgdown", "f", " ":
+			p.top += height
+		case "home", "g":
+			p.top = 0
+		case "end", "G":
+			p.top = p.lines
+		}
+
+	case tea.MouseMsg:
+		switch msg.Type {
+		case tea.MouseWheelUp:
+			p.top -= 3
+		case tea.MouseWheelDown:
+			p.top += 3
+		}
+	}
+
+	p.top = max(0, min(p.top, p.lines-height))
+	return p
+}
+
+// readLine reads a full line from r, keeping at most limit bytes of it
+func readLine(r *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return string(line), err
+		}
+		if len(line) < limit {
+			line = append(line, chunk[:min(len(chunk), limit-len(line))]...)
+		}
+		if !isPrefix {
+			return strings.ToValidUTF8(string(line), ""), nil
+		}
+	}
+}
+
+func (p pager) View(width int, height int) string {
+	if !p.indexed {
+		return emptyMessage.Render("Indexing response body...")
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return emptyMessage.Render(err.Error())
+	}
+	defer f.Close()
+
+	block := min(p.top/pagerIndexStep, len(p.index)-1)
+	if _, err := f.Seek(p.index[block], io.SeekStart); err != nil {
+		return emptyMessage.Render(err.Error())
+	}
+
+	maxDigits := len(strconv.Itoa(p.lines))
+	r := bufio.NewReader(f)
+	lines := []string{}
+	for nr := block * pagerIndexStep; len(lines) < height; nr++ {
+		line, err := readLine(r, width)
+		if err != nil {
+			break
+		}
+		if nr < p.top {
+			continue
+		}
+		linenr := strconv.Itoa(nr + 1)
+		line = strings.Repeat(" ", maxDigits-len(linenr)) + linenr + "  " + line
+		lines = append(lines, lipgloss.NewStyle().Foreground(config.COLOR_GRAY).MaxWidth(width).Render(line))
+	}
+
+	return strings.Join(lines, "\n")
+}
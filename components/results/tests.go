@@ -0,0 +1,47 @@
+package results
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	passedStyle = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	failedStyle = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+)
+
+// assertionsSummary renders the pass/fail count shown next to the status
+func assertionsSummary(results []app.AssertionResult) string {
+	passed := app.CountPassed(results)
+	style := statusStyle.Background(config.COLOR_SPECIAL).Foreground(lipgloss.Color("#000000"))
+	if passed < len(results) {
+		style = statusStyle.Background(config.COLOR_ERROR)
+	}
+	return style.Render(fmt.Sprintf("%d/%d passed", passed, len(results)))
+}
+
+// renderAssertions lists every assertion of the last response with the
+// reason of the failures
+func renderAssertions(results []app.AssertionResult, call *app.Call, width int) string {
+	if len(results) == 0 {
+		message := "Not sent yet"
+		if call != nil && len(call.Assertions) == 0 {
+			message = "No assertions, add them in the Tests tab of the request"
+		}
+		return emptyMessage.Render(message)
+	}
+
+	lines := []string{}
+	for _, result := range results {
+		line := passedStyle.Render("✓ ") + result.Assertion.String()
+		if !result.Passed {
+			line = failedStyle.Render("✗ ") + result.Assertion.String() + eventTimeStyle.Render(" · "+result.Message)
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,190 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"restman/app"
+	"restman/components"
+	"restman/components/config"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	FRAME_TEXT = "Text"
+	FRAME_JSON = "JSON"
+)
+
+var (
+	sentStyle     = lipgloss.NewStyle().Foreground(config.COLOR_LINK)
+	receivedStyle = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	controlStyle  = lipgloss.NewStyle().Foreground(config.COLOR_GRAY).Italic(true)
+)
+
+// socketView shows the frame log of a WebSocket connection with a box to
+// send new frames
+type socketView struct {
+	call     *app.Call
+	frames   []app.Frame
+	open     bool
+	notice   string
+	input    textinput.Model
+	toggle   components.ToggleModel
+	viewport viewport.Model
+}
+
+func newSocketView(call *app.Call, width int, height int) *socketView {
+	input := textinput.New()
+	input.Placeholder = "message"
+	input.Prompt = "󱞩 "
+	input.Focus()
+
+	s := &socketView{
+		call:     call,
+		open:     true,
+		input:    input,
+		toggle:   components.NewToggle("Frame", []string{FRAME_TEXT, FRAME_JSON}, FRAME_TEXT),
+		viewport: viewport.New(width, height),
+	}
+	s.render()
+	return s
+}
+
+func (s *socketView) Add(frame app.Frame) {
+	s.frames = append(s.frames, frame)
+	if len(s.frames) > maxEvents {
+		s.frames = s.frames[len(s.frames)-maxEvents:]
+	}
+	s.render()
+}
+
+func (s *socketView) Closed(msg app.OnWebSocketClosedMsg) {
+	s.open = false
+	switch {
+	case msg.Err != nil:
+		s.notice = "Connection closed: " + msg.Err.Error()
+	case msg.Reason != "":
+		s.notice = fmt.Sprintf("Connection closed: %d %s", msg.Code, msg.Reason)
+	default:
+		s.notice = fmt.Sprintf("Connection closed: %d", msg.Code)
+	}
+}
+
+func (s *socketView) send() tea.Cmd {
+	value := s.input.Value()
+	if value == "" || !s.open {
+		return nil
+	}
+
+	if s.toggle.Selected() == FRAME_JSON {
+		var obj interface{}
+		if err := json.Unmarshal([]byte(value), &obj); err != nil {
+			s.notice = "Invalid JSON: " + err.Error()
+			return nil
+		}
+		data, _ := json.Marshal(obj)
+		value = string(data)
+	}
+
+	s.notice = ""
+	s.input.SetValue("")
+	return app.GetInstance().SendFrame(s.call, value)
+}
+
+func (s *socketView) Update(msg tea.Msg) tea.Cmd {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			return s.send()
+		case "ctrl+t":
+			return s.toggle.Next()
+		case "ctrl+p":
+			return app.GetInstance().Ping(s.call)
+		case "ctrl+x":
+			return app.GetInstance().CloseWebSocket(s.call)
+		case "up", "down", "pgup", "pgdown":
+			var cmd tea.Cmd
+			s.viewport, cmd = s.viewport.Update(msg)
+			return cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	s.toggle, cmd = s.toggle.Update(msg)
+	cmds = append(cmds, cmd)
+
+	s.input, cmd = s.input.Update(msg)
+	cmds = append(cmds, cmd)
+
+	s.viewport, cmd = s.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (s *socketView) SetSize(width int, height int) {
+	resized := s.viewport.Width != width
+	s.viewport.Width = width
+	// leave room for the send box
+	s.viewport.Height = height - 4
+	s.input.Width = width - 6
+	if resized {
+		s.render()
+	}
+}
+
+func (s *socketView) render() {
+	follow := s.viewport.AtBottom()
+
+	lines := []string{}
+	for _, frame := range s.frames {
+		arrow, style := "←", receivedStyle
+		if frame.Direction == app.FRAME_SENT {
+			arrow, style = "→", sentStyle
+		}
+		line := eventTimeStyle.Render(frame.Time.Format("15:04:05.000")) + " " + style.Render(arrow) + " "
+		if frame.Type == "text" || frame.Type == "binary" {
+			line += frame.Data
+		} else {
+			line += controlStyle.Render(strings.TrimSpace(frame.Type + " " + frame.Data))
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(s.viewport.Width).Render(line))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, emptyMessage.Render("Connected, no frames yet"))
+	}
+	s.viewport.SetContent(strings.Join(lines, "\n"))
+
+	if follow {
+		s.viewport.GotoBottom()
+	}
+}
+
+func (s socketView) Title() string {
+	state := "open"
+	if !s.open {
+		state = "closed"
+	}
+	return fmt.Sprintf("WebSocket (%d frames) · %s", len(s.frames), state)
+}
+
+func (s socketView) View() string {
+	help := eventTimeStyle.Render("enter send · ctrl+t frame type · ctrl+p ping · ctrl+x close")
+	if s.notice != "" {
+		help = noticeStyle.Render(s.notice)
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		s.viewport.View(),
+		help,
+		s.toggle.View(),
+		config.InputStyle.Render(s.input.View()),
+	)
+}
@@ -0,0 +1,311 @@
+package runner
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"restman/components/overlay"
+	"restman/components/popup"
+	"restman/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	FOLDER_IDX = iota
+	TAGS_IDX
+	ITERATIONS_IDX
+	DELAY_IDX
+	ENVIRONMENT_IDX
+	DATA_IDX
+	STOP_IDX
+	CANCEL_IDX
+	OK_IDX
+)
+
+const NUM_OF_INPUTS = 9
+
+var (
+	general = lipgloss.NewStyle().
+		UnsetAlign().
+		Padding(0, 1, 0, 1).
+		Foreground(config.COLOR_FOREGROUND).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(config.COLOR_HIGHLIGHT)
+
+	grayStyle   = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	passedStyle = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	failedStyle = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+)
+
+// Runner is a popup running the calls of a collection, first showing the
+// run options and then a live table of the results
+type Runner struct {
+	collection app.Collection
+	width      int
+	height     int
+	bgRaw      string
+	focused    int
+	inputs     []textinput.Model
+	stop       bool
+	errors     []string
+
+	running  bool
+	finished bool
+	total    int
+	results  []app.RunResult
+	err      error
+}
+
+func New(collection app.Collection, bgRaw string, width int) Runner {
+	inputs := make([]textinput.Model, DATA_IDX+1)
+	placeholders := []string{"all folders", "all tags, e.g. smoke, auth", "1", "0ms", "current environment", "none, path to a CSV or JSON file"}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = "󱞩 "
+		inputs[i].Placeholder = placeholders[i]
+	}
+	if env := app.GetInstance().SelectedEnvironment; env != nil {
+		inputs[ENVIRONMENT_IDX].SetValue(env.Name)
+	}
+	inputs[FOLDER_IDX].Focus()
+
+	return Runner{
+		collection: collection,
+		width:      width,
+		height:     len(strings.Split(bgRaw, "\n")) - 4,
+		bgRaw:      bgRaw,
+		inputs:     inputs,
+	}
+}
+
+// Init initializes the popup.
+func (c Runner) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// options reads the run options from the inputs
+func (c Runner) options() (app.RunOptions, []string) {
+	errors := []string{}
+	opts := app.RunOptions{
+		Folder:        strings.TrimSpace(c.inputs[FOLDER_IDX].Value()),
+		StopOnFailure: c.stop,
+		Environment:   strings.TrimSpace(c.inputs[ENVIRONMENT_IDX].Value()),
+	}
+	for _, tag := range strings.Split(c.inputs[TAGS_IDX].Value(), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	if value := strings.TrimSpace(c.inputs[ITERATIONS_IDX].Value()); value != "" {
+		iterations, err := strconv.Atoi(value)
+		if err != nil || iterations < 1 {
+			errors = append(errors, "Iterations must be a positive number")
+		}
+		opts.Iterations = iterations
+	}
+	if value := strings.TrimSpace(c.inputs[DELAY_IDX].Value()); value != "" {
+		delay, err := app.ParseMilliseconds(value)
+		if err != nil {
+			errors = append(errors, "Delay must be milliseconds or a duration like 1.5s")
+		}
+		opts.Delay = delay
+	}
+	if path := strings.TrimSpace(c.inputs[DATA_IDX].Value()); path != "" {
+		rows, err := app.ReadDataFile(path)
+		if err != nil {
+			errors = append(errors, "Data file: "+err.Error())
+		}
+		opts.Data = rows
+	}
+	if len(app.RunnerCalls(c.collection, opts)) == 0 {
+		errors = append(errors, "No calls match the folder and tags")
+	}
+	return opts, errors
+}
+
+// Update handles messages.
+func (c Runner) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.OnRunProgressMsg:
+		c.results = append(c.results, msg.Result)
+		c.total = msg.Total
+		return c, nil
+
+	case app.OnRunFinishedMsg:
+		c.running = false
+		c.finished = true
+		c.err = msg.Err
+		return c, nil
+
+	case tea.KeyMsg:
+		if c.running || c.finished {
+			switch msg.String() {
+			case "esc", "enter", "q":
+				app.GetInstance().StopRun()
+				return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+			}
+			return c, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyShiftTab, tea.KeyCtrlP, tea.KeyUp:
+			c.focused = (c.focused - 1 + NUM_OF_INPUTS) % NUM_OF_INPUTS
+
+		case tea.KeyTab, tea.KeyCtrlN, tea.KeyDown:
+			c.focused = (c.focused + 1) % NUM_OF_INPUTS
+
+		case tea.KeySpace:
+			if c.focused == STOP_IDX {
+				c.stop = !c.stop
+				return c, nil
+			}
+
+		case tea.KeyEnter:
+			switch c.focused {
+			case STOP_IDX:
+				c.stop = !c.stop
+				return c, nil
+			case CANCEL_IDX:
+				return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+			default:
+				var opts app.RunOptions
+				opts, c.errors = c.options()
+				if len(c.errors) > 0 {
+					return c, nil
+				}
+				c.running = true
+				return c, app.GetInstance().StartRun(c.collection, opts)
+			}
+
+		case tea.KeyEsc:
+			return c, func() tea.Msg { return popup.ClosePopupMsg{} }
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i := range c.inputs {
+		if i == c.focused {
+			c.inputs[i].Focus()
+		} else {
+			c.inputs[i].Blur()
+		}
+		var cmd tea.Cmd
+		c.inputs[i], cmd = c.inputs[i].Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return c, tea.Batch(cmds...)
+}
+
+func (c Runner) optionsView() string {
+	labels := []string{"Folder:", "Tags:", "Iterations:", "Delay:", "Environment:", "Data file:"}
+	rows := []string{}
+	for i, input := range c.inputs {
+		rows = append(rows, config.LabelStyle.Render(labels[i]), config.InputStyle.Render(input.View()))
+	}
+
+	check := "[ ]"
+	if c.stop {
+		check = "[x]"
+	}
+	stop := check + " Stop on failure"
+	if c.focused == STOP_IDX {
+		stop = lipgloss.NewStyle().Foreground(config.COLOR_HIGHLIGHT).Render(stop)
+	}
+	rows = append(rows, "", stop)
+
+	okButtonStyle := config.ButtonStyle
+	cancelButtonStyle := config.ButtonStyle
+	if c.focused == CANCEL_IDX {
+		cancelButtonStyle = config.ActiveButtonStyle
+	} else if c.focused == OK_IDX {
+		okButtonStyle = config.ActiveButtonStyle
+	}
+	footer := lipgloss.PlaceHorizontal(
+		c.width,
+		lipgloss.Right,
+		lipgloss.JoinHorizontal(lipgloss.Right, cancelButtonStyle.Render("Cancel"), " ", okButtonStyle.Render("Run")),
+	)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		append(rows, utils.RenderErrors(c.errors), footer)...,
+	)
+}
+
+// resultsView renders the progress table, the latest rows when they do not
+// fit the popup
+func (c Runner) resultsView() string {
+	nameWidth := max(c.width-42, 10)
+	row := func(iteration, name, status, duration, result string) string {
+		return fmt.Sprintf("%-4s %-*s %-8s %-10s %s", iteration, nameWidth, name, status, duration, result)
+	}
+
+	rows := []string{}
+	passed := 0
+	for _, r := range c.results {
+		status := strconv.Itoa(r.Status)
+		result := passedStyle.Render("✓ passed")
+		if r.Passed() {
+			passed++
+		} else {
+			result = failedStyle.Render("✗ failed")
+		}
+		if r.Err != nil {
+			status = "-"
+			result = failedStyle.Render("✗ " + r.Err.Error())
+		} else if len(r.Assertions) > 0 {
+			result += grayStyle.Render(fmt.Sprintf(" %d/%d", app.CountPassed(r.Assertions), len(r.Assertions)))
+		}
+		name := r.Call.Title()
+		if len(name) > nameWidth {
+			name = name[:nameWidth-1] + "…"
+		}
+		line := row(strconv.Itoa(r.Iteration), name, status, r.Duration.Round(time.Millisecond).String(), result)
+		rows = append(rows, lipgloss.NewStyle().MaxWidth(c.width).Render(line))
+	}
+	if visible := max(c.height-8, 1); len(rows) > visible {
+		rows = rows[len(rows)-visible:]
+	}
+
+	summary := fmt.Sprintf("Running %d/%d", len(c.results), c.total)
+	if c.finished {
+		summary = fmt.Sprintf("%d/%d passed", passed, len(c.results))
+		if c.err != nil {
+			summary += " · " + c.err.Error()
+		}
+	}
+
+	help := "esc cancel"
+	if c.finished {
+		help = "enter close"
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		append([]string{grayStyle.Render(row("#", "Call", "Status", "Time", "Result")), ""}, append(rows, "", summary, grayStyle.Render(help))...)...,
+	)
+}
+
+func (c Runner) View() string {
+	content := c.optionsView()
+	if c.running || c.finished {
+		content = c.resultsView()
+	}
+
+	formView := lipgloss.JoinVertical(
+		lipgloss.Left,
+		config.BoxHeader.Render("Run "+c.collection.Name),
+		"",
+		content,
+	)
+
+	view := general.Width(c.width).Render(formView)
+	startCol, startRow := utils.GetStartColRow(view, c.bgRaw)
+	return overlay.PlaceOverlay(startCol, startRow, view, c.bgRaw)
+}
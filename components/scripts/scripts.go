@@ -0,0 +1,155 @@
+package scripts
+
+import (
+	"restman/app"
+	"restman/components/config"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	general = lipgloss.NewStyle().
+		UnsetAlign().
+		Padding(0, 2).
+		Foreground(config.COLOR_FOREGROUND)
+
+	helpStyle     = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	sectionStyle  = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+	activeSection = lipgloss.NewStyle().Bold(true).Foreground(config.COLOR_HIGHLIGHT)
+)
+
+const prePlaceholder = `// runs before the request is sent
+rm.request.headers["X-Timestamp"] = String(rm.timestamp())`
+
+const postPlaceholder = `// runs after the response is received
+rm.test("status is 200", () => rm.assert(rm.response.status === 200))
+rm.variables.set("token", rm.response.json().token)`
+
+// Model edits the pre-request and post-response scripts of a call or of its
+// collection
+type Model struct {
+	call         *app.Call
+	pre          textarea.Model
+	post         textarea.Model
+	editing      int // 0 pre-request, 1 post-response
+	onCollection bool
+}
+
+func newEditor(placeholder string, width int, height int) textarea.Model {
+	ti := textarea.New()
+	ti.CharLimit = 0
+	ti.Prompt = ""
+	ti.Placeholder = placeholder
+	ti.SetWidth(width - 4)
+	ti.SetHeight(height)
+	return ti
+}
+
+func New(call *app.Call, width int, height int) Model {
+	editorHeight := max((height-7)/2, 2)
+	m := Model{
+		call: call,
+		pre:  newEditor(prePlaceholder, width, editorHeight),
+		post: newEditor(postPlaceholder, width, editorHeight),
+	}
+	m.load()
+	m.pre.Focus()
+	return m
+}
+
+// scripts returns the scripts being edited
+func (m Model) scripts() app.Scripts {
+	var scripts *app.Scripts
+	if m.call != nil {
+		scripts = m.call.Scripts
+		if m.onCollection {
+			scripts = nil
+			if collection := m.call.Collection(); collection != nil {
+				scripts = collection.Scripts
+			}
+		}
+	}
+	if scripts == nil {
+		return app.Scripts{}
+	}
+	return *scripts
+}
+
+func (m *Model) load() {
+	scripts := m.scripts()
+	m.pre.SetValue(scripts.PreRequest)
+	m.post.SetValue(scripts.PostResponse)
+}
+
+func (m Model) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+y":
+			m.editing = 1 - m.editing
+			m.pre.Blur()
+			m.post.Blur()
+			if m.editing == 0 {
+				return m, m.pre.Focus()
+			}
+			return m, m.post.Focus()
+
+		case "ctrl+g":
+			// scripts of a collection need a saved call
+			if m.call != nil && m.call.Collection() != nil {
+				m.onCollection = !m.onCollection
+				m.load()
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.editing == 0 {
+		m.pre, cmd = m.pre.Update(msg)
+	} else {
+		m.post, cmd = m.post.Update(msg)
+	}
+
+	if _, ok := msg.(tea.KeyMsg); !ok || m.call == nil {
+		return m, cmd
+	}
+
+	scripts := app.Scripts{PreRequest: m.pre.Value(), PostResponse: m.post.Value()}
+	if scripts != m.scripts() {
+		return m, tea.Batch(cmd, app.GetInstance().SetCallScripts(m.call, scripts, m.onCollection))
+	}
+	return m, cmd
+}
+
+func (m Model) title(text string, index int) string {
+	if m.editing == index {
+		return activeSection.Render(text)
+	}
+	return sectionStyle.Render(text)
+}
+
+func (m Model) View() string {
+	scope := "call"
+	if m.onCollection {
+		scope = "collection"
+	}
+
+	return general.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.title("Pre-request", 0)+sectionStyle.Render(" · "+scope),
+			m.pre.View(),
+			"",
+			m.title("Post-response", 1)+sectionStyle.Render(" · "+scope),
+			m.post.View(),
+			"",
+			helpStyle.Render("ctrl+y switch editor · ctrl+g call/collection scripts · output in the Console tab of the results"),
+		),
+	)
+}
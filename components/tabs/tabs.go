@@ -0,0 +1,100 @@
+package tabs
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/config"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+// longest title shown in a tab
+const maxTitleWidth = 24
+
+var (
+	tabStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(config.COLOR_GRAY)
+
+	activeTabStyle = tabStyle.
+			Foreground(config.COLOR_FOREGROUND).
+			Background(config.COLOR_SUBTLE).
+			Bold(true)
+
+	modifiedStyle = lipgloss.NewStyle().Foreground(config.COLOR_WARNING)
+	newTabStyle   = lipgloss.NewStyle().Padding(0, 1).Foreground(config.COLOR_HIGHLIGHT)
+)
+
+// Tab is what the strip shows of an open request tab
+type Tab struct {
+	Call     *app.Call
+	Modified bool
+}
+
+func (t Tab) title() string {
+	if t.Call == nil || (t.Call.Name == "" && t.Call.Url == "") {
+		return "New request"
+	}
+	title := t.Call.Title()
+	if len([]rune(title)) > maxTitleWidth {
+		title = string([]rune(title)[:maxTitleWidth-1]) + "…"
+	}
+	return title
+}
+
+// Model is the strip of open request tabs above the url
+type Model struct {
+	width  int
+	tabs   []Tab
+	active int
+}
+
+func New() Model {
+	return Model{}
+}
+
+// SetTabs replaces the tabs shown
+func (m Model) SetTabs(tabs []Tab, active int) Model {
+	m.tabs = tabs
+	m.active = active
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width = msg.Width
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	rendered := []string{}
+	for i, tab := range m.tabs {
+		style := tabStyle
+		if i == m.active {
+			style = activeTabStyle
+		}
+		marker := ""
+		if tab.Modified {
+			marker = modifiedStyle.Render("●")
+		}
+		rendered = append(rendered, zone.Mark(fmt.Sprintf("request_tab_%d", i), style.Render(tab.title())+marker))
+	}
+	rendered = append(rendered, zone.Mark("request_tab_new", newTabStyle.Render("+")))
+
+	// the tabs which do not fit are cut off at the right, the active one
+	// is kept visible
+	row := strings.Join(rendered, "")
+	for start := 0; lipgloss.Width(row) > m.width && start < m.active; {
+		start++
+		row = strings.Join(rendered[start:], "")
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(row)
+}
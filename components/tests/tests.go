@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"restman/app"
+	"restman/components/config"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	general = lipgloss.NewStyle().
+		UnsetAlign().
+		Padding(1, 2).
+		Foreground(config.COLOR_FOREGROUND)
+
+	helpStyle = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+)
+
+const placeholder = `status in 2xx
+header Content-Type matches ^application/json
+jsonpath $.id == 42
+body contains "ok"
+time < 500
+size < 1024
+set token = jsonpath $.access_token`
+
+// Model edits the assertions and extraction rules of a call, one per line
+type Model struct {
+	call     *app.Call
+	textarea textarea.Model
+	errors   map[int]error
+}
+
+func New(call *app.Call, width int, height int) Model {
+	ti := textarea.New()
+	ti.CharLimit = 0
+	ti.Prompt = ""
+	ti.Placeholder = placeholder
+	ti.SetWidth(width - 4)
+	ti.SetHeight(height - 6)
+	ti.Focus()
+	if call != nil {
+		ti.SetValue(app.FormatTests(call.Assertions, call.Extractions))
+	}
+
+	return Model{
+		call:     call,
+		textarea: ti,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+
+	if _, ok := msg.(tea.KeyMsg); !ok || m.call == nil {
+		return m, cmd
+	}
+
+	// lines with errors are left out until they are fixed
+	var assertions []app.Assertion
+	var extractions []app.Extraction
+	assertions, extractions, m.errors = app.ParseTests(m.textarea.Value())
+	if len(assertions) == 0 {
+		assertions = nil
+	}
+	if len(extractions) == 0 {
+		extractions = nil
+	}
+	if !reflect.DeepEqual(assertions, m.call.Assertions) || !reflect.DeepEqual(extractions, m.call.Extractions) {
+		return m, tea.Batch(cmd, app.GetInstance().SetCallTests(m.call, assertions, extractions))
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	status := helpStyle.Render("one per line: status, header, jsonpath, body, time, size or set <variable> = <jsonpath|header|cookie|regex> ...")
+	if len(m.errors) > 0 {
+		lines := []int{}
+		for line := range m.errors {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		status = config.ErrorStyle.Render(fmt.Sprintf("line %d: %s", lines[0], m.errors[lines[0]]))
+	}
+
+	return general.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.textarea.View(),
+			"",
+			status,
+		),
+	)
+}
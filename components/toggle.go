@@ -59,6 +59,10 @@ func (c *ToggleModel) Next() tea.Cmd {
 	}
 }
 
+func (c ToggleModel) Selected() string {
+	return c.options[c.selected]
+}
+
 func (c ToggleModel) Update(msg tea.Msg) (ToggleModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.MouseMsg:
@@ -81,6 +81,11 @@ func (m Url) Call() *app.Call {
 	return m.call
 }
 
+// CurrentCall returns the call shown, nil when nothing was selected or sent
+func (m Url) CurrentCall() *app.Call {
+	return m.call
+}
+
 func (m Url) Init() tea.Cmd {
 	return nil
 }
@@ -106,10 +111,21 @@ func (m Url) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.defaultText = m.call.Url
 			m.t.SetValue(m.defaultText)
 			m.method = m.call.Method
+			m.modified = m.call.WasChanged()
 		} else {
 			m.call = nil
 			m.defaultText = ""
 			m.t.SetValue(m.defaultText)
+			m.modified = false
+		}
+
+	case app.CallUpdatedMsg:
+		// the params table re-encodes the URL
+		if m.call != nil && msg.Call == m.call {
+			if m.t.Prompt+m.t.Value() != m.call.Url {
+				m.t.SetValue(m.call.Url)
+			}
+			m.modified = m.call.WasChanged()
 		}
 
 	case tea.WindowSizeMsg:
@@ -141,7 +157,14 @@ func (m Url) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// check if call was modified
 		if m.call != nil {
-			m.call.Url = m.t.Prompt + m.t.Value()
+			url := m.t.Prompt + m.t.Value()
+			if url != m.call.Url {
+				m.call.Url = url
+				call := m.call
+				cmd = tea.Batch(cmd, func() tea.Msg {
+					return app.CallUpdatedMsg{Call: call}
+				})
+			}
 			m.modified = m.call.WasChanged()
 		}
 
@@ -172,7 +195,13 @@ func (m Url) View() string {
 	if m.focused {
 		style = focused
 	}
-	method := zone.Mark("method", config.Methods[m.method])
+	methodName := m.method
+	if app.IsWebSocketUrl(m.t.Value()) {
+		methodName = config.WS
+	} else if app.IsGRPCUrl(m.t.Value()) {
+		methodName = config.GRPC
+	}
+	method := zone.Mark("method", config.Methods[methodName])
 	send := zone.Mark("send", buttonStyle.Render(" SEND "))
 
 	w := 7
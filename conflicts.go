@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/popup"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// confirmConflicts asks which changes win when collections were changed both
+// here and on disk. An open popup is not replaced, the conflict is reported
+// again on the next save.
+func (m *Model) confirmConflicts(names []string) tea.Cmd {
+	if m.popup != nil {
+		return nil
+	}
+
+	changed := strings.Join(names, ", ")
+	if len(names) > 3 {
+		changed = fmt.Sprintf("%s and %d more", strings.Join(names[:3], ", "), len(names)-3)
+	}
+	question := "Changed here and on disk: " + changed
+
+	m.popup = popup.NewConflict(m.GetFadedView(), 100, question)
+	return m.popup.Init()
+}
+
+// resolveConflicts saves the collections with the changes the user picked
+func resolveConflicts(takeTheirs bool) tea.Cmd {
+	resolution := app.KeepMine
+	if takeTheirs {
+		resolution = app.TakeTheirs
+	}
+	return app.GetInstance().ResolveConflicts(resolution)
+}
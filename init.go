@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"restman/components/config"
+
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [collection...]",
+	Short: "Create a workspace for the collections of a project",
+	Long: `Create a .restman workspace in the current directory. Collections of a
+workspace are stored with one file per call, so they can be committed and
+reviewed with the code of the project.
+
+The global collections named are moved into the workspace. Collections
+created while restman runs inside the project are added to it.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.SetVersion(version)
+		readConfig()
+
+		a := readCollections()
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		// credentials are moved to the secrets store when the collections
+		// are written
+		if a.HasPlaintextCredentials() {
+			if err := unlockSecrets(a, true); err != nil {
+				return err
+			}
+		}
+		workspace, err := a.InitWorkspace(cwd, args...)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Workspace created in", workspace)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
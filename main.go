@@ -1,12 +1,19 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"restman/app"
+	"restman/components/bench"
 	"restman/components/collections"
 	"restman/components/config"
+	"restman/components/headers"
 	"restman/components/importer"
+	"restman/components/mock"
+	"restman/components/params"
 	"restman/components/popup"
 	"restman/components/request"
+	"restman/components/runner"
 	"restman/components/url"
 	"restman/utils"
 
@@ -69,7 +76,9 @@ func main() {
 	rootCmd.Flags().StringP("user-agent", "", "", "Send User-Agent <name> to server")
 	rootCmd.Flags().StringP("referer", "", "", "Send Referer <URL> to server")
 
-	rootCmd.Execute()
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(exitCode(err))
+	}
 }
 
 type Model struct {
@@ -80,6 +89,10 @@ type Model struct {
 	initialCall *app.Call
 	width       int
 	height      int
+	tabs        []requestTab
+	activeTab   int
+	// action waiting for the unsaved changes popup
+	pending int
 }
 
 func (m Model) Init() tea.Cmd {
@@ -100,11 +113,19 @@ func (m Model) Init() tea.Cmd {
 		runCmd = app.GetInstance().GetResponse(m.initialCall)
 	}
 
-	return tea.Sequence(
-		app.GetInstance().ReadCollectionsFromJSON(),
-		focusCmd,
-		initalCallCmd,
-		runCmd,
+	// the collections are read first, the workspace watched depends on them
+	readCmd := app.GetInstance().ReadCollectionsFromJSON()
+
+	return tea.Batch(
+		tea.Sequence(
+			readCmd,
+			app.GetInstance().CheckSecrets(),
+			focusCmd,
+			restoreTabs,
+			initalCallCmd,
+			runCmd,
+		),
+		app.GetInstance().WatchCollections(),
 	)
 }
 
@@ -221,7 +242,20 @@ func (m Model) GetFadedView() string {
 	return lipgloss.NewStyle().Foreground(config.COLOR_SUBTLE).Render(utils.RemoveANSI(m.View()))
 }
 
+// Update passes the message on, then shows the state of the open tabs as
+// any update can open, close or edit them
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	switch model := updated.(type) {
+	case Model:
+		model.refreshTabs()
+	case *Model:
+		model.refreshTabs()
+	}
+	return updated, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -234,11 +268,45 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+	case popup.UnsavedResultMsg:
+		m.popup = nil
+		return m, m.resolveUnsaved(msg.Result)
+
+	case popup.ConflictResultMsg:
+		m.popup = nil
+		return m, resolveConflicts(msg.TakeTheirs)
+
+	case app.CollectionsChangedMsg:
+		return m, tea.Batch(app.GetInstance().ReloadCollections(), app.GetInstance().WatchCollections())
+
+	case app.CollectionsConflictMsg:
+		return m, m.confirmConflicts(msg.Names)
+
+	case app.SecretsLockedMsg:
+		return m, m.askPassphrase(nil)
+
+	case popup.PassphraseMsg:
+		return m, m.unlockSecrets(msg.Passphrase)
+
 	case popup.ClosePopupMsg:
 		m.popup = nil
 
+	case app.OnResponseMsg:
+		// the variables extracted while the response was read
+		cmds = append(cmds, app.GetInstance().ApplyVariables(msg.Call, msg.Variables))
+
+	case app.OnRunProgressMsg:
+		cmds = append(cmds, msg.Next(), app.GetInstance().ApplyVariables(&msg.Result.Call, msg.Result.Variables))
+
+	case app.StreamingMsg:
+		// keep downloads and streams going, even when a popup is shown
+		cmds = append(cmds, msg.Next())
+
 	case app.CallSelectedMsg:
 		m.SetFocused("url")
+		if cmd, handled := m.openTab(msg.Call); handled {
+			return m, cmd
+		}
 
 	case tea.MouseMsg:
 		if msg.Type == tea.MouseLeft {
@@ -253,8 +321,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else if zone.Get("send").InBounds(msg) {
 				m.SetFocused("url")
 				url := m.getUrlPane()
-				_, cmd = url.Submit()
-				m.tui.ModelMap["url"] = url
+				m.tui.ModelMap["url"], cmd = url.Submit()
 				return m, cmd
 
 			} else if zone.Get("save").InBounds(msg) {
@@ -290,12 +357,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				request.SetActiveTab(0)
 				m.tui.ModelMap["request"] = request
 
+			} else if zone.Get("add_param").InBounds(msg) {
+				m.SetFocused("request")
+				return m, func() tea.Msg { return params.AddParamMsg{} }
+
 			} else if zone.Get("tab_Headers").InBounds(msg) {
 				m.SetFocused("request")
 				request := m.getRequestPane()
 				request.SetActiveTab(1)
 				m.tui.ModelMap["request"] = request
 
+			} else if zone.Get("add_header").InBounds(msg) {
+				m.SetFocused("request")
+				return m, func() tea.Msg { return headers.AddHeaderMsg{} }
+
 			} else if zone.Get("tab_Auth").InBounds(msg) {
 				m.SetFocused("request")
 				request := m.getRequestPane()
@@ -308,12 +383,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				request.SetActiveTab(3)
 				m.tui.ModelMap["request"] = request
 
+			} else if zone.Get("tab_Tests").InBounds(msg) {
+				m.SetFocused("request")
+				request := m.getRequestPane()
+				request.SetActiveTab(4)
+				m.tui.ModelMap["request"] = request
+
+			} else if zone.Get("tab_Scripts").InBounds(msg) {
+				m.SetFocused("request")
+				request := m.getRequestPane()
+				request.SetActiveTab(5)
+				m.tui.ModelMap["request"] = request
+
 			} else if zone.Get("collections_minify").InBounds(msg) {
 				m.tui.ModelMap["collections"], cmd = m.tui.ModelMap["collections"].(collections.Collections).SetMinified(true)
 				m.tui.UpdateSize(tea.WindowSizeMsg{Width: m.tui.LayoutTree.GetWidth(), Height: m.tui.LayoutTree.GetHeight()})
 				return m.SetFocused("url")
 			} else if zone.Get("collections").InBounds(msg) {
 				return m.SetFocused("collections")
+			} else if zone.Get("request_tab_new").InBounds(msg) {
+				return m, m.newTab()
+			} else {
+				for i := range m.tabs {
+					if zone.Get(fmt.Sprintf("request_tab_%d", i)).InBounds(msg) {
+						return m, m.switchTab(i)
+					}
+				}
 			}
 		}
 
@@ -340,7 +435,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// If we are showing a popup, we need to update the popup
 	if m.popup != nil {
 		m.popup, cmd = m.popup.Update(msg)
-		return m, cmd
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
 	}
 	// ----------------------------
 
@@ -349,10 +445,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case app.SetFocusMsg:
 		m.SetFocused(msg.Item)
 
+	case tabsRestoredMsg:
+		if len(msg.calls) == 0 {
+			return m, nil
+		}
+		m.tabs = make([]requestTab, len(msg.calls))
+		for i, call := range msg.calls {
+			m.tabs[i].call = call
+		}
+		m.activeTab = msg.active
+		if call := msg.calls[msg.active]; call != nil {
+			app.GetInstance().SelectedCall = call
+			return m, func() tea.Msg { return app.CallSelectedMsg{Call: call} }
+		}
+		return m, nil
+
 	case app.CollectionEditMsg:
 		m.popup = collections.NewForm(*msg.Collection, m.GetFadedView(), 70)
 		return m, m.popup.Init()
 
+	case app.FolderEditMsg:
+		m.popup = collections.NewFolderForm(*msg.Collection, msg.Path, m.GetFadedView())
+		return m, m.popup.Init()
+
+	case app.RunCollectionMsg:
+		m.popup = runner.New(*msg.Collection, m.GetFadedView(), 100)
+		return m, m.popup.Init()
+
+	case app.MockCollectionMsg:
+		m.popup = mock.New(*msg.Collection, m.GetFadedView(), 100)
+		return m, m.popup.Init()
+
+	case app.BenchCallMsg:
+		m.popup = bench.New(*msg.Call, m.GetFadedView(), 100)
+		return m, m.popup.Init()
+
 	case tea.KeyMsg:
 		{
 			switch msg.String() {
@@ -366,6 +493,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, tea.Quit
 				}
 
+				if calls := m.unsavedCalls(); len(calls) > 0 {
+					return m, m.confirmUnsaved(quitAction, calls)
+				}
+
 				width := 100
 				m.popup = popup.NewChoice(m.GetFadedView(), width, "Are you sure, you want to quit?", false)
 				return m, m.popup.Init()
@@ -391,6 +522,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.popup = coll
 				return m, m.popup.Init()
 
+			case "alt+e":
+				return m, app.GetInstance().NextEnvironment()
+
+			case "alt+t":
+				return m, m.newTab()
+
+			case "alt+w":
+				if call := m.currentCall(); app.GetInstance().HasUnsavedChanges(call) {
+					return m, m.confirmUnsaved(closeTabAction, []*app.Call{call})
+				}
+				return m, m.closeTab()
+
+			case "alt+l":
+				return m, m.switchTab((m.activeTab + 1) % len(m.tabs))
+
+			case "alt+h":
+				return m, m.switchTab((m.activeTab - 1 + len(m.tabs)) % len(m.tabs))
+
+			case "alt+r":
+				return m, m.revertCall()
+
+			case "alt+b":
+				if call := app.GetInstance().SelectedCall; call != nil && call.IsValid() {
+					return m, func() tea.Msg { return app.BenchCallMsg{Call: call} }
+				}
+				return m, nil
+
 			case "tab":
 				m, cmd := m.Next()
 				return m, cmd
@@ -426,6 +584,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.tui.UpdateSize(msg)
 
 	default:
+		// responses of a tab in the background go to its panes
+		if call := app.MessageCall(msg); call != nil {
+			if i := m.tabOf(call); i >= 0 && i != m.activeTab {
+				return m, tea.Batch(append(cmds, m.updateTab(i, msg))...)
+			}
+		}
+
 		var cmd tea.Cmd
 		for key, element := range m.tui.ModelMap {
 			m.tui.ModelMap[key], cmd = element.Update(msg)
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"restman/app"
+	"restman/components/config"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var mockCmd = &cobra.Command{
+	Use:   "mock <collection>",
+	Short: "Serve a collection from a local mock server",
+	Long: `Start a local HTTP server answering the calls of a collection, routed by
+their method and path template.
+
+Calls are answered with their saved examples, the first successful one by
+default. Send an X-Mock-Status or X-Mock-Example header to pick another one.
+Calls without examples are answered with data generated from the OpenAPI
+spec the collection was imported from.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.SetVersion(version)
+		readConfig()
+
+		a := readCollections()
+		collection := a.FindCollection(args[0])
+		if collection == nil {
+			return fmt.Errorf("collection %q not found", args[0])
+		}
+
+		opts := app.MockOptions{}
+		opts.Latency, _ = cmd.Flags().GetDuration("latency")
+		opts.Jitter, _ = cmd.Flags().GetDuration("jitter")
+		opts.ErrorRate, _ = cmd.Flags().GetFloat64("error-rate")
+		opts.ErrorStatus, _ = cmd.Flags().GetInt("error-status")
+		opts.Spec, _ = cmd.Flags().GetString("spec")
+		if opts.ErrorRate < 0 || opts.ErrorRate > 1 {
+			return fmt.Errorf("the error rate must be between 0 and 1")
+		}
+
+		mock, err := app.NewMockServer(*collection, opts, printMockRequest)
+		if err != nil {
+			return err
+		}
+		host, _ := cmd.Flags().GetString("host")
+		port, _ := cmd.Flags().GetInt("port")
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Mocking %s on http://%s\n\n", collection.Name, listener.Addr())
+		for _, route := range mock.Routes() {
+			fmt.Println(runGrayStyle.Render("  " + route))
+		}
+		fmt.Println()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		server := &http.Server{Handler: mock}
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdown)
+		}()
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	mockCmd.Flags().IntP("port", "p", 8080, "Port to listen on")
+	mockCmd.Flags().String("host", "127.0.0.1", "Address to listen on, 0.0.0.0 for every interface")
+	mockCmd.Flags().Duration("latency", 0, "Delay of every response, e.g. 200ms")
+	mockCmd.Flags().Duration("jitter", 0, "Random extra delay of up to this duration")
+	mockCmd.Flags().Float64("error-rate", 0, "Share of requests answered with an error, from 0 to 1")
+	mockCmd.Flags().Int("error-status", http.StatusInternalServerError, "Status code of injected errors")
+	mockCmd.Flags().String("spec", "", "OpenAPI spec `file or URL` to generate responses from")
+	rootCmd.AddCommand(mockCmd)
+}
+
+// printMockRequest prints a line of the request log
+func printMockRequest(entry app.MockLogEntry) {
+	status := runPassedStyle.Render(strconv.Itoa(entry.Status))
+	if entry.Status >= 400 {
+		status = runFailedStyle.Render(strconv.Itoa(entry.Status))
+	}
+	source := entry.Source
+	if entry.Call != "" {
+		source = entry.Call + " · " + source
+	}
+	fmt.Printf("%s %-7s %-40s %s %-8s %s\n",
+		entry.Time.Format("15:04:05"), entry.Method, entry.Path, status,
+		entry.Duration.Round(time.Millisecond), runGrayStyle.Render(source))
+}
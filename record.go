@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"restman/app"
+	"restman/components/config"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record the traffic of a client into a new collection",
+	Long: `Run a local HTTP proxy and record every request which goes through it.
+
+Point the client to the proxy, e.g. with HTTP_PROXY and HTTPS_PROXY, or start
+it with --target to forward plain requests to a server. HTTPS is intercepted
+with a locally generated CA, which the client has to trust. When the proxy is
+stopped with ctrl+c the recorded requests are saved as a new collection, in a
+folder per host, with the responses as examples.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.SetVersion(version)
+		readConfig()
+
+		a := readCollections()
+
+		opts := app.RecordOptions{}
+		opts.Target, _ = cmd.Flags().GetString("target")
+		opts.Hosts, _ = cmd.Flags().GetStringArray("host")
+		opts.Paths, _ = cmd.Flags().GetStringArray("path")
+		if intercept, _ := cmd.Flags().GetBool("intercept"); intercept {
+			configDir, _ := os.UserConfigDir()
+			ca, certPath, err := app.LoadOrCreateCA(filepath.Join(configDir, "restman"))
+			if err != nil {
+				return fmt.Errorf("could not load the CA: %w", err)
+			}
+			opts.CA = ca
+			fmt.Println(runGrayStyle.Render("HTTPS is intercepted, trust the CA in " + certPath))
+		}
+
+		proxy, err := app.NewRecordingProxy(opts, printRecordedExchange)
+		if err != nil {
+			return err
+		}
+		listen, _ := cmd.Flags().GetString("listen")
+		listener, err := net.Listen("tcp", listen)
+		if err != nil {
+			return err
+		}
+		if opts.Target != "" {
+			fmt.Printf("Recording requests to %s on http://%s, ctrl+c to stop\n\n", opts.Target, listener.Addr())
+		} else {
+			fmt.Printf("Recording through the proxy http://%s, ctrl+c to stop\n\n", listener.Addr())
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		server := &http.Server{Handler: proxy}
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdown)
+		}()
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			return err
+		}
+
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = "Recorded " + time.Now().Format("2006-01-02 15:04")
+		}
+		collection := proxy.Collection(name)
+		if len(collection.Calls) == 0 {
+			fmt.Println("\nNothing was recorded")
+			return nil
+		}
+		a.CreateCollection(collection)()
+		fmt.Printf("\nSaved %d calls of %d requests to %q\n", len(collection.Calls), len(proxy.Exchanges()), name)
+		return nil
+	},
+}
+
+func init() {
+	recordCmd.Flags().StringP("listen", "l", ":8888", "Address the proxy listens on")
+	recordCmd.Flags().String("target", "", "Forward requests to this `URL`, as a reverse proxy")
+	recordCmd.Flags().StringArray("host", []string{}, "Only record hosts matching this pattern, e.g. *.example.com, can be repeated")
+	recordCmd.Flags().StringArray("path", []string{}, "Only record paths starting with or matching this pattern, can be repeated")
+	recordCmd.Flags().StringP("name", "n", "", "Name of the new collection")
+	recordCmd.Flags().Bool("intercept", true, "Intercept HTTPS with the local CA, --intercept=false tunnels it without recording")
+	rootCmd.AddCommand(recordCmd)
+}
+
+// printRecordedExchange prints a line of the proxy log, exchanges which are
+// not recorded are grayed out
+func printRecordedExchange(exchange app.RecordedExchange) {
+	status := strconv.Itoa(exchange.Response.Status)
+	if exchange.Err != nil {
+		status = runFailedStyle.Render(exchange.Err.Error())
+	} else if exchange.Response.Status >= 400 {
+		status = runFailedStyle.Render(status)
+	} else {
+		status = runPassedStyle.Render(status)
+	}
+	line := fmt.Sprintf("%s %-7s %s %s %s", exchange.Time.Format("15:04:05"), exchange.Method, exchange.URL, status, exchange.Duration.Round(time.Millisecond))
+	if !exchange.Recorded {
+		line = runGrayStyle.Render(line)
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"restman/app"
+	"restman/components/config"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runPassedStyle = lipgloss.NewStyle().Foreground(config.COLOR_SPECIAL)
+	runFailedStyle = lipgloss.NewStyle().Foreground(config.COLOR_ERROR)
+	runGrayStyle   = lipgloss.NewStyle().Foreground(config.COLOR_GRAY)
+)
+
+// errCallsFailed is returned by run when the collection ran but some calls
+// failed, restman exits with 1 instead of 2
+var errCallsFailed = errors.New("some calls failed")
+
+var runCmd = &cobra.Command{
+	Use:   "run <collection>",
+	Short: "Run the calls of a collection and report the results",
+	Long: `Run the calls of a collection and report the results.
+
+Reports can be written as JUnit XML, TAP and JSON at the same time, use - to
+write a report to stdout. Restman exits with 0 when every call passed, 1 when
+a call failed and 2 when the collection could not be run.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.SetVersion(version)
+		readConfig()
+
+		a := readCollections()
+		collection := a.FindCollection(args[0])
+		if collection == nil {
+			return fmt.Errorf("collection %q not found", args[0])
+		}
+
+		opts := app.RunOptions{}
+		opts.Folder, _ = cmd.Flags().GetString("folder")
+		opts.Tags, _ = cmd.Flags().GetStringArray("tag")
+		opts.StopOnFailure, _ = cmd.Flags().GetBool("stop-on-failure")
+		opts.Delay, _ = cmd.Flags().GetDuration("delay")
+		opts.Iterations, _ = cmd.Flags().GetInt("iterations")
+		opts.Environment, _ = cmd.Flags().GetString("env")
+		if err := readDataFlag(cmd, &opts); err != nil {
+			return err
+		}
+
+		// the progress table moves out of the way of reports on stdout
+		reports := reportFlags(cmd)
+		var out io.Writer = os.Stdout
+		if reportsToStdout(reports) {
+			out = os.Stderr
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		defer a.Cleanup()
+
+		fmt.Fprintln(out, runGrayStyle.Render(runRow("#", "Call", "Status", "Time", "Result")))
+		report := app.Report{Collection: collection.Name, Started: time.Now()}
+		report.Results, report.Err = a.Run(ctx, *collection, opts, func(r app.RunResult) { printRunResult(out, r) })
+		report.Duration = time.Since(report.Started)
+		if err := saveVariablesFlag(cmd, a, report.Results); err != nil {
+			return err
+		}
+
+		return finishRun(out, report, reports)
+	},
+}
+
+func init() {
+	runCmd.Flags().String("folder", "", "Only run the calls in this folder")
+	runCmd.Flags().StringArray("tag", []string{}, "Only run the calls with this tag, can be repeated")
+	runCmd.Flags().Bool("stop-on-failure", false, "Stop at the first call which fails")
+	runCmd.Flags().Duration("delay", 0, "Delay between requests, e.g. 500ms")
+	runCmd.Flags().IntP("iterations", "n", 1, "Number of times the calls are run")
+	runCmd.Flags().StringP("env", "e", "", "Environment to use instead of the selected one")
+	runCmd.Flags().StringP("data", "d", "", "CSV or JSON data `file`, the calls run once per row")
+	runCmd.Flags().Bool("save-variables", false, "Store the variables set by the calls, they are only kept for the run otherwise")
+	addReportFlags(runCmd)
+	rootCmd.AddCommand(runCmd)
+}
+
+func addReportFlags(cmd *cobra.Command) {
+	cmd.Flags().String(app.REPORT_JUNIT, "", "Write a JUnit XML report to `file`")
+	cmd.Flags().String(app.REPORT_TAP, "", "Write a TAP report to `file`")
+	cmd.Flags().String(app.REPORT_JSON, "", "Write a JSON report to `file`")
+}
+
+// reportFlags returns the path of every report asked for by format
+func reportFlags(cmd *cobra.Command) map[string]string {
+	reports := map[string]string{}
+	for _, format := range []string{app.REPORT_JUNIT, app.REPORT_TAP, app.REPORT_JSON} {
+		if path, _ := cmd.Flags().GetString(format); path != "" {
+			reports[format] = path
+		}
+	}
+	return reports
+}
+
+func reportsToStdout(reports map[string]string) bool {
+	for _, path := range reports {
+		if path == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// readDataFlag reads the rows of the data file given with --data
+func readDataFlag(cmd *cobra.Command, opts *app.RunOptions) error {
+	path, _ := cmd.Flags().GetString("data")
+	if path == "" {
+		return nil
+	}
+	rows, err := app.ReadDataFile(path)
+	if err != nil {
+		return err
+	}
+	opts.Data = rows
+	return nil
+}
+
+// saveVariablesFlag stores the variables set by the calls of a run when
+// --save-variables is given
+func saveVariablesFlag(cmd *cobra.Command, a *app.App, results []app.RunResult) error {
+	if save, _ := cmd.Flags().GetBool("save-variables"); !save {
+		return nil
+	}
+	return a.SaveVariables(results)
+}
+
+// finishRun writes the reports and prints the summary of a run
+func finishRun(out io.Writer, report app.Report, reports map[string]string) error {
+	// reports are written even for runs which could not finish
+	for format, path := range reports {
+		if err := writeReport(report, format, path); err != nil {
+			return err
+		}
+	}
+	if report.Err != nil {
+		return report.Err
+	}
+
+	fmt.Fprintf(out, "\n%d/%d passed\n", report.Passed(), len(report.Results))
+	if report.Failed() {
+		return errCallsFailed
+	}
+	return nil
+}
+
+func writeReport(report app.Report, format string, path string) error {
+	if path == "-" {
+		return report.Write(os.Stdout, format)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exitCode maps the error of a command to the exit code of restman
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errCallsFailed):
+		return 1
+	}
+	return 2
+}
+
+func runRow(iteration, name, status, duration, result string) string {
+	return fmt.Sprintf("%-4s %-40s %-8s %-10s %s", iteration, name, status, duration, result)
+}
+
+// printRunResult prints a row of the progress table as soon as a call is done
+func printRunResult(out io.Writer, r app.RunResult) {
+	status := strconv.Itoa(r.Status)
+	result := runPassedStyle.Render("✓ passed")
+	if !r.Passed() {
+		result = runFailedStyle.Render("✗ failed")
+	}
+	if r.Err != nil {
+		status = "-"
+		result = runFailedStyle.Render("✗ " + r.Err.Error())
+	}
+	fmt.Fprintln(out, runRow(strconv.Itoa(r.Iteration), r.Call.Title(), status, r.Duration.Round(time.Millisecond).String(), result))
+
+	for _, assertion := range r.Assertions {
+		if !assertion.Passed {
+			fmt.Fprintln(out, runGrayStyle.Render("     ✗ "+assertion.Assertion.String()+" · "+assertion.Message))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"restman/app"
+	"restman/components/config"
+	"restman/components/popup"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// askPassphrase asks for the passphrase of the secrets store, err is the
+// reason the previous one was refused. An open popup is not replaced.
+func (m *Model) askPassphrase(err error) tea.Cmd {
+	if m.popup != nil {
+		return nil
+	}
+
+	question := "Enter the passphrase to unlock the secrets"
+	if !app.GetInstance().SecretsExist() {
+		question = "Choose a passphrase for the secrets store"
+	}
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	m.popup = popup.NewPassphrase(m.GetFadedView(), 100, question, message)
+	return m.popup.Init()
+}
+
+// unlockSecrets unlocks the secrets with the passphrase entered, the
+// collections waiting for them are saved then
+func (m *Model) unlockSecrets(passphrase string) tea.Cmd {
+	a := app.GetInstance()
+	if err := a.UnlockSecrets(passphrase); err != nil {
+		m.popup = nil
+		return m.askPassphrase(err)
+	}
+	m.popup = nil
+	return a.SaveCollections()
+}
+
+// readPassphrase reads the passphrase of the secrets store from
+// RESTMAN_SECRETS_PASSPHRASE, or asks for it on the terminal
+func readPassphrase(prompt string) (string, error) {
+	if passphrase := os.Getenv("RESTMAN_SECRETS_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("set RESTMAN_SECRETS_PASSPHRASE or secrets_key_file to unlock the secrets")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(passphrase), err
+}
+
+// unlockSecrets unlocks the secrets store for the commands, the store is
+// created when create is set and there is none
+func unlockSecrets(a *app.App, create bool) error {
+	if a.SecretsUnlocked() || (!create && !a.SecretsExist()) {
+		return nil
+	}
+	prompt := "Passphrase of the secrets: "
+	if !a.SecretsExist() {
+		prompt = "New passphrase for the secrets: "
+	}
+	passphrase, err := readPassphrase(prompt)
+	if err != nil {
+		return err
+	}
+	return a.UnlockSecrets(passphrase)
+}
+
+// readSecret reads the value of a secret from stdin, without echoing it when
+// typed on the terminal
+func readSecret(name string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		value, err := io.ReadAll(bufio.NewReader(os.Stdin))
+		return strings.TrimRight(string(value), "\r\n"), err
+	}
+	fmt.Fprintf(os.Stderr, "Value of %s: ", name)
+	value, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(value), err
+}
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the encrypted secrets",
+	Long: `Manage the secrets store, credentials encrypted with a master passphrase.
+Calls and environments reference them as {{secret:name}}.
+
+The passphrase is read from RESTMAN_SECRETS_PASSPHRASE, from the key file set
+as secrets_key_file in the config, or asked for.`,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:          "set <name>",
+	Short:        "Store a secret, its value is read from stdin",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := secretsApp()
+		if err := unlockSecrets(a, true); err != nil {
+			return err
+		}
+		value, err := readSecret(args[0])
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return errors.New("the value of the secret cannot be empty")
+		}
+		if err := a.SetSecret(args[0], value); err != nil {
+			return err
+		}
+		fmt.Println("Use it as", app.SecretReference(args[0]))
+		return nil
+	},
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the names of the secrets",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := secretsApp()
+		if err := unlockSecrets(a, false); err != nil {
+			return err
+		}
+		for _, name := range a.SecretNames() {
+			fmt.Println(name)
+		}
+		return nil
+	},
+}
+
+var secretsRemoveCmd = &cobra.Command{
+	Use:          "remove <name>",
+	Short:        "Remove a secret",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := secretsApp()
+		if err := unlockSecrets(a, false); err != nil {
+			return err
+		}
+		return a.RemoveSecret(args[0])
+	},
+}
+
+// secretsApp reads the config for the secrets commands, the key file set in
+// it unlocks the store
+func secretsApp() *app.App {
+	config.SetVersion(version)
+	readConfig()
+
+	a := app.GetInstance()
+	if err := a.UnlockSecretsWithKeyFile(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	return a
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsSetCmd, secretsListCmd, secretsRemoveCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
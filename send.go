@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"restman/app"
+	"restman/components/config"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var sendCmd = &cobra.Command{
+	Use:   "send <collection> <call>",
+	Short: "Send a saved call and print the response",
+	Long: `Send a saved call of a collection and print the response body.
+
+With a data file the call is sent once per row, the fields of the row are
+variables for the URL, headers, body and auth. The status of every response
+is printed to stderr, the bodies to stdout.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.SetVersion(version)
+		readConfig()
+
+		a := readCollections()
+		collection := a.FindCollection(args[0])
+		if collection == nil {
+			return fmt.Errorf("collection %q not found", args[0])
+		}
+		call := collection.FindCall(args[1])
+		if call == nil {
+			return fmt.Errorf("call %q not found in %s", args[1], collection.Name)
+		}
+
+		opts := app.RunOptions{KeepResponses: true}
+		opts.Iterations, _ = cmd.Flags().GetInt("iterations")
+		opts.Environment, _ = cmd.Flags().GetString("env")
+		if err := readDataFlag(cmd, &opts); err != nil {
+			return err
+		}
+
+		// bodies are left out when a report is written to stdout
+		reports := reportFlags(cmd)
+		var body io.Writer = os.Stdout
+		if reportsToStdout(reports) {
+			body = io.Discard
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		defer a.Cleanup()
+
+		// the collection is narrowed down to the call, its variables and
+		// scripts still apply
+		single := *collection
+		single.Calls = []app.Call{*call}
+
+		report := app.Report{Collection: collection.Name, Started: time.Now()}
+		report.Results, report.Err = a.Run(ctx, single, opts, func(r app.RunResult) {
+			printRunResult(os.Stderr, r)
+			if r.Response != nil {
+				content := r.Response.Content()
+				if !strings.HasSuffix(content, "\n") {
+					content += "\n"
+				}
+				fmt.Fprint(body, content)
+			}
+		})
+		report.Duration = time.Since(report.Started)
+		if err := saveVariablesFlag(cmd, a, report.Results); err != nil {
+			return err
+		}
+
+		return finishRun(os.Stderr, report, reports)
+	},
+}
+
+func init() {
+	sendCmd.Flags().IntP("iterations", "n", 1, "Number of times the call is sent")
+	sendCmd.Flags().StringP("env", "e", "", "Environment to use instead of the selected one")
+	sendCmd.Flags().StringP("data", "d", "", "CSV or JSON data `file`, the call is sent once per row")
+	sendCmd.Flags().Bool("save-variables", false, "Store the variables set by the call, they are only kept for the run otherwise")
+	addReportFlags(sendCmd)
+	rootCmd.AddCommand(sendCmd)
+}
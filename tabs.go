@@ -0,0 +1,193 @@
+package main
+
+import (
+	"restman/app"
+	"restman/components/request"
+	"restman/components/results"
+	"restman/components/tabs"
+	"restman/components/url"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// panes which belong to a request tab, the other panes are shared
+var tabPanes = []string{"url", "request", "results"}
+
+// requestTab is an open request tab. The panes of the active tab live in
+// the layout, the others keep theirs here with their edits, response and
+// scroll position.
+type requestTab struct {
+	call  *app.Call
+	panes map[string]tea.Model
+}
+
+// tabsRestoredMsg carries the tabs which were open when restman quit
+type tabsRestoredMsg struct {
+	calls  []*app.Call
+	active int
+}
+
+func restoreTabs() tea.Msg {
+	calls, active := app.GetInstance().ReadOpenTabs()
+	return tabsRestoredMsg{calls: calls, active: active}
+}
+
+// currentCall returns the call of the active tab, which is kept by the url
+// pane
+func (m Model) currentCall() *app.Call {
+	return m.getUrlPane().CurrentCall()
+}
+
+func (m Model) tabCall(i int) *app.Call {
+	if i == m.activeTab {
+		return m.currentCall()
+	}
+	return m.tabs[i].call
+}
+
+// tabOf returns the index of the tab showing the call, or -1
+func (m Model) tabOf(call *app.Call) int {
+	for i := range m.tabs {
+		if m.tabCall(i) == call {
+			return i
+		}
+	}
+	return -1
+}
+
+// storeTab takes the panes of the active tab out of the layout
+func (m *Model) storeTab() {
+	tab := &m.tabs[m.activeTab]
+	tab.call = m.currentCall()
+	tab.panes = map[string]tea.Model{}
+	for _, name := range tabPanes {
+		tab.panes[name] = m.tui.ModelMap[name]
+	}
+}
+
+// showTab puts the panes of the tab into the layout. Tabs which were never
+// shown, like the restored ones, get new panes showing their call.
+func (m *Model) showTab(i int) tea.Cmd {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	m.activeTab = i
+	tab := m.tabs[i]
+	app.GetInstance().SelectedCall = tab.call
+	if tab.panes != nil {
+		for name, pane := range tab.panes {
+			m.tui.ModelMap[name] = pane
+		}
+		m.tabs[i].panes = nil
+	} else {
+		m.tui.ModelMap["url"] = url.New()
+		m.tui.ModelMap["request"] = request.New()
+		m.tui.ModelMap["results"] = results.New()
+		m.tui.UpdateSize(tea.WindowSizeMsg{Width: m.tui.LayoutTree.GetWidth(), Height: m.tui.LayoutTree.GetHeight()})
+		if tab.call != nil {
+			for _, name := range tabPanes {
+				m.tui.ModelMap[name], cmd = m.tui.ModelMap[name].Update(app.CallSelectedMsg{Call: tab.call})
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+
+	// the panes keep the focus state they had when they were stored
+	_, cmd = m.SetFocused(m.focused)
+	return tea.Batch(append(cmds, cmd)...)
+}
+
+// switchTab shows the tab at index i
+func (m *Model) switchTab(i int) tea.Cmd {
+	if i == m.activeTab || i < 0 || i >= len(m.tabs) {
+		return nil
+	}
+	m.storeTab()
+	return m.showTab(i)
+}
+
+// newTab opens an empty tab
+func (m *Model) newTab() tea.Cmd {
+	m.storeTab()
+	m.tabs = append(m.tabs, requestTab{})
+	return m.showTab(len(m.tabs) - 1)
+}
+
+// closeTab closes the active tab, the last tab is replaced by an empty one
+func (m *Model) closeTab() tea.Cmd {
+	if call := m.currentCall(); call != nil {
+		app.GetInstance().Disconnect(call)
+	}
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	if len(m.tabs) == 0 {
+		m.tabs = []requestTab{{}}
+	}
+	return m.showTab(min(m.activeTab, len(m.tabs)-1))
+}
+
+// openTab shows a call selected in the collections. A call which is open
+// already is shown with its edits, an empty active tab is reused and
+// otherwise a new tab is opened. It reports whether the selection was
+// handled, otherwise the panes of the active tab have to show the call.
+func (m *Model) openTab(call *app.Call) (tea.Cmd, bool) {
+	current := m.currentCall()
+	if call == nil || call == current {
+		return nil, false
+	}
+	for i := range m.tabs {
+		if open := m.tabCall(i); open != nil && open.ID == call.ID {
+			if i == m.activeTab {
+				app.GetInstance().SelectedCall = current
+				return nil, true
+			}
+			return m.switchTab(i), true
+		}
+	}
+	if current == nil && m.getUrlPane().Value() == "" {
+		m.tabs[m.activeTab].call = call
+		return nil, false
+	}
+	m.storeTab()
+	m.tabs = append(m.tabs, requestTab{call: call})
+	return m.showTab(len(m.tabs) - 1), true
+}
+
+// updateTab passes a message to the panes of a tab which is not shown
+func (m *Model) updateTab(i int, msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	for _, name := range tabPanes {
+		if pane, ok := m.tabs[i].panes[name]; ok {
+			m.tabs[i].panes[name], cmd = pane.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// tabStrip returns the strip of tabs shown above the url
+func (m Model) tabStrip() tabs.Model {
+	strip := m.tui.ModelMap["tabs"].(tabs.Model)
+	shown := []tabs.Tab{}
+	for i := range m.tabs {
+		call := m.tabCall(i)
+		shown = append(shown, tabs.Tab{Call: call, Modified: app.GetInstance().HasUnsavedChanges(call)})
+	}
+	return strip.SetTabs(shown, m.activeTab)
+}
+
+// refreshTabs shows the open tabs in the strip and marks the calls with
+// unsaved edits for the collections list
+func (m *Model) refreshTabs() {
+	m.tui.ModelMap["tabs"] = m.tabStrip()
+	app.GetInstance().SetUnsavedCalls(m.unsavedCalls())
+}
+
+// saveTabs stores the open tabs to be restored on the next start
+func (m Model) saveTabs() error {
+	calls := []*app.Call{}
+	for i := range m.tabs {
+		calls = append(calls, m.tabCall(i))
+	}
+	return app.GetInstance().SaveOpenTabs(calls, m.activeTab)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"restman/app"
+	"restman/components/popup"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// actions which wait for the user to decide about unsaved changes
+const (
+	noAction = iota
+	quitAction
+	closeTabAction
+)
+
+// unsavedCalls returns the calls of the open tabs with unsaved edits
+func (m Model) unsavedCalls() []*app.Call {
+	calls := []*app.Call{}
+	for i := range m.tabs {
+		if call := m.tabCall(i); app.GetInstance().HasUnsavedChanges(call) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// confirmUnsaved asks whether the edits of the calls are saved or discarded
+// before the action is done
+func (m *Model) confirmUnsaved(action int, calls []*app.Call) tea.Cmd {
+	verb := "quitting"
+	if action == closeTabAction {
+		verb = "closing the tab"
+	}
+	question := fmt.Sprintf("Save changes to %d calls before %s?", len(calls), verb)
+	if len(calls) == 1 {
+		question = fmt.Sprintf("Save changes to %s before %s?", calls[0].Title(), verb)
+	}
+
+	m.pending = action
+	m.popup = popup.NewUnsaved(m.GetFadedView(), 100, question)
+	return m.popup.Init()
+}
+
+// resolveUnsaved saves or discards the edits as chosen and does the pending
+// action, unless it was cancelled
+func (m *Model) resolveUnsaved(result int) tea.Cmd {
+	action := m.pending
+	m.pending = noAction
+	if result == popup.UnsavedCancel {
+		return nil
+	}
+
+	calls := m.unsavedCalls()
+	if action == closeTabAction {
+		calls = []*app.Call{m.currentCall()}
+	}
+
+	cmds := []tea.Cmd{}
+	for _, call := range calls {
+		if result == popup.UnsavedSave {
+			cmds = append(cmds, app.GetInstance().UpdateCall(call))
+		} else {
+			app.GetInstance().RevertCall(call)
+		}
+	}
+
+	switch action {
+	case quitAction:
+		return tea.Sequence(append(cmds, tea.Quit)...)
+	case closeTabAction:
+		return tea.Sequence(append(cmds, m.closeTab())...)
+	}
+	return tea.Sequence(cmds...)
+}
+
+// revertCall drops the edits of the call in the active tab, the response
+// shown is kept
+func (m *Model) revertCall() tea.Cmd {
+	call := m.currentCall()
+	if !app.GetInstance().HasUnsavedChanges(call) {
+		return nil
+	}
+	app.GetInstance().RevertCall(call)
+
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	for _, name := range []string{"url", "request"} {
+		m.tui.ModelMap[name], cmd = m.tui.ModelMap[name].Update(app.CallSelectedMsg{Call: call})
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/base64"
 	"io"
 	"net/http"
@@ -14,13 +15,33 @@ type HTTPRequestParams struct {
 	Password string
 	Headers  map[string]string
 	Body     io.Reader
+	Context  context.Context
+	// Client sends the request, a new client is used when nil
+	Client *http.Client
+}
+
+// NewKeepAliveClient returns a client which keeps up to connections idle
+// connections per host open to be reused by the next requests
+func NewKeepAliveClient(connections int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = connections
+	transport.MaxIdleConnsPerHost = connections
+	transport.MaxConnsPerHost = connections
+	return &http.Client{Transport: transport}
 }
 
 // MakeRequest makes an HTTP request based on the given parameters
 func MakeRequest(params HTTPRequestParams) (*http.Response, error) {
-	client := &http.Client{}
+	client := params.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	ctx := params.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	// Create the request
-	req, err := http.NewRequest(params.Method, params.URL, params.Body)
+	req, err := http.NewRequestWithContext(ctx, params.Method, params.URL, params.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+// DiffLine is a single line of a text diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffLines computes a line based diff of a and b using the Myers algorithm.
+func DiffLines(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] keeps the part of v which is needed to backtrack step d
+	trace := [][]int{}
+
+	for d := 0; d <= n+m; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrackDiff(trace [][]int, a, b []string) []DiffLine {
+	x, y := len(a), len(b)
+	lines := []DiffLine{}
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		get := func(k int) int { return trace[d][k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, DiffLine{DiffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				lines = append(lines, DiffLine{DiffInsert, b[y-1]})
+			} else {
+				lines = append(lines, DiffLine{DiffDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	// lines were collected from the end
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+// Change describes a single difference between two structured values
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+// DiffJSON compares two decoded JSON values. Object keys are compared
+// regardless of their order, arrays are compared index by index.
+func DiffJSON(a, b interface{}) []Change {
+	changes := []Change{}
+	diffValues("$", a, b, &changes)
+	return changes
+}
+
+var identifierReg = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func keyPath(path string, key string) string {
+	if identifierReg.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s[%q]", path, key)
+}
+
+func diffValues(path string, a, b interface{}, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			for _, key := range unionKeys(av, bv) {
+				va, inA := av[key]
+				vb, inB := bv[key]
+				p := keyPath(path, key)
+				if !inB {
+					*changes = append(*changes, Change{Path: p, Kind: ChangeRemoved, Old: va})
+				} else if !inA {
+					*changes = append(*changes, Change{Path: p, Kind: ChangeAdded, New: vb})
+				} else {
+					diffValues(p, va, vb, changes)
+				}
+			}
+			return
+		}
+
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			for i := 0; i < len(av) || i < len(bv); i++ {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				if i >= len(bv) {
+					*changes = append(*changes, Change{Path: p, Kind: ChangeRemoved, Old: av[i]})
+				} else if i >= len(av) {
+					*changes = append(*changes, Change{Path: p, Kind: ChangeAdded, New: bv[i]})
+				} else {
+					diffValues(p, av[i], bv[i], changes)
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Kind: ChangeModified, Old: a, New: b})
+	}
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DiffHeaders compares two sets of HTTP headers, the path of each change is
+// the header name.
+func DiffHeaders(a, b map[string][]string) []Change {
+	av := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		av[k] = strings.Join(v, ", ")
+	}
+	bv := make(map[string]interface{}, len(b))
+	for k, v := range b {
+		bv[k] = strings.Join(v, ", ")
+	}
+
+	changes := []Change{}
+	for _, key := range unionKeys(av, bv) {
+		va, inA := av[key]
+		vb, inB := bv[key]
+		if !inB {
+			changes = append(changes, Change{Path: key, Kind: ChangeRemoved, Old: va})
+		} else if !inA {
+			changes = append(changes, Change{Path: key, Kind: ChangeAdded, New: vb})
+		} else if va != vb {
+			changes = append(changes, Change{Path: key, Kind: ChangeModified, Old: va, New: vb})
+		}
+	}
+	return changes
+}
+
+// FormatValue renders a decoded JSON value in a compact form
+func FormatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []DiffLine
+	}{
+		{
+			name: "Equal lines",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}},
+		},
+		{
+			name: "Changed line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "x", "c"},
+			want: []DiffLine{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffInsert, "x"}, {DiffEqual, "c"}},
+		},
+		{
+			name: "Added lines",
+			a:    []string{},
+			b:    []string{"a", "b"},
+			want: []DiffLine{{DiffInsert, "a"}, {DiffInsert, "b"}},
+		},
+		{
+			name: "Removed lines",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"b"},
+			want: []DiffLine{{DiffDelete, "a"}, {DiffEqual, "b"}, {DiffDelete, "c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func decode(s string) interface{} {
+	var v interface{}
+	json.Unmarshal([]byte(s), &v)
+	return v
+}
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []Change
+	}{
+		{
+			name: "Ignore key order",
+			a:    `{"a": 1, "b": 2}`,
+			b:    `{"b": 2, "a": 1}`,
+			want: []Change{},
+		},
+		{
+			name: "Detect added, removed and modified keys",
+			a:    `{"a": 1, "b": {"c": "x"}, "d": true}`,
+			b:    `{"a": 2, "b": {"c": "x", "e": null}, "my key": 1}`,
+			want: []Change{
+				{Path: "$.a", Kind: ChangeModified, Old: 1.0, New: 2.0},
+				{Path: "$.b.e", Kind: ChangeAdded, New: nil},
+				{Path: "$.d", Kind: ChangeRemoved, Old: true},
+				{Path: `$["my key"]`, Kind: ChangeAdded, New: 1.0},
+			},
+		},
+		{
+			name: "Compare arrays by index",
+			a:    `[1, 2]`,
+			b:    `[1, 3, 4]`,
+			want: []Change{
+				{Path: "$[1]", Kind: ChangeModified, Old: 2.0, New: 3.0},
+				{Path: "$[2]", Kind: ChangeAdded, New: 4.0},
+			},
+		},
+		{
+			name: "Type change",
+			a:    `{"a": [1]}`,
+			b:    `{"a": {"0": 1}}`,
+			want: []Change{
+				{Path: "$.a", Kind: ChangeModified, Old: []interface{}{1.0}, New: map[string]interface{}{"0": 1.0}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffJSON(decode(tt.a), decode(tt.b)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffHeaders(t *testing.T) {
+	a := map[string][]string{"Content-Type": {"application/json"}, "X-Old": {"1"}, "Date": {"Mon"}}
+	b := map[string][]string{"Content-Type": {"application/json"}, "X-New": {"2"}, "Date": {"Tue"}}
+
+	want := []Change{
+		{Path: "Date", Kind: ChangeModified, Old: "Mon", New: "Tue"},
+		{Path: "X-New", Kind: ChangeAdded, New: "2"},
+		{Path: "X-Old", Kind: ChangeRemoved, Old: "1"},
+	}
+	if got := DiffHeaders(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffHeaders() = %v, want %v", got, want)
+	}
+}
@@ -33,11 +33,15 @@ func RemoveTempFile(file *os.File) error {
 }
 
 func OpenInEditorCommand(file *os.File) *exec.Cmd {
+	return OpenPathInEditorCommand(file.Name())
+}
+
+func OpenPathInEditorCommand(path string) *exec.Cmd {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		editor = "vim"
 	}
-	return exec.Command(editor, file.Name())
+	return exec.Command(editor, path)
 }
 
 func DownloadToTempFile(url string) (string, error) {
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type pathStep struct {
+	key       string
+	index     *int
+	wildcard  bool
+	recursive bool
+	slice     *[2]*int
+}
+
+// parseJSONPath splits a path like $.items[0].name, $..id or $['a key'][*]
+// into steps
+func parseJSONPath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with $")
+	}
+	steps := []pathStep{}
+	rest := path[1:]
+
+	for rest != "" {
+		step := pathStep{}
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				// $..[0] is handled by the bracket below
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(rest, "."):
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, fmt.Errorf("empty name in %s", path)
+			}
+			if name == "*" {
+				step.wildcard = true
+			} else {
+				step.key = name
+			}
+			steps = append(steps, step)
+			continue
+		case !strings.HasPrefix(rest, "["):
+			return nil, fmt.Errorf("unexpected %q in %s", rest, path)
+		}
+
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("missing ] in %s", path)
+		}
+		inner := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+
+		switch {
+		case inner == "*":
+			step.wildcard = true
+		case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+			step.key = inner[1 : len(inner)-1]
+		case strings.Contains(inner, ":"):
+			bounds := [2]*int{}
+			for i, part := range strings.SplitN(inner, ":", 2) {
+				if part = strings.TrimSpace(part); part == "" {
+					continue
+				}
+				n, err := strconv.Atoi(part)
+				if err != nil {
+					return nil, fmt.Errorf("invalid slice [%s] in %s", inner, path)
+				}
+				bounds[i] = &n
+			}
+			step.slice = &bounds
+		default:
+			n, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index [%s] in %s", inner, path)
+			}
+			step.index = &n
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (s pathStep) apply(value interface{}) []interface{} {
+	matches := []interface{}{}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				matches = append(matches, v[k])
+			}
+		} else if s.index == nil && s.slice == nil {
+			if child, ok := v[s.key]; ok {
+				matches = append(matches, child)
+			}
+		}
+
+	case []interface{}:
+		switch {
+		case s.wildcard:
+			matches = append(matches, v...)
+		case s.index != nil:
+			i := *s.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				matches = append(matches, v[i])
+			}
+		case s.slice != nil:
+			start, end := 0, len(v)
+			if s.slice[0] != nil {
+				start = *s.slice[0]
+			}
+			if s.slice[1] != nil {
+				end = *s.slice[1]
+			}
+			if start < 0 {
+				start += len(v)
+			}
+			if end < 0 {
+				end += len(v)
+			}
+			start = min(max(start, 0), len(v))
+			end = min(max(end, start), len(v))
+			matches = append(matches, v[start:end]...)
+		}
+	}
+	return matches
+}
+
+// descendants returns the value and everything nested in it
+func descendants(value interface{}) []interface{} {
+	values := []interface{}{value}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = append(values, descendants(v[k])...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			values = append(values, descendants(child)...)
+		}
+	}
+	return values
+}
+
+// JSONPath returns the values selected by the path in a decoded JSON
+// document. Dot and bracket names, indexes, slices, wildcards and recursive
+// descent are supported.
+func JSONPath(document interface{}, path string) ([]interface{}, error) {
+	steps, err := parseJSONPath(strings.TrimSpace(path))
+	if err != nil {
+		return nil, err
+	}
+
+	current := []interface{}{document}
+	for _, step := range steps {
+		next := []interface{}{}
+		for _, value := range current {
+			if step.recursive {
+				for _, d := range descendants(value) {
+					next = append(next, step.apply(d)...)
+				}
+			} else {
+				next = append(next, step.apply(value)...)
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	var document interface{}
+	json.Unmarshal([]byte(`{
+		"name": "store",
+		"my key": true,
+		"items": [
+			{"id": 1, "tags": ["a", "b"]},
+			{"id": 2, "tags": []},
+			{"id": 3, "child": {"id": 4}}
+		]
+	}`), &document)
+
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		{"$", []interface{}{document}},
+		{"$.name", []interface{}{"store"}},
+		{"$['my key']", []interface{}{true}},
+		{"$.items[0].id", []interface{}{1.0}},
+		{"$.items[-1].id", []interface{}{3.0}},
+		{"$.items[*].id", []interface{}{1.0, 2.0, 3.0}},
+		{"$.items[0:2].id", []interface{}{1.0, 2.0}},
+		{"$.items[1:].id", []interface{}{2.0, 3.0}},
+		{"$.items[0].tags[1]", []interface{}{"b"}},
+		{"$..id", []interface{}{1.0, 2.0, 3.0, 4.0}},
+		{"$..tags[0]", []interface{}{"a"}},
+		{"$.missing", []interface{}{}},
+		{"$.items[10]", []interface{}{}},
+	}
+
+	for _, test := range tests {
+		got, err := JSONPath(document, test.path)
+		if err != nil {
+			t.Errorf("JSONPath(%s) error = %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("JSONPath(%s) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	for _, path := range []string{"name", "$.", "$.items[", "$.items[x]", "$name"} {
+		if _, err := JSONPath(nil, path); err == nil {
+			t.Errorf("JSONPath(%s) should fail", path)
+		}
+	}
+}
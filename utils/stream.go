@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SpillBuffer is an io.Writer that keeps data in memory until it grows past
+// limit bytes, then moves everything written so far into a temp file and
+// keeps appending there.
+type SpillBuffer struct {
+	limit int64
+	size  int64
+	buf   bytes.Buffer
+	file  *os.File
+}
+
+func NewSpillBuffer(limit int64) *SpillBuffer {
+	return &SpillBuffer{limit: limit}
+}
+
+func (s *SpillBuffer) Write(p []byte) (int, error) {
+	if s.file == nil && s.size+int64(len(p)) > s.limit {
+		file, err := os.CreateTemp("", "restman_body_*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		if _, err := s.buf.WriteTo(file); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return 0, fmt.Errorf("failed to write to temp file: %w", err)
+		}
+		s.file = file
+	}
+
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+// Size returns the number of bytes written so far.
+func (s *SpillBuffer) Size() int64 {
+	return s.size
+}
+
+// Spilled reports whether the data was moved to a temp file.
+func (s *SpillBuffer) Spilled() bool {
+	return s.file != nil
+}
+
+// String returns the in-memory content, it is empty once the buffer spilled.
+func (s *SpillBuffer) String() string {
+	return s.buf.String()
+}
+
+// Close closes the temp file (if any) and returns its path.
+func (s *SpillBuffer) Close() (string, error) {
+	if s.file == nil {
+		return "", nil
+	}
+	return s.file.Name(), s.file.Close()
+}
+
+// ProgressReader wraps a reader and reports the total number of bytes read
+// after every read.
+type ProgressReader struct {
+	Reader     io.Reader
+	OnProgress func(total int64)
+	total      int64
+}
+
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.total += int64(n)
+	if n > 0 && r.OnProgress != nil {
+		r.OnProgress(r.total)
+	}
+	return n, err
+}
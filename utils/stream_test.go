@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpillBuffer(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int64
+		chunks      []string
+		wantSpilled bool
+	}{
+		{
+			name:        "Keep small body in memory",
+			limit:       10,
+			chunks:      []string{"hello"},
+			wantSpilled: false,
+		},
+		{
+			name:        "Keep body equal to limit in memory",
+			limit:       10,
+			chunks:      []string{"hello", "world"},
+			wantSpilled: false,
+		},
+		{
+			name:        "Spill body over limit",
+			limit:       10,
+			chunks:      []string{"hello", "world", "!"},
+			wantSpilled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSpillBuffer(tt.limit)
+			for _, c := range tt.chunks {
+				if _, err := s.Write([]byte(c)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			want := strings.Join(tt.chunks, "")
+			if s.Size() != int64(len(want)) {
+				t.Errorf("Size() = %d, want %d", s.Size(), len(want))
+			}
+			if s.Spilled() != tt.wantSpilled {
+				t.Errorf("Spilled() = %v, want %v", s.Spilled(), tt.wantSpilled)
+			}
+
+			path, err := s.Close()
+			if err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if !tt.wantSpilled {
+				if s.String() != want {
+					t.Errorf("String() = %q, want %q", s.String(), want)
+				}
+				return
+			}
+			defer os.Remove(path)
+			content, _ := os.ReadFile(path)
+			if string(content) != want {
+				t.Errorf("file content = %q, want %q", content, want)
+			}
+			if s.String() != "" {
+				t.Errorf("String() = %q, want empty after spill", s.String())
+			}
+		})
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var last int64
+	r := &ProgressReader{
+		Reader:     strings.NewReader("hello world"),
+		OnProgress: func(total int64) { last = total },
+	}
+	io.Copy(io.Discard, r)
+	if last != 11 {
+		t.Errorf("OnProgress total = %d, want 11", last)
+	}
+}
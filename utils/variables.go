@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var variableReg = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.:-]+)\s*\}\}`)
+
+// SubstituteVariables replaces {{name}} references with their values,
+// unknown variables are left untouched
+func SubstituteVariables(s string, variables map[string]string) string {
+	if len(variables) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return variableReg.ReplaceAllStringFunc(s, func(match string) string {
+		name := variableReg.FindStringSubmatch(match)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return match
+	})
+}
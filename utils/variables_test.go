@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestSubstituteVariables(t *testing.T) {
+	variables := map[string]string{"token": "abc", "user.id": "42", "empty": ""}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Bearer {{token}}", "Bearer abc"},
+		{"/users/{{ user.id }}/posts", "/users/42/posts"},
+		{"{{token}}{{token}}", "abcabc"},
+		{"x{{empty}}y", "xy"},
+		{"{{unknown}} stays", "{{unknown}} stays"},
+		{"{{BASE_URL}}/path", "{{BASE_URL}}/path"},
+		{"no variables", "no variables"},
+		{"{ {token} }", "{ {token} }"},
+	}
+
+	for _, test := range tests {
+		if got := SubstituteVariables(test.input, variables); got != test.want {
+			t.Errorf("SubstituteVariables(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}